@@ -0,0 +1,92 @@
+/*
+Copyright © 2025 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"context"
+	"io"
+	"log"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"craq-cluster/cmd/manager/gen/managerpb"
+	"craq-cluster/gen/rpcpb"
+)
+
+var appendFolder, appendFile, appendData, appendInput string
+
+// appendCmd represents the append command
+var appendCmd = &cobra.Command{
+	Use:   "append",
+	Short: "Append data to the end of a file",
+	Run: func(cmd *cobra.Command, args []string) {
+		if appendFolder == "" || appendFile == "" {
+			log.Fatalf("❌ --folder, and --file are required")
+		}
+
+		data := []byte(appendData)
+		if appendInput != "" {
+			var err error
+			if appendInput == "-" {
+				data, err = io.ReadAll(os.Stdin)
+			} else {
+				data, err = os.ReadFile(appendInput)
+			}
+			if err != nil {
+				log.Fatalf("❌ Failed to read input: %v", err)
+			}
+		}
+		if len(data) == 0 {
+			log.Fatalf("❌ nothing to append: pass --data or --input")
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		mgrConn, err := grpc.Dial("localhost:9005", grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if err != nil {
+			log.Fatalf("❌ Failed to connect to Manager: %v", err)
+		}
+		defer mgrConn.Close()
+
+		mgrClient := managerpb.NewManagerClient(mgrConn)
+		writeHead, err := mgrClient.GetWriteHead(ctx, &managerpb.KeyQuery{
+			Folder:   appendFolder,
+			FileName: appendFile,
+		})
+		if err != nil {
+			log.Fatalf("❌ Manager.GetWriteHead failed: %v", err)
+		}
+
+		writeConn, err := grpc.Dial(writeHead.Address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if err != nil {
+			log.Fatalf("❌ Failed to dial write node: %v", err)
+		}
+		defer writeConn.Close()
+
+		ack, err := rpcpb.NewNodeClient(writeConn).Append(ctx, &rpcpb.AppendReq{
+			Folder:   appendFolder,
+			FileName: appendFile,
+			Data:     data,
+		})
+		if err != nil {
+			log.Fatalf("❌ Append failed: %v", err)
+		}
+		log.Printf("✅ Appended %d bytes: Folder=%s File=%s Seq=%d Offset=%d Size=%d",
+			len(data), ack.Folder, ack.FileName, ack.Seq, ack.Offset, ack.Size)
+	},
+}
+
+func init() {
+	appendCmd.Flags().StringVar(&appendFolder, "folder", "", "Folder of the file in CRAQ")
+	appendCmd.Flags().StringVar(&appendFile, "file", "", "Name of the file to append to")
+	appendCmd.Flags().StringVar(&appendData, "data", "", "Data to append")
+	appendCmd.Flags().StringVar(&appendInput, "input", "", "Local file to append instead of --data (- for stdin)")
+
+	rootCmd.AddCommand(appendCmd)
+}
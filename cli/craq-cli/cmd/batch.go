@@ -0,0 +1,139 @@
+/*
+Copyright © 2025 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"context"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"craq-cluster/cmd/manager/gen/managerpb"
+	"craq-cluster/gen/rpcpb"
+	"craq-cluster/pkg/compression"
+)
+
+var batchFolder string
+var batchFiles []string
+var batchCompress string
+
+// batchCmd represents the batch command
+var batchCmd = &cobra.Command{
+	Use:   "batch",
+	Short: "Upload several files atomically",
+	Long: `Upload several files into one folder as a single batch. Readers see
+either all of the new versions or none of them.
+
+Every file must belong to the same chain, since a batch commits on one
+chain; the command fails before sending anything if they don't.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if batchFolder == "" || len(batchFiles) == 0 {
+			log.Fatalf("❌ --folder, and at least one --file are required")
+		}
+		if err := compression.Validate(batchCompress); err != nil {
+			log.Fatalf("❌ --compress: %v", err)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		mgrConn, err := grpc.Dial("localhost:9005", grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if err != nil {
+			log.Fatalf("❌ Failed to connect to Manager: %v", err)
+		}
+		defer mgrConn.Close()
+
+		mgrClient := managerpb.NewManagerClient(mgrConn)
+
+		var writeHead *managerpb.NodeInfo
+		for _, path := range batchFiles {
+			head, err := mgrClient.GetWriteHead(ctx, &managerpb.KeyQuery{
+				Folder:   batchFolder,
+				FileName: filepath.Base(path),
+			})
+			if err != nil {
+				log.Fatalf("❌ Manager.GetWriteHead failed for %s: %v", path, err)
+			}
+			if writeHead == nil {
+				writeHead = head
+			} else if head.NodeId != writeHead.NodeId {
+				log.Fatalf("❌ %s belongs to the chain headed by %s, %s to the one headed by %s; a batch must stay on one chain",
+					filepath.Base(batchFiles[0]), writeHead.NodeId, filepath.Base(path), head.NodeId)
+			}
+		}
+		log.Printf("📤 Head node for write: %s (%s)", writeHead.NodeId, writeHead.Address)
+
+		writeConn, err := grpc.Dial(writeHead.Address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if err != nil {
+			log.Fatalf("❌ Failed to dial write node: %v", err)
+		}
+		defer writeConn.Close()
+
+		writeClient := rpcpb.NewNodeClient(writeConn)
+		stream, err := writeClient.BatchWrite(ctx, compression.CallOptions(batchCompress)...)
+		if err != nil {
+			log.Fatalf("❌ BatchWrite failed: %v", err)
+		}
+
+		for _, path := range batchFiles {
+			sendBatchFile(stream, path)
+		}
+
+		ack, err := stream.CloseAndRecv()
+		if err != nil {
+			log.Fatalf("❌ BatchWrite close failed: %v", err)
+		}
+		for _, a := range ack.Acks {
+			log.Printf("✅ Committed: Folder=%s File=%s Seq=%d", a.Folder, a.FileName, a.Seq)
+		}
+	},
+}
+
+// sendBatchFile streams one file of the batch. Every message names the
+// file; the first one that does starts it on the server.
+func sendBatchFile(stream rpcpb.Node_BatchWriteClient, path string) {
+	file, err := os.Open(path)
+	if err != nil {
+		log.Fatalf("❌ Failed to open file: %v", err)
+	}
+	defer file.Close()
+
+	const chunkSize = 64 * 1024
+	buf := make([]byte, chunkSize)
+	sent := false
+
+	for {
+		n, err := file.Read(buf)
+		if err == io.EOF && sent {
+			break
+		}
+		if err != nil && err != io.EOF {
+			log.Fatalf("❌ File read failed: %v", err)
+		}
+
+		err = stream.Send(&rpcpb.StreamWriteReq{
+			Folder:      batchFolder,
+			FileName:    filepath.Base(path),
+			Data:        buf[:n],
+			Compression: batchCompress,
+		})
+		if err != nil {
+			log.Fatalf("❌ Send chunk failed: %v", err)
+		}
+		sent = true
+	}
+}
+
+func init() {
+	batchCmd.Flags().StringVar(&batchFolder, "folder", "", "Folder to upload to in CRAQ")
+	batchCmd.Flags().StringArrayVar(&batchFiles, "file", nil, "Local file to include (repeatable)")
+	batchCmd.Flags().StringVar(&batchCompress, "compress", "", "Compress the upload and its replication: gzip, zstd or none (default: folder setting)")
+	rootCmd.AddCommand(batchCmd)
+}
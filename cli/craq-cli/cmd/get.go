@@ -4,11 +4,15 @@ Copyright © 2025 NAME HERE <EMAIL ADDRESS>
 package cmd
 
 import (
+	"bytes"
 	"context"
 	"craq-cluster/cmd/manager/gen/managerpb"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"log"
+	"sync"
 	"time"
 
 	"github.com/spf13/cobra"
@@ -16,9 +20,13 @@ import (
 	"google.golang.org/grpc/credentials/insecure"
 
 	"craq-cluster/gen/rpcpb"
+	"craq-cluster/pkg/compression"
 )
 
 var file, fldr string
+var parallel int
+var getSnapshot string
+var getCompress string
 
 // getCmd represents the get command
 var getCmd = &cobra.Command{
@@ -28,6 +36,9 @@ var getCmd = &cobra.Command{
 		if fldr == "" || file == "" {
 			log.Fatalf("❌ --folder, and --file are required")
 		}
+		if err := compression.Validate(getCompress); err != nil {
+			log.Fatalf("❌ --compress: %v", err)
+		}
 		mgrConn, err := grpc.Dial("localhost:9005", grpc.WithTransportCredentials(insecure.NewCredentials()))
 		if err != nil {
 			log.Fatalf("❌ Failed to connect to Manager: %v", err)
@@ -41,6 +52,8 @@ var getCmd = &cobra.Command{
 
 		readResp, err := mgrClient.GetReadNode(ctx, &managerpb.ReadNodeQuery{
 			ClientId: fldr,
+			Folder:   fldr,
+			FileName: file,
 		})
 		if err != nil {
 			log.Fatalf("❌ Manager.GetReadNode failed: %v", err)
@@ -54,31 +67,59 @@ var getCmd = &cobra.Command{
 		defer readConn.Close()
 
 		readClient := rpcpb.NewNodeClient(readConn)
-		readStream, err := readClient.StreamRead(ctx, &rpcpb.StreamReadReq{
+		manifest, err := readClient.GetManifest(ctx, &rpcpb.StreamReadReq{
 			Folder:   fldr,
 			FileName: file,
+			Snapshot: getSnapshot,
 		})
 		if err != nil {
-			log.Fatalf("❌ StreamRead failed: %v", err)
+			log.Fatalf("❌ GetManifest failed: %v", err)
+		}
+		log.Printf("📜 Manifest: Seq=%d Size=%d Chunks=%d", manifest.Seq, manifest.Size, len(manifest.Chunks))
+		for k, v := range manifest.Attributes {
+			log.Printf("🏷️  %s=%s", k, v)
+		}
+
+		// Fetch content chunks in parallel, then stitch them back in order
+		parts := make([][]byte, len(manifest.Chunks))
+		sem := make(chan struct{}, max(parallel, 1))
+		var wg sync.WaitGroup
+		var fetchErr error
+		var errOnce sync.Once
+
+		for i, ref := range manifest.Chunks {
+			wg.Add(1)
+			sem <- struct{}{}
+			go func(i int, ref *rpcpb.ChunkRef) {
+				defer wg.Done()
+				defer func() { <-sem }()
+
+				req := &rpcpb.ChunkRef{
+					Id:          ref.Id,
+					Size:        ref.Size,
+					Shards:      ref.Shards,
+					DataShards:  ref.DataShards,
+					Folder:      manifest.Folder,
+					Compression: getCompress,
+				}
+				data, err := fetchChunk(ctx, readClient, req, compression.CallOptions(getCompress)...)
+				if err != nil {
+					errOnce.Do(func() { fetchErr = err })
+					return
+				}
+				log.Printf("📦 Chunk #%d received (%d bytes)", i+1, len(data))
+				parts[i] = data
+			}(i, ref)
 		}
+		wg.Wait()
+		if fetchErr != nil {
+			log.Fatalf("❌ %v", fetchErr)
+		}
+		log.Println("📦 [FetchChunk] ✅ All chunks received")
 
-		var reconstructed []byte
-		chunkNum := 0
-		totalBytes := 0
-		for {
-			chunk, err := readStream.Recv()
-			if err == io.EOF {
-				log.Println("📦 [StreamRead] ✅ All chunks received")
-				break
-			}
-			if err != nil {
-				log.Fatalf("❌ receive chunk failed: %v", err)
-			}
-
-			chunkNum++
-			totalBytes += len(chunk.Data)
-			log.Printf("📦 Chunk #%d received (%d bytes)", chunkNum, len(chunk.Data))
-			reconstructed = append(reconstructed, chunk.Data...)
+		reconstructed := bytes.Join(parts, nil)
+		if sum := sha256.Sum256(reconstructed); hex.EncodeToString(sum[:]) != manifest.Checksum {
+			log.Fatalf("❌ checksum mismatch for %s/%s", fldr, file)
 		}
 
 		fmt.Println("✅ Final Output:")
@@ -86,8 +127,37 @@ var getCmd = &cobra.Command{
 	},
 }
 
+// fetchChunk reads one content chunk. The reply is compressed as the
+// request asks, or else as the folder named in ref is set to.
+func fetchChunk(ctx context.Context, client rpcpb.NodeClient, ref *rpcpb.ChunkRef, opts ...grpc.CallOption) ([]byte, error) {
+	stream, err := client.FetchChunk(ctx, ref, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("FetchChunk %s failed: %w", ref.Id, err)
+	}
+
+	data := make([]byte, 0, ref.Size)
+	for {
+		msg, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("receive chunk %s failed: %w", ref.Id, err)
+		}
+		data = append(data, msg.Data...)
+	}
+
+	if uint64(len(data)) != ref.Size {
+		return nil, fmt.Errorf("chunk %s: got %d bytes, want %d", ref.Id, len(data), ref.Size)
+	}
+	return data, nil
+}
+
 func init() {
 	getCmd.Flags().StringVar(&fldr, "folder", "", "Folder to upload to in CRAQ")
 	getCmd.Flags().StringVar(&file, "file", "", "Local file path to upload")
+	getCmd.Flags().IntVar(&parallel, "parallel", 4, "Number of chunks to fetch concurrently")
+	getCmd.Flags().StringVar(&getSnapshot, "snapshot", "", "Read the version recorded in this snapshot")
+	getCmd.Flags().StringVar(&getCompress, "compress", "", "Fetch chunks compressed: gzip or zstd")
 	rootCmd.AddCommand(getCmd)
 }
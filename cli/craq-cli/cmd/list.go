@@ -19,6 +19,9 @@ import (
 )
 
 var folder string
+var long, recursive, desc, all bool
+var pageSize uint32
+var pageToken, pattern string
 
 // listCmd represents the list command
 var listCmd = &cobra.Command{
@@ -40,7 +43,7 @@ var listCmd = &cobra.Command{
 
 		mgrClient := managerpb.NewManagerClient(mgrConn)
 
-		readResp, err := mgrClient.GetReadNode(ctx, &managerpb.ReadNodeQuery{ClientId: folder})
+		readResp, err := mgrClient.GetReadNode(ctx, &managerpb.ReadNodeQuery{ClientId: folder, Folder: folder})
 		if err != nil {
 			log.Fatalf("❌ GetReadNode failed: %v", err)
 		}
@@ -51,23 +54,68 @@ var listCmd = &cobra.Command{
 		defer readConn.Close()
 
 		readClient := rpcpb.NewNodeClient(readConn)
-		resp, err := readClient.ListFiles(ctx, &rpcpb.FolderQuery{Folder: folder})
-		if err != nil {
-			log.Fatalf("❌ ListFiles failed: %v", err)
+		query := &rpcpb.FolderQuery{
+			Folder:    folder,
+			Recursive: recursive,
+			PageSize:  pageSize,
+			PageToken: pageToken,
+			Pattern:   pattern,
+		}
+		if desc {
+			query.Order = rpcpb.SortOrder_NAME_DESC
 		}
 
 		fmt.Printf("📂 Files in folder %s:\n", folder)
-		for _, name := range resp.FileNames {
-			if strings.HasSuffix(name, "/") {
-				fmt.Println("📁", strings.TrimSuffix(name, "/"))
-			} else {
-				fmt.Println("📄", name)
+		for {
+			resp, err := readClient.ListFiles(ctx, query)
+			if err != nil {
+				log.Fatalf("❌ ListFiles failed: %v", err)
+			}
+			printPage(resp)
+
+			if resp.NextPageToken == "" {
+				return
+			}
+			if !all {
+				fmt.Printf("… more entries: --page-token %s\n", resp.NextPageToken)
+				return
 			}
+			query.PageToken = resp.NextPageToken
 		}
 	},
 }
 
+// printPage prints one page of a listing. Entries carry the stats of the
+// files in file_names, in the same order, skipping subfolders.
+func printPage(resp *rpcpb.FileList) {
+	entries := resp.Entries
+	for _, name := range resp.FileNames {
+		if strings.HasSuffix(name, "/") {
+			fmt.Println("📁", strings.TrimSuffix(name, "/"))
+			continue
+		}
+
+		var st *rpcpb.FileStat
+		if len(entries) > 0 {
+			st, entries = entries[0], entries[1:]
+		}
+		if long && st != nil {
+			fmt.Printf("📄 %-32s %10d  seq=%-4d %-5s  %s\n", name, st.Size, st.Seq, st.State,
+				st.ModifiedAt.AsTime().Local().Format(time.RFC3339))
+		} else {
+			fmt.Println("📄", name)
+		}
+	}
+}
+
 func init() {
 	rootCmd.AddCommand(listCmd)
 	listCmd.Flags().StringVarP(&folder, "folder", "f", "", "Folder name to list files from")
+	listCmd.Flags().BoolVarP(&long, "long", "l", false, "Show size, seq, state and modified time of each file")
+	listCmd.Flags().BoolVarP(&recursive, "recursive", "r", false, "Include files in subfolders")
+	listCmd.Flags().StringVar(&pattern, "pattern", "", "Only list names matching this glob, e.g. '*.json'")
+	listCmd.Flags().BoolVar(&desc, "desc", false, "Sort names in descending order")
+	listCmd.Flags().Uint32Var(&pageSize, "page-size", 0, "Entries per page (0 = server default)")
+	listCmd.Flags().StringVar(&pageToken, "page-token", "", "Resume from the token printed by a previous page")
+	listCmd.Flags().BoolVar(&all, "all", false, "Follow page tokens until the listing is complete")
 }
@@ -0,0 +1,77 @@
+/*
+Copyright © 2025 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"context"
+	"log"
+	"path"
+	"time"
+
+	"craq-cluster/cmd/manager/gen/managerpb"
+	"craq-cluster/gen/rpcpb"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+var mkdirPath string
+var mkdirParents bool
+
+// mkdirCmd represents the mkdir command
+var mkdirCmd = &cobra.Command{
+	Use:   "mkdir",
+	Short: "Create a directory",
+	Run: func(cmd *cobra.Command, args []string) {
+		if mkdirPath == "" {
+			log.Fatalf("❌ --path is required")
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		writeClient, closeConn := dialWriteHead(ctx, mkdirPath)
+		defer closeConn()
+
+		dir, err := writeClient.Mkdir(ctx, &rpcpb.DirReq{Path: mkdirPath, Recursive: mkdirParents})
+		if err != nil {
+			log.Fatalf("❌ Mkdir failed: %v", err)
+		}
+		log.Printf("✅ Created %s", dir.Path)
+	},
+}
+
+// dialWriteHead connects to the head of the chain owning dir, the way
+// put does for files. Directory operations are keyed by parent and name.
+func dialWriteHead(ctx context.Context, dir string) (rpcpb.NodeClient, func()) {
+	mgrConn, err := grpc.Dial("localhost:9005", grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		log.Fatalf("❌ Failed to connect to Manager: %v", err)
+	}
+	defer mgrConn.Close()
+
+	mgrClient := managerpb.NewManagerClient(mgrConn)
+	dir = path.Clean("/" + dir)
+	writeHead, err := mgrClient.GetWriteHead(ctx, &managerpb.KeyQuery{
+		Folder:   path.Dir(dir),
+		FileName: path.Base(dir),
+	})
+	if err != nil {
+		log.Fatalf("❌ Manager.GetWriteHead failed: %v", err)
+	}
+	log.Printf("📤 Head node for write: %s (%s)", writeHead.NodeId, writeHead.Address)
+
+	writeConn, err := grpc.Dial(writeHead.Address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		log.Fatalf("❌ Failed to dial write node: %v", err)
+	}
+	return rpcpb.NewNodeClient(writeConn), func() { writeConn.Close() }
+}
+
+func init() {
+	mkdirCmd.Flags().StringVar(&mkdirPath, "path", "", "Directory to create, e.g. /craq/models")
+	mkdirCmd.Flags().BoolVarP(&mkdirParents, "parents", "p", false, "Create missing parent directories")
+	rootCmd.AddCommand(mkdirCmd)
+}
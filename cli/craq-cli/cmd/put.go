@@ -5,23 +5,33 @@ package cmd
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
 	"io"
 	"log"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
-	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/grpc/status"
 
 	"craq-cluster/cmd/manager/gen/managerpb"
 	"craq-cluster/gen/rpcpb"
+	"craq-cluster/pkg/compression"
 )
 
 var foldr string
 var filePath string
+var meta []string
+var putTTL time.Duration
+var putCompress string
+var putDedup bool
 
 // putCmd represents the put command
 var putCmd = &cobra.Command{
@@ -31,9 +41,21 @@ var putCmd = &cobra.Command{
 		if foldr == "" || filePath == "" {
 			log.Fatalf("❌ --folder, and --filepath are required")
 		}
+		if err := compression.Validate(putCompress); err != nil {
+			log.Fatalf("❌ --compress: %v", err)
+		}
 
 		fileName := filepath.Base(filePath)
 
+		attrs := make(map[string]string, len(meta))
+		for _, kv := range meta {
+			k, v, ok := strings.Cut(kv, "=")
+			if !ok || k == "" {
+				log.Fatalf("❌ --meta expects key=value, got %q", kv)
+			}
+			attrs[k] = v
+		}
+
 		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 		defer cancel()
 
@@ -47,7 +69,10 @@ var putCmd = &cobra.Command{
 		mgrClient := managerpb.NewManagerClient(mgrConn)
 
 		// Step 2: Ask Manager for head node
-		writeHead, err := mgrClient.GetWriteHead(ctx, &emptypb.Empty{})
+		writeHead, err := mgrClient.GetWriteHead(ctx, &managerpb.KeyQuery{
+			Folder:   foldr,
+			FileName: fileName,
+		})
 		if err != nil {
 			log.Fatalf("❌ Manager.GetWriteHead failed: %v", err)
 		}
@@ -61,10 +86,6 @@ var putCmd = &cobra.Command{
 		defer writeConn.Close()
 
 		writeClient := rpcpb.NewNodeClient(writeConn)
-		writeStream, err := writeClient.StreamWrite(ctx)
-		if err != nil {
-			log.Fatalf("❌ StreamWrite failed: %v", err)
-		}
 
 		file, err := os.Open(filePath)
 		if err != nil {
@@ -72,40 +93,137 @@ var putCmd = &cobra.Command{
 		}
 		defer file.Close()
 
-		const chunkSize = 64 * 1024
-		buf := make([]byte, chunkSize)
-
-		for {
-			n, err := file.Read(buf)
-			if err == io.EOF {
-				break
-			}
-			if err != nil {
-				log.Fatalf("❌ File read failed: %v", err)
-			}
+		// Step 4: Ask the head which content chunks of the file it already
+		// holds, under any name; those are sent by id instead of as bytes.
+		var held map[string]bool
+		if putDedup {
+			held = heldChunks(ctx, writeClient, file)
+		}
 
-			err = writeStream.Send(&rpcpb.StreamWriteReq{
-				Folder:   foldr,
-				Seq:      0,
-				FileName: fileName,
-				Path:     "", // server stores to /tmp/{chunkID}
-				Data:     buf[:n],
-			})
-			if err != nil {
-				log.Fatalf("❌ Send chunk failed: %v", err)
-			}
+		ack, err := putFile(ctx, writeClient, file, fileName, attrs, held)
+		if status.Code(err) == codes.FailedPrecondition && len(held) > 0 {
+			log.Printf("⚠️ Head no longer holds every chunk, resending all bytes: %v", err)
+			ack, err = putFile(ctx, writeClient, file, fileName, attrs, nil)
 		}
-		ack, err := writeStream.CloseAndRecv()
 		if err != nil {
-			log.Fatalf("❌ StreamWrite close failed: %v", err)
+			log.Fatalf("❌ %v", err)
 		}
 		log.Printf("✅ Write complete: Folder=%s File=%s Seq=%d", ack.Folder, ack.FileName, ack.Seq)
 	},
 }
 
+// contentChunkSize is the size nodes split files into; a chunk can only
+// be sent by id if the client cuts the file the same way.
+const contentChunkSize = 1 << 20
+
+// heldChunks returns the ids of the file's content chunks the node
+// already holds.
+func heldChunks(ctx context.Context, client rpcpb.NodeClient, file *os.File) map[string]bool {
+	var ids []string
+	err := eachContentChunk(file, func(id string, _ []byte) error {
+		ids = append(ids, id)
+		return nil
+	})
+	if err != nil {
+		log.Fatalf("❌ File read failed: %v", err)
+	}
+	missing, err := client.MissingChunks(ctx, &rpcpb.ChunkSet{Ids: ids})
+	if err != nil {
+		log.Printf("⚠️ MissingChunks failed, sending all bytes: %v", err)
+		return nil
+	}
+
+	held := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		held[id] = true
+	}
+	for _, id := range missing.Ids {
+		delete(held, id)
+	}
+	if len(held) > 0 {
+		log.Printf("🔗 Head already holds %d of %d chunks", len(held), len(ids))
+	}
+	return held
+}
+
+// eachContentChunk calls fn with the id and bytes of each content chunk
+// of file, from the start.
+func eachContentChunk(file *os.File, fn func(id string, data []byte) error) error {
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	buf := make([]byte, contentChunkSize)
+	for {
+		n, err := io.ReadFull(file, buf)
+		if n > 0 {
+			sum := sha256.Sum256(buf[:n])
+			if err := fn(hex.EncodeToString(sum[:]), buf[:n]); err != nil {
+				return err
+			}
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// putFile streams file to the head: chunks in held by id, the rest as
+// bytes.
+func putFile(ctx context.Context, client rpcpb.NodeClient, file *os.File, fileName string, attrs map[string]string, held map[string]bool) (*rpcpb.WriteAck, error) {
+	writeStream, err := client.StreamWrite(ctx, compression.CallOptions(putCompress)...)
+	if err != nil {
+		return nil, fmt.Errorf("StreamWrite failed: %w", err)
+	}
+
+	first := true
+	send := func(req *rpcpb.StreamWriteReq) error {
+		req.Folder = foldr
+		req.FileName = fileName
+		if first {
+			req.Attributes = attrs // only read from the first message
+			req.TtlSeconds = int64(putTTL / time.Second)
+			req.Compression = putCompress
+			first = false
+		}
+		return writeStream.Send(req)
+	}
+
+	const msgSize = 64 * 1024
+	err = eachContentChunk(file, func(id string, data []byte) error {
+		if held[id] {
+			return send(&rpcpb.StreamWriteReq{ChunkId: id})
+		}
+		for len(data) > 0 {
+			n := min(len(data), msgSize)
+			if err := send(&rpcpb.StreamWriteReq{Data: data[:n]}); err != nil {
+				return err
+			}
+			data = data[n:]
+		}
+		return nil
+	})
+	// A stream the node ended early reports why on close.
+	if err != nil && err != io.EOF {
+		return nil, fmt.Errorf("send chunk failed: %w", err)
+	}
+
+	ack, err := writeStream.CloseAndRecv()
+	if err != nil {
+		return nil, fmt.Errorf("StreamWrite close failed: %w", err)
+	}
+	return ack, nil
+}
+
 func init() {
 	putCmd.Flags().StringVar(&foldr, "folder", "", "Folder to upload to in CRAQ")
 	putCmd.Flags().StringVar(&filePath, "file", "", "Local file path to upload")
+	putCmd.Flags().StringArrayVar(&meta, "meta", nil, "Attribute to attach as key=value (repeatable)")
+	putCmd.Flags().DurationVar(&putTTL, "ttl", 0, "Delete the file after this long, e.g. 24h (default: folder TTL)")
+	putCmd.Flags().BoolVar(&putDedup, "dedup", true, "Send chunks the head already holds by id instead of as bytes")
+	putCmd.Flags().StringVar(&putCompress, "compress", "", "Compress the upload and its replication: gzip, zstd or none (default: folder setting)")
 
 	rootCmd.AddCommand(putCmd)
 }
@@ -0,0 +1,111 @@
+/*
+Copyright © 2025 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"craq-cluster/gen/rpcpb"
+
+	"github.com/spf13/cobra"
+)
+
+var quotaFolder string
+var quotaMaxBytes, quotaMaxFiles uint64
+
+// quotaCmd groups the quota admin subcommands
+var quotaCmd = &cobra.Command{
+	Use:   "quota",
+	Short: "Set and inspect per-folder storage quotas",
+}
+
+var quotaSetCmd = &cobra.Command{
+	Use:   "set",
+	Short: "Set the quota of a folder tree (0 = unlimited; both 0 removes it)",
+	Run: func(cmd *cobra.Command, args []string) {
+		if quotaFolder == "" {
+			log.Fatalf("❌ --folder is required")
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		client, closeConn := dialWriteHead(ctx, quotaFolder)
+		defer closeConn()
+
+		u, err := client.SetQuota(ctx, &rpcpb.Quota{Folder: quotaFolder, MaxBytes: quotaMaxBytes, MaxFiles: quotaMaxFiles})
+		if err != nil {
+			log.Fatalf("❌ SetQuota failed: %v", err)
+		}
+		printUsage(u)
+	},
+}
+
+var quotaShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Show the usage of a folder tree against its quota",
+	Run: func(cmd *cobra.Command, args []string) {
+		if quotaFolder == "" {
+			log.Fatalf("❌ --folder is required")
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		client, closeConn := dialWriteHead(ctx, quotaFolder)
+		defer closeConn()
+
+		u, err := client.GetUsage(ctx, &rpcpb.UsageReq{Folder: quotaFolder})
+		if err != nil {
+			log.Fatalf("❌ GetUsage failed: %v", err)
+		}
+		printUsage(u)
+	},
+}
+
+var quotaListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List every quota with its usage",
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		client, closeConn := dialWriteHead(ctx, "/")
+		defer closeConn()
+
+		list, err := client.ListQuotas(ctx, &rpcpb.ListQuotasReq{})
+		if err != nil {
+			log.Fatalf("❌ ListQuotas failed: %v", err)
+		}
+		for _, u := range list.Quotas {
+			printUsage(u)
+		}
+	},
+}
+
+func printUsage(u *rpcpb.QuotaUsage) {
+	fmt.Printf("📏 %s\n", u.Quota.Folder)
+	fmt.Printf("   Bytes: %d / %s\n", u.UsedBytes, limitString(u.Quota.MaxBytes))
+	fmt.Printf("   Files: %d / %s\n", u.UsedFiles, limitString(u.Quota.MaxFiles))
+}
+
+func limitString(limit uint64) string {
+	if limit == 0 {
+		return "unlimited"
+	}
+	return fmt.Sprint(limit)
+}
+
+func init() {
+	quotaSetCmd.Flags().StringVar(&quotaFolder, "folder", "", "Folder tree the quota covers")
+	quotaSetCmd.Flags().Uint64Var(&quotaMaxBytes, "max-bytes", 0, "Maximum total size of live files")
+	quotaSetCmd.Flags().Uint64Var(&quotaMaxFiles, "max-files", 0, "Maximum number of live files")
+	quotaShowCmd.Flags().StringVar(&quotaFolder, "folder", "", "Folder tree to report")
+
+	quotaCmd.AddCommand(quotaSetCmd, quotaShowCmd, quotaListCmd)
+	rootCmd.AddCommand(quotaCmd)
+}
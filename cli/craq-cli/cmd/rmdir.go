@@ -0,0 +1,46 @@
+/*
+Copyright © 2025 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"craq-cluster/gen/rpcpb"
+
+	"github.com/spf13/cobra"
+)
+
+var rmdirPath string
+var rmdirRecursive bool
+
+// rmdirCmd represents the rmdir command
+var rmdirCmd = &cobra.Command{
+	Use:   "rmdir",
+	Short: "Remove a directory",
+	Run: func(cmd *cobra.Command, args []string) {
+		if rmdirPath == "" {
+			log.Fatalf("❌ --path is required")
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		writeClient, closeConn := dialWriteHead(ctx, rmdirPath)
+		defer closeConn()
+
+		dir, err := writeClient.Rmdir(ctx, &rpcpb.DirReq{Path: rmdirPath, Recursive: rmdirRecursive})
+		if err != nil {
+			log.Fatalf("❌ Rmdir failed: %v", err)
+		}
+		log.Printf("✅ Removed %s", dir.Path)
+	},
+}
+
+func init() {
+	rmdirCmd.Flags().StringVar(&rmdirPath, "path", "", "Directory to remove")
+	rmdirCmd.Flags().BoolVarP(&rmdirRecursive, "recursive", "r", false, "Also remove subfolders and files")
+	rootCmd.AddCommand(rmdirCmd)
+}
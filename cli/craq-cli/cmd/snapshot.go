@@ -0,0 +1,97 @@
+/*
+Copyright © 2025 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"craq-cluster/gen/rpcpb"
+
+	"github.com/spf13/cobra"
+)
+
+var snapshotName, snapshotFolder string
+
+// snapshotCmd groups the snapshot subcommands
+var snapshotCmd = &cobra.Command{
+	Use:   "snapshot",
+	Short: "Create, list and delete point-in-time folder snapshots",
+}
+
+var snapshotCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Freeze the committed version of every file under a folder",
+	Run: func(cmd *cobra.Command, args []string) {
+		if snapshotName == "" || snapshotFolder == "" {
+			log.Fatalf("❌ --name, and --folder are required")
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		client, closeConn := dialWriteHead(ctx, snapshotFolder)
+		defer closeConn()
+
+		snap, err := client.CreateSnapshot(ctx, &rpcpb.SnapshotReq{Name: snapshotName, Folder: snapshotFolder})
+		if err != nil {
+			log.Fatalf("❌ CreateSnapshot failed: %v", err)
+		}
+		log.Printf("✅ Snapshot %s of %s: %d files", snap.Name, snap.Folder, snap.FileCount)
+	},
+}
+
+var snapshotDeleteCmd = &cobra.Command{
+	Use:   "delete",
+	Short: "Delete a snapshot and release the versions it pinned",
+	Run: func(cmd *cobra.Command, args []string) {
+		if snapshotName == "" {
+			log.Fatalf("❌ --name is required")
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		client, closeConn := dialWriteHead(ctx, "/")
+		defer closeConn()
+
+		snap, err := client.DeleteSnapshot(ctx, &rpcpb.SnapshotReq{Name: snapshotName})
+		if err != nil {
+			log.Fatalf("❌ DeleteSnapshot failed: %v", err)
+		}
+		log.Printf("✅ Deleted snapshot %s of %s", snap.Name, snap.Folder)
+	},
+}
+
+var snapshotListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List snapshots",
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		client, closeConn := dialWriteHead(ctx, "/")
+		defer closeConn()
+
+		list, err := client.ListSnapshots(ctx, &rpcpb.ListSnapshotsReq{})
+		if err != nil {
+			log.Fatalf("❌ ListSnapshots failed: %v", err)
+		}
+		for _, snap := range list.Snapshots {
+			fmt.Printf("📸 %-24s %-32s %6d files  %s\n", snap.Name, snap.Folder, snap.FileCount,
+				snap.CreatedAt.AsTime().Local().Format(time.RFC3339))
+		}
+	},
+}
+
+func init() {
+	snapshotCreateCmd.Flags().StringVar(&snapshotName, "name", "", "Snapshot name, e.g. release-1.4")
+	snapshotCreateCmd.Flags().StringVar(&snapshotFolder, "folder", "", "Folder tree to snapshot")
+	snapshotDeleteCmd.Flags().StringVar(&snapshotName, "name", "", "Snapshot to delete")
+
+	snapshotCmd.AddCommand(snapshotCreateCmd, snapshotDeleteCmd, snapshotListCmd)
+	rootCmd.AddCommand(snapshotCmd)
+}
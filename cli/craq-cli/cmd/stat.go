@@ -0,0 +1,101 @@
+/*
+Copyright © 2025 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"craq-cluster/cmd/manager/gen/managerpb"
+	"craq-cluster/gen/rpcpb"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+var statFolder, statFile, statSnapshot string
+
+// statCmd represents the stat command
+var statCmd = &cobra.Command{
+	Use:   "stat",
+	Short: "Show metadata of a file without downloading it",
+	Run: func(cmd *cobra.Command, args []string) {
+		if statFolder == "" || statFile == "" {
+			log.Fatalf("❌ --folder, and --file are required")
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		mgrConn, err := grpc.Dial("localhost:9005", grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if err != nil {
+			log.Fatalf("❌ Failed to connect to manager: %v", err)
+		}
+		defer mgrConn.Close()
+
+		mgrClient := managerpb.NewManagerClient(mgrConn)
+
+		readResp, err := mgrClient.GetReadNode(ctx, &managerpb.ReadNodeQuery{
+			ClientId: statFolder,
+			Folder:   statFolder,
+			FileName: statFile,
+		})
+		if err != nil {
+			log.Fatalf("❌ GetReadNode failed: %v", err)
+		}
+		readConn, err := grpc.Dial(readResp.Address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if err != nil {
+			log.Fatalf("❌ Failed to connect to read node: %v", err)
+		}
+		defer readConn.Close()
+
+		readClient := rpcpb.NewNodeClient(readConn)
+		st, err := readClient.Stat(ctx, &rpcpb.StatReq{Folder: statFolder, FileName: statFile, Snapshot: statSnapshot})
+		if err != nil {
+			log.Fatalf("❌ Stat failed: %v", err)
+		}
+
+		printStat(st)
+	},
+}
+
+func printStat(st *rpcpb.FileStat) {
+	fmt.Printf("📄 %s/%s\n", st.Folder, st.FileName)
+	fmt.Printf("   Size:     %d bytes\n", st.Size)
+	fmt.Printf("   Seq:      %d (%s)\n", st.Seq, st.State)
+	fmt.Printf("   Checksum: %s\n", st.Checksum)
+	if st.CreatedAt != nil { // not kept for snapshot versions
+		fmt.Printf("   Created:  %s\n", st.CreatedAt.AsTime().Local().Format(time.RFC3339))
+		fmt.Printf("   Modified: %s\n", st.ModifiedAt.AsTime().Local().Format(time.RFC3339))
+	}
+	fmt.Printf("   Node:     %s\n", st.NodeId)
+	printAttributes(st.Attributes)
+}
+
+func printAttributes(attrs map[string]string) {
+	if len(attrs) == 0 {
+		return
+	}
+	keys := make([]string, 0, len(attrs))
+	for k := range attrs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	fmt.Println("   Attributes:")
+	for _, k := range keys {
+		fmt.Printf("     %s=%s\n", k, attrs[k])
+	}
+}
+
+func init() {
+	statCmd.Flags().StringVar(&statFolder, "folder", "", "Folder containing the file")
+	statCmd.Flags().StringVar(&statFile, "file", "", "File name to stat")
+	statCmd.Flags().StringVar(&statSnapshot, "snapshot", "", "Stat the version recorded in this snapshot")
+	rootCmd.AddCommand(statCmd)
+}
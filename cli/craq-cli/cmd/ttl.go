@@ -0,0 +1,50 @@
+/*
+Copyright © 2025 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"craq-cluster/gen/rpcpb"
+
+	"github.com/spf13/cobra"
+)
+
+var ttlFolder string
+var ttlDuration time.Duration
+
+// ttlCmd represents the ttl command
+var ttlCmd = &cobra.Command{
+	Use:   "ttl",
+	Short: "Set the default TTL of files written under a folder (0 removes it)",
+	Run: func(cmd *cobra.Command, args []string) {
+		if ttlFolder == "" {
+			log.Fatalf("❌ --folder is required")
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		client, closeConn := dialWriteHead(ctx, ttlFolder)
+		defer closeConn()
+
+		resp, err := client.SetFolderTTL(ctx, &rpcpb.FolderTTL{Folder: ttlFolder, TtlSeconds: int64(ttlDuration / time.Second)})
+		if err != nil {
+			log.Fatalf("❌ SetFolderTTL failed: %v", err)
+		}
+		if resp.TtlSeconds == 0 {
+			log.Printf("✅ Removed default TTL of %s", resp.Folder)
+			return
+		}
+		log.Printf("✅ Files written under %s now expire after %v", resp.Folder, time.Duration(resp.TtlSeconds)*time.Second)
+	},
+}
+
+func init() {
+	ttlCmd.Flags().StringVar(&ttlFolder, "folder", "", "Folder tree the default applies to")
+	ttlCmd.Flags().DurationVar(&ttlDuration, "ttl", 0, "Default lifetime, e.g. 24h")
+	rootCmd.AddCommand(ttlCmd)
+}
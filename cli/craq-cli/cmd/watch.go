@@ -0,0 +1,98 @@
+/*
+Copyright © 2025 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"path"
+	"strings"
+	"time"
+
+	"craq-cluster/cmd/manager/gen/managerpb"
+	"craq-cluster/gen/rpcpb"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+var watchFolder string
+var watchRecursive bool
+var watchSince uint64
+
+// watchCmd represents the watch command
+var watchCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "Print changes committed under a folder as they happen",
+	Run: func(cmd *cobra.Command, args []string) {
+		if watchFolder == "" {
+			log.Fatalf("❌ Folder must be provided using --folder")
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+
+		mgrConn, err := grpc.Dial("localhost:9005", grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if err != nil {
+			log.Fatalf("❌ Failed to connect to manager: %v", err)
+		}
+		defer mgrConn.Close()
+
+		mgrClient := managerpb.NewManagerClient(mgrConn)
+
+		mgrCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		readResp, err := mgrClient.GetReadNode(mgrCtx, &managerpb.ReadNodeQuery{ClientId: watchFolder, Folder: watchFolder})
+		cancel()
+		if err != nil {
+			log.Fatalf("❌ GetReadNode failed: %v", err)
+		}
+		readConn, err := grpc.Dial(readResp.Address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if err != nil {
+			log.Fatalf("❌ Failed to connect to read node: %v", err)
+		}
+		defer readConn.Close()
+
+		readClient := rpcpb.NewNodeClient(readConn)
+		stream, err := readClient.Watch(ctx, &rpcpb.WatchReq{
+			Folder:    watchFolder,
+			Recursive: watchRecursive,
+			SinceSeq:  watchSince,
+		})
+		if err != nil {
+			log.Fatalf("❌ Watch failed: %v", err)
+		}
+
+		log.Printf("👀 Watching %s via %s (Ctrl-C to stop)", watchFolder, readResp.NodeId)
+		for {
+			e, err := stream.Recv()
+			if err == io.EOF || status.Code(err) == codes.Canceled {
+				return
+			}
+			if err != nil {
+				log.Fatalf("❌ Watch stream failed: %v", err)
+			}
+
+			name := path.Join(e.Folder, e.FileName)
+			if e.IsDir {
+				name += "/"
+			}
+			fmt.Printf("%-20d %-6s %s seq=%d  %s\n", e.Cursor, strings.ToLower(e.Kind.String()), name, e.Seq,
+				e.CommittedAt.AsTime().Local().Format(time.RFC3339))
+		}
+	},
+}
+
+func init() {
+	watchCmd.Flags().StringVarP(&watchFolder, "folder", "f", "", "Folder to watch")
+	watchCmd.Flags().BoolVarP(&watchRecursive, "recursive", "r", false, "Include changes in subfolders")
+	watchCmd.Flags().Uint64Var(&watchSince, "since", 0, "Resume after this cursor (first column of a previous run)")
+	rootCmd.AddCommand(watchCmd)
+}
@@ -1,12 +1,13 @@
 module craq-cli
 
-go 1.23.4
+go 1.25
 
 require google.golang.org/grpc v1.73.0
 
 require (
 	craq-cluster v0.0.0
 	github.com/inconshreveable/mousetrap v1.1.0 // indirect
+	github.com/klauspost/compress v1.20.1 // indirect
 	github.com/spf13/cobra v1.9.1
 	github.com/spf13/pflag v1.0.6 // indirect
 	golang.org/x/net v0.38.0 // indirect
@@ -28,6 +28,7 @@ type NodeInfo struct {
 	Address       string                 `protobuf:"bytes,2,opt,name=address,proto3" json:"address,omitempty"`
 	IsHead        bool                   `protobuf:"varint,3,opt,name=is_head,json=isHead,proto3" json:"is_head,omitempty"`
 	IsTail        bool                   `protobuf:"varint,4,opt,name=is_tail,json=isTail,proto3" json:"is_tail,omitempty"`
+	ChainId       int32                  `protobuf:"varint,5,opt,name=chain_id,json=chainId,proto3" json:"chain_id,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -90,6 +91,13 @@ func (x *NodeInfo) GetIsTail() bool {
 	return false
 }
 
+func (x *NodeInfo) GetChainId() int32 {
+	if x != nil {
+		return x.ChainId
+	}
+	return 0
+}
+
 type NodeHealth struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	NodeId        string                 `protobuf:"bytes,1,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"`
@@ -178,17 +186,72 @@ func (x *SuccessorQuery) GetNodeId() string {
 	return ""
 }
 
+// Identifies a file; keys are mapped to chains by consistent hashing
+type KeyQuery struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Folder        string                 `protobuf:"bytes,1,opt,name=folder,proto3" json:"folder,omitempty"`
+	FileName      string                 `protobuf:"bytes,2,opt,name=file_name,json=fileName,proto3" json:"file_name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KeyQuery) Reset() {
+	*x = KeyQuery{}
+	mi := &file_manager_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KeyQuery) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KeyQuery) ProtoMessage() {}
+
+func (x *KeyQuery) ProtoReflect() protoreflect.Message {
+	mi := &file_manager_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KeyQuery.ProtoReflect.Descriptor instead.
+func (*KeyQuery) Descriptor() ([]byte, []int) {
+	return file_manager_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *KeyQuery) GetFolder() string {
+	if x != nil {
+		return x.Folder
+	}
+	return ""
+}
+
+func (x *KeyQuery) GetFileName() string {
+	if x != nil {
+		return x.FileName
+	}
+	return ""
+}
+
 type ReadNodeQuery struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
 	// Optional: can include filtering criteria later
 	ClientId      string `protobuf:"bytes,1,opt,name=client_id,json=clientId,proto3" json:"client_id,omitempty"`
+	Folder        string `protobuf:"bytes,2,opt,name=folder,proto3" json:"folder,omitempty"`
+	FileName      string `protobuf:"bytes,3,opt,name=file_name,json=fileName,proto3" json:"file_name,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *ReadNodeQuery) Reset() {
 	*x = ReadNodeQuery{}
-	mi := &file_manager_proto_msgTypes[3]
+	mi := &file_manager_proto_msgTypes[4]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -200,7 +263,7 @@ func (x *ReadNodeQuery) String() string {
 func (*ReadNodeQuery) ProtoMessage() {}
 
 func (x *ReadNodeQuery) ProtoReflect() protoreflect.Message {
-	mi := &file_manager_proto_msgTypes[3]
+	mi := &file_manager_proto_msgTypes[4]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -213,7 +276,7 @@ func (x *ReadNodeQuery) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ReadNodeQuery.ProtoReflect.Descriptor instead.
 func (*ReadNodeQuery) Descriptor() ([]byte, []int) {
-	return file_manager_proto_rawDescGZIP(), []int{3}
+	return file_manager_proto_rawDescGZIP(), []int{4}
 }
 
 func (x *ReadNodeQuery) GetClientId() string {
@@ -223,29 +286,106 @@ func (x *ReadNodeQuery) GetClientId() string {
 	return ""
 }
 
+func (x *ReadNodeQuery) GetFolder() string {
+	if x != nil {
+		return x.Folder
+	}
+	return ""
+}
+
+func (x *ReadNodeQuery) GetFileName() string {
+	if x != nil {
+		return x.FileName
+	}
+	return ""
+}
+
+// Nodes of one chain, ordered head to tail
+type ChainInfo struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ChainId       int32                  `protobuf:"varint,1,opt,name=chain_id,json=chainId,proto3" json:"chain_id,omitempty"`
+	Nodes         []*NodeInfo            `protobuf:"bytes,2,rep,name=nodes,proto3" json:"nodes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ChainInfo) Reset() {
+	*x = ChainInfo{}
+	mi := &file_manager_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ChainInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ChainInfo) ProtoMessage() {}
+
+func (x *ChainInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_manager_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ChainInfo.ProtoReflect.Descriptor instead.
+func (*ChainInfo) Descriptor() ([]byte, []int) {
+	return file_manager_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ChainInfo) GetChainId() int32 {
+	if x != nil {
+		return x.ChainId
+	}
+	return 0
+}
+
+func (x *ChainInfo) GetNodes() []*NodeInfo {
+	if x != nil {
+		return x.Nodes
+	}
+	return nil
+}
+
 var File_manager_proto protoreflect.FileDescriptor
 
 const file_manager_proto_rawDesc = "" +
 	"\n" +
-	"\rmanager.proto\x12\tmanagerpb\x1a\x1bgoogle/protobuf/empty.proto\"o\n" +
+	"\rmanager.proto\x12\tmanagerpb\x1a\x1bgoogle/protobuf/empty.proto\"\x8a\x01\n" +
 	"\bNodeInfo\x12\x17\n" +
 	"\anode_id\x18\x01 \x01(\tR\x06nodeId\x12\x18\n" +
 	"\aaddress\x18\x02 \x01(\tR\aaddress\x12\x17\n" +
 	"\ais_head\x18\x03 \x01(\bR\x06isHead\x12\x17\n" +
-	"\ais_tail\x18\x04 \x01(\bR\x06isTail\"%\n" +
+	"\ais_tail\x18\x04 \x01(\bR\x06isTail\x12\x19\n" +
+	"\bchain_id\x18\x05 \x01(\x05R\achainId\"%\n" +
 	"\n" +
 	"NodeHealth\x12\x17\n" +
 	"\anode_id\x18\x01 \x01(\tR\x06nodeId\")\n" +
 	"\x0eSuccessorQuery\x12\x17\n" +
-	"\anode_id\x18\x01 \x01(\tR\x06nodeId\",\n" +
+	"\anode_id\x18\x01 \x01(\tR\x06nodeId\"?\n" +
+	"\bKeyQuery\x12\x16\n" +
+	"\x06folder\x18\x01 \x01(\tR\x06folder\x12\x1b\n" +
+	"\tfile_name\x18\x02 \x01(\tR\bfileName\"a\n" +
 	"\rReadNodeQuery\x12\x1b\n" +
-	"\tclient_id\x18\x01 \x01(\tR\bclientId2\xbd\x02\n" +
+	"\tclient_id\x18\x01 \x01(\tR\bclientId\x12\x16\n" +
+	"\x06folder\x18\x02 \x01(\tR\x06folder\x12\x1b\n" +
+	"\tfile_name\x18\x03 \x01(\tR\bfileName\"Q\n" +
+	"\tChainInfo\x12\x19\n" +
+	"\bchain_id\x18\x01 \x01(\x05R\achainId\x12)\n" +
+	"\x05nodes\x18\x02 \x03(\v2\x13.managerpb.NodeInfoR\x05nodes2\xf7\x02\n" +
 	"\aManager\x12;\n" +
 	"\fRegisterNode\x12\x13.managerpb.NodeInfo\x1a\x16.google.protobuf.Empty\x12>\n" +
 	"\fGetSuccessor\x12\x19.managerpb.SuccessorQuery\x1a\x13.managerpb.NodeInfo\x12:\n" +
-	"\tHeartbeat\x12\x15.managerpb.NodeHealth\x1a\x16.google.protobuf.Empty\x12;\n" +
-	"\fGetWriteHead\x12\x16.google.protobuf.Empty\x1a\x13.managerpb.NodeInfo\x12<\n" +
-	"\vGetReadNode\x12\x18.managerpb.ReadNodeQuery\x1a\x13.managerpb.NodeInfoB\rZ\v.;managerpbb\x06proto3"
+	"\tHeartbeat\x12\x15.managerpb.NodeHealth\x1a\x16.google.protobuf.Empty\x128\n" +
+	"\fGetWriteHead\x12\x13.managerpb.KeyQuery\x1a\x13.managerpb.NodeInfo\x12<\n" +
+	"\vGetReadNode\x12\x18.managerpb.ReadNodeQuery\x1a\x13.managerpb.NodeInfo\x12;\n" +
+	"\bGetChain\x12\x19.managerpb.SuccessorQuery\x1a\x14.managerpb.ChainInfoB\rZ\v.;managerpbb\x06proto3"
 
 var (
 	file_manager_proto_rawDescOnce sync.Once
@@ -259,30 +399,35 @@ func file_manager_proto_rawDescGZIP() []byte {
 	return file_manager_proto_rawDescData
 }
 
-var file_manager_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
+var file_manager_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
 var file_manager_proto_goTypes = []any{
 	(*NodeInfo)(nil),       // 0: managerpb.NodeInfo
 	(*NodeHealth)(nil),     // 1: managerpb.NodeHealth
 	(*SuccessorQuery)(nil), // 2: managerpb.SuccessorQuery
-	(*ReadNodeQuery)(nil),  // 3: managerpb.ReadNodeQuery
-	(*emptypb.Empty)(nil),  // 4: google.protobuf.Empty
+	(*KeyQuery)(nil),       // 3: managerpb.KeyQuery
+	(*ReadNodeQuery)(nil),  // 4: managerpb.ReadNodeQuery
+	(*ChainInfo)(nil),      // 5: managerpb.ChainInfo
+	(*emptypb.Empty)(nil),  // 6: google.protobuf.Empty
 }
 var file_manager_proto_depIdxs = []int32{
-	0, // 0: managerpb.Manager.RegisterNode:input_type -> managerpb.NodeInfo
-	2, // 1: managerpb.Manager.GetSuccessor:input_type -> managerpb.SuccessorQuery
-	1, // 2: managerpb.Manager.Heartbeat:input_type -> managerpb.NodeHealth
-	4, // 3: managerpb.Manager.GetWriteHead:input_type -> google.protobuf.Empty
-	3, // 4: managerpb.Manager.GetReadNode:input_type -> managerpb.ReadNodeQuery
-	4, // 5: managerpb.Manager.RegisterNode:output_type -> google.protobuf.Empty
-	0, // 6: managerpb.Manager.GetSuccessor:output_type -> managerpb.NodeInfo
-	4, // 7: managerpb.Manager.Heartbeat:output_type -> google.protobuf.Empty
-	0, // 8: managerpb.Manager.GetWriteHead:output_type -> managerpb.NodeInfo
-	0, // 9: managerpb.Manager.GetReadNode:output_type -> managerpb.NodeInfo
-	5, // [5:10] is the sub-list for method output_type
-	0, // [0:5] is the sub-list for method input_type
-	0, // [0:0] is the sub-list for extension type_name
-	0, // [0:0] is the sub-list for extension extendee
-	0, // [0:0] is the sub-list for field type_name
+	0, // 0: managerpb.ChainInfo.nodes:type_name -> managerpb.NodeInfo
+	0, // 1: managerpb.Manager.RegisterNode:input_type -> managerpb.NodeInfo
+	2, // 2: managerpb.Manager.GetSuccessor:input_type -> managerpb.SuccessorQuery
+	1, // 3: managerpb.Manager.Heartbeat:input_type -> managerpb.NodeHealth
+	3, // 4: managerpb.Manager.GetWriteHead:input_type -> managerpb.KeyQuery
+	4, // 5: managerpb.Manager.GetReadNode:input_type -> managerpb.ReadNodeQuery
+	2, // 6: managerpb.Manager.GetChain:input_type -> managerpb.SuccessorQuery
+	6, // 7: managerpb.Manager.RegisterNode:output_type -> google.protobuf.Empty
+	0, // 8: managerpb.Manager.GetSuccessor:output_type -> managerpb.NodeInfo
+	6, // 9: managerpb.Manager.Heartbeat:output_type -> google.protobuf.Empty
+	0, // 10: managerpb.Manager.GetWriteHead:output_type -> managerpb.NodeInfo
+	0, // 11: managerpb.Manager.GetReadNode:output_type -> managerpb.NodeInfo
+	5, // 12: managerpb.Manager.GetChain:output_type -> managerpb.ChainInfo
+	7, // [7:13] is the sub-list for method output_type
+	1, // [1:7] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	1, // [1:1] is the sub-list for extension extendee
+	0, // [0:1] is the sub-list for field type_name
 }
 
 func init() { file_manager_proto_init() }
@@ -296,7 +441,7 @@ func file_manager_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_manager_proto_rawDesc), len(file_manager_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   4,
+			NumMessages:   6,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
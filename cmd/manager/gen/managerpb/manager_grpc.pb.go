@@ -25,6 +25,7 @@ const (
 	Manager_Heartbeat_FullMethodName    = "/managerpb.Manager/Heartbeat"
 	Manager_GetWriteHead_FullMethodName = "/managerpb.Manager/GetWriteHead"
 	Manager_GetReadNode_FullMethodName  = "/managerpb.Manager/GetReadNode"
+	Manager_GetChain_FullMethodName     = "/managerpb.Manager/GetChain"
 )
 
 // ManagerClient is the client API for Manager service.
@@ -34,8 +35,9 @@ type ManagerClient interface {
 	RegisterNode(ctx context.Context, in *NodeInfo, opts ...grpc.CallOption) (*emptypb.Empty, error)
 	GetSuccessor(ctx context.Context, in *SuccessorQuery, opts ...grpc.CallOption) (*NodeInfo, error)
 	Heartbeat(ctx context.Context, in *NodeHealth, opts ...grpc.CallOption) (*emptypb.Empty, error)
-	GetWriteHead(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*NodeInfo, error)
+	GetWriteHead(ctx context.Context, in *KeyQuery, opts ...grpc.CallOption) (*NodeInfo, error)
 	GetReadNode(ctx context.Context, in *ReadNodeQuery, opts ...grpc.CallOption) (*NodeInfo, error)
+	GetChain(ctx context.Context, in *SuccessorQuery, opts ...grpc.CallOption) (*ChainInfo, error)
 }
 
 type managerClient struct {
@@ -76,7 +78,7 @@ func (c *managerClient) Heartbeat(ctx context.Context, in *NodeHealth, opts ...g
 	return out, nil
 }
 
-func (c *managerClient) GetWriteHead(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*NodeInfo, error) {
+func (c *managerClient) GetWriteHead(ctx context.Context, in *KeyQuery, opts ...grpc.CallOption) (*NodeInfo, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(NodeInfo)
 	err := c.cc.Invoke(ctx, Manager_GetWriteHead_FullMethodName, in, out, cOpts...)
@@ -96,6 +98,16 @@ func (c *managerClient) GetReadNode(ctx context.Context, in *ReadNodeQuery, opts
 	return out, nil
 }
 
+func (c *managerClient) GetChain(ctx context.Context, in *SuccessorQuery, opts ...grpc.CallOption) (*ChainInfo, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ChainInfo)
+	err := c.cc.Invoke(ctx, Manager_GetChain_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // ManagerServer is the server API for Manager service.
 // All implementations must embed UnimplementedManagerServer
 // for forward compatibility.
@@ -103,8 +115,9 @@ type ManagerServer interface {
 	RegisterNode(context.Context, *NodeInfo) (*emptypb.Empty, error)
 	GetSuccessor(context.Context, *SuccessorQuery) (*NodeInfo, error)
 	Heartbeat(context.Context, *NodeHealth) (*emptypb.Empty, error)
-	GetWriteHead(context.Context, *emptypb.Empty) (*NodeInfo, error)
+	GetWriteHead(context.Context, *KeyQuery) (*NodeInfo, error)
 	GetReadNode(context.Context, *ReadNodeQuery) (*NodeInfo, error)
+	GetChain(context.Context, *SuccessorQuery) (*ChainInfo, error)
 	mustEmbedUnimplementedManagerServer()
 }
 
@@ -124,12 +137,15 @@ func (UnimplementedManagerServer) GetSuccessor(context.Context, *SuccessorQuery)
 func (UnimplementedManagerServer) Heartbeat(context.Context, *NodeHealth) (*emptypb.Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Heartbeat not implemented")
 }
-func (UnimplementedManagerServer) GetWriteHead(context.Context, *emptypb.Empty) (*NodeInfo, error) {
+func (UnimplementedManagerServer) GetWriteHead(context.Context, *KeyQuery) (*NodeInfo, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetWriteHead not implemented")
 }
 func (UnimplementedManagerServer) GetReadNode(context.Context, *ReadNodeQuery) (*NodeInfo, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetReadNode not implemented")
 }
+func (UnimplementedManagerServer) GetChain(context.Context, *SuccessorQuery) (*ChainInfo, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetChain not implemented")
+}
 func (UnimplementedManagerServer) mustEmbedUnimplementedManagerServer() {}
 func (UnimplementedManagerServer) testEmbeddedByValue()                 {}
 
@@ -206,7 +222,7 @@ func _Manager_Heartbeat_Handler(srv interface{}, ctx context.Context, dec func(i
 }
 
 func _Manager_GetWriteHead_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(emptypb.Empty)
+	in := new(KeyQuery)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
@@ -218,7 +234,7 @@ func _Manager_GetWriteHead_Handler(srv interface{}, ctx context.Context, dec fun
 		FullMethod: Manager_GetWriteHead_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(ManagerServer).GetWriteHead(ctx, req.(*emptypb.Empty))
+		return srv.(ManagerServer).GetWriteHead(ctx, req.(*KeyQuery))
 	}
 	return interceptor(ctx, in, info, handler)
 }
@@ -241,6 +257,24 @@ func _Manager_GetReadNode_Handler(srv interface{}, ctx context.Context, dec func
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Manager_GetChain_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SuccessorQuery)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ManagerServer).GetChain(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Manager_GetChain_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ManagerServer).GetChain(ctx, req.(*SuccessorQuery))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // Manager_ServiceDesc is the grpc.ServiceDesc for Manager service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -268,6 +302,10 @@ var Manager_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetReadNode",
 			Handler:    _Manager_GetReadNode_Handler,
 		},
+		{
+			MethodName: "GetChain",
+			Handler:    _Manager_GetChain_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "manager.proto",
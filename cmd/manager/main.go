@@ -25,15 +25,23 @@ type Manager struct {
 	nodeOrder     []string
 	nodeStatus    map[string]bool
 	expectedCount int
+	chainCount    int
+	replication   int
 	chainBuilt    bool
+
+	// chains[i] lists the node IDs of chain i, head first
+	chains [][]string
+	ring   *hashRing
 }
 
-func NewManager(expected int) *Manager {
+func NewManager(expected, chainCount, replication int) *Manager {
 	return &Manager{
 		nodes:         make(map[string]config.NodeInfo),
 		nodeOrder:     []string{},
 		nodeStatus:    make(map[string]bool),
 		expectedCount: expected,
+		chainCount:    chainCount,
+		replication:   replication,
 	}
 }
 
@@ -63,17 +71,29 @@ func (m *Manager) RegisterNode(ctx context.Context, req *managerpb.NodeInfo) (*e
 	return &emptypb.Empty{}, nil
 }
 
+// finalizeChain splits the registered nodes, in registration order, into
+// chainCount chains of replication nodes each and places the chains on
+// the hash ring.
 func (m *Manager) finalizeChain() {
-	log.Println("🔗 Finalizing CRAQ chain...")
-
-	for i, nodeID := range m.nodeOrder {
-		node := m.nodes[nodeID]
-		node.IsHead = (i == 0)
-		node.IsTail = (i == len(m.nodeOrder)-1)
-		m.nodes[nodeID] = node
-
-		log.Printf(" - Node %s | Addr: %s | Head: %v | Tail: %v", node.ID, node.Addr, node.IsHead, node.IsTail)
+	log.Printf("🔗 Finalizing %d CRAQ chain(s) with replication factor %d...", m.chainCount, m.replication)
+
+	m.chains = make([][]string, m.chainCount)
+	chainIDs := make([]int, m.chainCount)
+	for c := range m.chains {
+		chainIDs[c] = c
+		m.chains[c] = m.nodeOrder[c*m.replication : (c+1)*m.replication]
+
+		for i, nodeID := range m.chains[c] {
+			node := m.nodes[nodeID]
+			node.IsHead = (i == 0)
+			node.IsTail = (i == len(m.chains[c])-1)
+			node.ChainID = c
+			m.nodes[nodeID] = node
+
+			log.Printf(" - Chain %d | Node %s | Addr: %s | Head: %v | Tail: %v", c, node.ID, node.Addr, node.IsHead, node.IsTail)
+		}
 	}
+	m.ring = newHashRing(chainIDs)
 
 	m.chainBuilt = true
 	log.Println("✅ Chain finalized!")
@@ -87,15 +107,15 @@ func (m *Manager) GetSuccessor(ctx context.Context, req *managerpb.SuccessorQuer
 		return nil, status.Error(codes.FailedPrecondition, "Chain not finalized yet")
 	}
 
-	for i, id := range m.nodeOrder {
-		if id == req.NodeId && i+1 < len(m.nodeOrder) {
-			next := m.nodes[m.nodeOrder[i+1]]
-			return &managerpb.NodeInfo{
-				NodeId:  next.ID,
-				Address: next.Addr,
-				IsHead:  next.IsHead,
-				IsTail:  next.IsTail,
-			}, nil
+	node, ok := m.nodes[req.NodeId]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "Node %s not registered", req.NodeId)
+	}
+
+	chain := m.chains[node.ChainID]
+	for i, id := range chain {
+		if id == req.NodeId && i+1 < len(chain) {
+			return toNodeInfo(m.nodes[chain[i+1]]), nil
 		}
 	}
 
@@ -103,6 +123,26 @@ func (m *Manager) GetSuccessor(ctx context.Context, req *managerpb.SuccessorQuer
 	return &managerpb.NodeInfo{}, nil
 }
 
+func (m *Manager) GetChain(ctx context.Context, req *managerpb.SuccessorQuery) (*managerpb.ChainInfo, error) {
+	m.RLock()
+	defer m.RUnlock()
+
+	if !m.chainBuilt {
+		return nil, status.Error(codes.FailedPrecondition, "Chain not finalized yet")
+	}
+
+	node, ok := m.nodes[req.NodeId]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "Node %s not registered", req.NodeId)
+	}
+
+	info := &managerpb.ChainInfo{ChainId: int32(node.ChainID)}
+	for _, id := range m.chains[node.ChainID] {
+		info.Nodes = append(info.Nodes, toNodeInfo(m.nodes[id]))
+	}
+	return info, nil
+}
+
 func (m *Manager) Heartbeat(ctx context.Context, hb *managerpb.NodeHealth) (*emptypb.Empty, error) {
 	m.Lock()
 	defer m.Unlock()
@@ -111,7 +151,7 @@ func (m *Manager) Heartbeat(ctx context.Context, hb *managerpb.NodeHealth) (*emp
 	return &emptypb.Empty{}, nil
 }
 
-func (m *Manager) GetWriteHead(ctx context.Context, _ *emptypb.Empty) (*managerpb.NodeInfo, error) {
+func (m *Manager) GetWriteHead(ctx context.Context, req *managerpb.KeyQuery) (*managerpb.NodeInfo, error) {
 	m.RLock()
 	defer m.RUnlock()
 
@@ -119,21 +159,16 @@ func (m *Manager) GetWriteHead(ctx context.Context, _ *emptypb.Empty) (*managerp
 		return nil, status.Errorf(codes.FailedPrecondition, "Chain not finalized or no nodes registered")
 	}
 
-	headID := m.nodeOrder[0]
-	headNode, ok := m.nodes[headID]
+	chain := m.chains[m.ring.Lookup(req.Folder, req.FileName)]
+	headNode, ok := m.nodes[chain[0]]
 	if !ok {
 		return nil, status.Errorf(codes.Internal, "Head node not found in registry")
 	}
 
-	return &managerpb.NodeInfo{
-		NodeId:  headNode.ID,
-		Address: headNode.Addr,
-		IsHead:  true,
-		IsTail:  false,
-	}, nil
+	return toNodeInfo(headNode), nil
 }
 
-func (m *Manager) GetReadNode(ctx context.Context, _ *managerpb.ReadNodeQuery) (*managerpb.NodeInfo, error) {
+func (m *Manager) GetReadNode(ctx context.Context, req *managerpb.ReadNodeQuery) (*managerpb.NodeInfo, error) {
 	m.RLock()
 	defer m.RUnlock()
 
@@ -141,19 +176,38 @@ func (m *Manager) GetReadNode(ctx context.Context, _ *managerpb.ReadNodeQuery) (
 		return nil, status.Errorf(codes.FailedPrecondition, "Chain not finalized or no nodes registered")
 	}
 
-	idx := rand.Intn(len(m.nodeOrder))
-	nodeID := m.nodeOrder[idx]
+	chain := m.chains[m.ring.Lookup(req.Folder, req.FileName)]
+	nodeID := chain[rand.Intn(len(chain))]
 	node, ok := m.nodes[nodeID]
 	if !ok {
 		return nil, status.Errorf(codes.Internal, "Node %s not found in registry", nodeID)
 	}
 
+	return toNodeInfo(node), nil
+}
+
+func toNodeInfo(node config.NodeInfo) *managerpb.NodeInfo {
 	return &managerpb.NodeInfo{
 		NodeId:  node.ID,
 		Address: node.Addr,
-		IsHead:  idx == 0,
-		IsTail:  idx == len(m.nodeOrder)-1,
-	}, nil
+		IsHead:  node.IsHead,
+		IsTail:  node.IsTail,
+		ChainId: int32(node.ChainID),
+	}
+}
+
+// envInt reads a positive integer from the environment, falling back to def
+// when the variable is unset.
+func envInt(name string, def int) int {
+	v := os.Getenv(name)
+	if v == "" {
+		return def
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n <= 0 {
+		log.Fatalf("Invalid %s: %v", name, v)
+	}
+	return n
 }
 
 func main() {
@@ -169,7 +223,13 @@ func main() {
 		log.Fatalf("Invalid EXPECTED_NODE_COUNT: %v", expectedCountStr)
 	}
 
-	manager := NewManager(expectedCount)
+	chainCount := envInt("CHAIN_COUNT", 1)
+	replication := envInt("REPLICATION_FACTOR", expectedCount/chainCount)
+	if chainCount*replication != expectedCount {
+		log.Fatalf("CHAIN_COUNT (%d) x REPLICATION_FACTOR (%d) must equal EXPECTED_NODE_COUNT (%d)", chainCount, replication, expectedCount)
+	}
+
+	manager := NewManager(expectedCount, chainCount, replication)
 
 	// Start gRPC server
 	listenAddr := ":9005"
@@ -181,7 +241,7 @@ func main() {
 	grpcServer := grpc.NewServer()
 	managerpb.RegisterManagerServer(grpcServer, manager)
 
-	log.Printf("🚀 Manager is running on %s | Expecting %d nodes", listenAddr, expectedCount)
+	log.Printf("🚀 Manager is running on %s | Expecting %d nodes | Chains: %d", listenAddr, expectedCount, chainCount)
 
 	if err := grpcServer.Serve(lis); err != nil {
 		log.Fatalf("❌ gRPC serve failed: %v", err)
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"hash/fnv"
+	"path"
+	"sort"
+)
+
+// virtualPoints is how many positions each chain takes on the ring. More
+// points spread keys more evenly across chains.
+const virtualPoints = 128
+
+// hashRing maps keys to chains with consistent hashing, so adding a chain
+// only moves the keys that land on its new points.
+type hashRing struct {
+	points []uint64
+	owners map[uint64]int
+}
+
+func newHashRing(chainIDs []int) *hashRing {
+	r := &hashRing{owners: make(map[uint64]int)}
+	for _, id := range chainIDs {
+		for v := 0; v < virtualPoints; v++ {
+			h := hashKey(fmt.Sprintf("chain-%d#%d", id, v))
+			if _, taken := r.owners[h]; taken {
+				continue
+			}
+			r.owners[h] = id
+			r.points = append(r.points, h)
+		}
+	}
+	sort.Slice(r.points, func(i, j int) bool { return r.points[i] < r.points[j] })
+	return r
+}
+
+// Lookup returns the chain owning folder/fileName: the first point at or
+// after the key's hash, wrapping around the ring. The folder is cleaned
+// the way nodes clean it (storage.CleanDir), so every spelling of a path
+// lands on the chain that stores it.
+func (r *hashRing) Lookup(folder, fileName string) int {
+	h := hashKey(path.Join("/", path.Clean(folder), fileName))
+	i := sort.Search(len(r.points), func(i int) bool { return r.points[i] >= h })
+	if i == len(r.points) {
+		i = 0
+	}
+	return r.owners[r.points[i]]
+}
+
+// hashKey hashes key onto the ring. FNV-1a alone leaves keys that differ
+// only in their last bytes close together, so chains' points cluster and
+// some chains own far more of the ring than others; the splitmix64
+// finalizer spreads them out.
+func hashKey(key string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	x := h.Sum64()
+	x = (x ^ (x >> 30)) * 0xbf58476d1ce4e5b9
+	x = (x ^ (x >> 27)) * 0x94d049bb133111eb
+	return x ^ (x >> 31)
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestRingLookupCleansFolder(t *testing.T) {
+	r := newHashRing([]int{0, 1, 2, 3})
+	for _, name := range []string{"a", "b", "model.bin", "report.pdf"} {
+		want := r.Lookup("/craq/a", name)
+		for _, folder := range []string{"craq/a", "/craq//a", "/craq/a/", "/craq/./a", "/craq/b/../a"} {
+			if got := r.Lookup(folder, name); got != want {
+				t.Fatalf("%s in %q on chain %d, in /craq/a on chain %d", name, folder, got, want)
+			}
+		}
+	}
+}
+
+func TestRingSpreadsKeysAndMovesFewWhenGrown(t *testing.T) {
+	const keys = 20000
+	r := newHashRing([]int{0, 1, 2, 3})
+	counts := make(map[int]int)
+	owners := make([]int, keys)
+	for i := range owners {
+		owners[i] = r.Lookup("/data", fmt.Sprintf("file-%d", i))
+		counts[owners[i]]++
+	}
+	for chain := range 4 {
+		if share := float64(counts[chain]) / keys; share < 0.15 || share > 0.35 {
+			t.Fatalf("chain %d owns %.0f%% of keys, want about 25%%: %v", chain, share*100, counts)
+		}
+	}
+
+	// A fifth chain takes about a fifth of the keys, and only from the
+	// others: no key moves between two old chains.
+	grown := newHashRing([]int{0, 1, 2, 3, 4})
+	moved := 0
+	for i, was := range owners {
+		now := grown.Lookup("/data", fmt.Sprintf("file-%d", i))
+		if now == was {
+			continue
+		}
+		if now != 4 {
+			t.Fatalf("file-%d moved from chain %d to old chain %d", i, was, now)
+		}
+		moved++
+	}
+	if share := float64(moved) / keys; share < 0.1 || share > 0.3 {
+		t.Fatalf("adding a chain moved %.0f%% of keys, want about 20%%", share*100)
+	}
+}
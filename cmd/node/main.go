@@ -3,11 +3,15 @@ package main
 import (
 	"context"
 	"craq-cluster/internal/config"
+	"craq-cluster/pkg/compression"
 	"craq-cluster/pkg/craq"
 	"craq-cluster/pkg/storage"
+	"fmt"
 	"log"
 	"net"
 	"os"
+	"path"
+	"path/filepath"
 	"time"
 
 	managerpb "craq-cluster/cmd/manager/gen/managerpb"
@@ -16,7 +20,6 @@ import (
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
-	"google.golang.org/protobuf/types/known/emptypb"
 )
 
 var (
@@ -26,6 +29,11 @@ var (
 
 func main() {
 
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		runMigrate(os.Args[2:])
+		return
+	}
+
 	if nodeID == "" || nodeAddr == "" {
 		log.Fatal("NODE_ID, NODE_ADDRESS must be set")
 		panic(0)
@@ -105,29 +113,96 @@ func main() {
 		log.Fatalf("Unable to retrieve successor after %d attempts. Exiting.", maxRetries)
 	}
 
-	ctx2, _ := context.WithTimeout(context.Background(), 5*time.Second)
-	writeHead, err := managerClient.GetWriteHead(ctx2, &emptypb.Empty{})
+	ctx3, cancel3 := context.WithTimeout(context.Background(), 5*time.Second)
+	chain, err := managerClient.GetChain(ctx3, &managerpb.SuccessorQuery{NodeId: nodeID})
+	cancel3()
 	if err != nil {
-		log.Fatalf("❌ Manager.GetWriteHead failed: %v", err)
+		log.Fatalf("❌ Manager.GetChain failed: %v", err)
 	}
-	log.Printf("📤 Head node for write: %s (%s)", writeHead.NodeId, writeHead.Address)
+	writeHead := chain.Nodes[0]
+	log.Printf("📤 Head node of chain %d: %s (%s)", chain.ChainId, writeHead.NodeId, writeHead.Address)
 
-	// Assume head is node1 for demo
 	isHead := nodeID == writeHead.NodeId
 
-	store, err := storage.NewCraqStore(context.Background(), cfg.DB.Addr)
+	// The other members of the chain, which the scrubber repairs from and
+	// erasure-coded shards are placed on, by chain position.
+	var peers []rpcpb.NodeClient
+	members := make([]rpcpb.NodeClient, len(chain.Nodes))
+	chainPos := 0
+	for i, member := range chain.Nodes {
+		if member.NodeId == nodeID {
+			chainPos = i
+			continue
+		}
+		conn, err := grpc.Dial(member.Address, grpc.WithInsecure())
+		if err != nil {
+			log.Fatalf("Failed to connect to chain peer %s: %v", member.NodeId, err)
+		}
+		members[i] = rpcpb.NewNodeClient(conn)
+		peers = append(peers, members[i])
+	}
+
+	store, err := openStore(cfg.DB)
 	if err != nil {
 		log.Fatalf("store init failed: %v", err)
 	}
 
+	hot, err := openBlobs(cfg.Blobs)
+	if err != nil {
+		log.Fatalf("blob store init failed: %v", err)
+	}
+	if cfg.Blobs.Compress != "" {
+		if hot, err = storage.NewCompressedBlobStore(hot, cfg.Blobs.Compress); err != nil {
+			log.Fatalf("blob store init failed: %v", err)
+		}
+		log.Printf("🗜️ Keeping blobs %s-compressed at rest", cfg.Blobs.Compress)
+	}
+	archive, err := openBlobs(archiveInfo(cfg.Blobs, cfg.Tier.Archive))
+	if err != nil {
+		log.Fatalf("archive blob store init failed: %v", err)
+	}
+	blobs := storage.NewTieredBlobStore(hot, archive)
+
 	localNode := craq.NewNode(
 		nodeID,
 		isHead,
 		isTail,
 		store,
+		blobs,
 		nil,  // Prev not used
 		next, // Next client
 	)
+	localNode.ChainPos = chainPos
+	localNode.Chain = members
+	localNode.Erasure = erasurePolicies(cfg.Erasure, len(chain.Nodes))
+	localNode.Compression = compressionPolicies(cfg.Compression)
+
+	// Finish versions a crash left in flight before serving.
+	if err := localNode.RecoverWAL(context.Background(), filepath.Join("/tmp/craq", "wal", nodeID+".log")); err != nil {
+		log.Fatalf("WAL recovery failed: %v", err)
+	}
+
+	if err := localNode.LoadRefs(context.Background()); err != nil {
+		log.Printf("⚠️ Chunk reference index not loaded, GC retries it: %v", err)
+	}
+
+	// The manager decides which chain each file belongs to.
+	localNode.Owns = func(folder, fileName string) bool {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		head, err := managerClient.GetWriteHead(ctx, &managerpb.KeyQuery{Folder: folder, FileName: fileName})
+		if err != nil {
+			log.Printf("⚠️ GetWriteHead for %s/%s failed: %v", folder, fileName, err)
+			return false
+		}
+		return head.NodeId == nodeID
+	}
+
+	go localNode.RunGC(context.Background(), gcPolicy(cfg.GC))
+	go localNode.RunTiering(context.Background(), tierPolicy(cfg.Tier))
+	go localNode.RunScrub(context.Background(), durationOr(cfg.Scrub.Interval, 24*time.Hour), peers)
+	go localNode.RunExpiry(context.Background(), durationOr(cfg.TTL.Interval, time.Minute), localNode.Owns)
+	go localNode.RunEventPruning(context.Background(), durationOr(cfg.Watch.Retention, 7*24*time.Hour))
 
 	// Start gRPC Server
 	lis, err := net.Listen("tcp", nodeAddr)
@@ -138,8 +213,8 @@ func main() {
 	grpcServer := grpc.NewServer()
 	rpcpb.RegisterNodeServer(grpcServer, craq.NewNodeServer(localNode))
 
-	log.Printf("🚀 Node started | ID: %s | Addr: %s | Head: %v | Tail: %v | Next: %v",
-		nodeID, nodeAddr, isHead, isTail,
+	log.Printf("🚀 Node started | ID: %s | Addr: %s | Chain: %d | Head: %v | Tail: %v | Next: %v",
+		nodeID, nodeAddr, chain.ChainId, isHead, isTail,
 		func() string {
 			if nextNodeAddr != "" {
 				return nextNodeAddr
@@ -151,3 +226,219 @@ func main() {
 		log.Fatalf("gRPC serve failed: %v", err)
 	}
 }
+
+// openStore opens the metadata backend named in the config.
+func openStore(db config.DBInfo) (storage.StorageClient, error) {
+	switch db.Backend {
+	case "", "cockroach":
+		store, err := storage.NewCraqStore(context.Background(), db.Addr)
+		if err != nil {
+			return nil, err
+		}
+		ran, err := store.Migrate(context.Background())
+		if err != nil {
+			return nil, fmt.Errorf("migrate schema: %w", err)
+		}
+		for _, m := range ran {
+			log.Printf("🗄️ Applied migration %04d_%s", m.Version, m.Name)
+		}
+		store.SetGroupCommit(durationOr(db.BatchWindow, storage.DefaultBatchWindow), db.MaxBatch)
+		return store, nil
+	case "embedded":
+		path := db.Path
+		if path == "" {
+			path = filepath.Join("/tmp/craq", "meta-"+nodeID+".db")
+		}
+		log.Printf("📦 Using embedded metadata at %s: not shared with other nodes", path)
+		return storage.NewBoltStore(path)
+	case "memory":
+		log.Printf("⚠️ Using in-memory metadata: not shared with other nodes, lost on restart")
+		return storage.NewMemStore(), nil
+	default:
+		return nil, fmt.Errorf("unknown db backend %q", db.Backend)
+	}
+}
+
+// openBlobs opens the blob store named in the config.
+func openBlobs(b config.BlobsInfo) (storage.BlobStore, error) {
+	switch b.Backend {
+	case "", "disk":
+		dir := b.Dir
+		if dir == "" {
+			dir = defaultBlobDir()
+		}
+		return storage.NewDiskBlobStore(dir)
+	case "memory":
+		log.Printf("⚠️ Using in-memory blobs: lost on restart")
+		return storage.NewMemBlobStore(), nil
+	case "s3":
+		prefix := b.Prefix
+		if prefix == "" {
+			prefix = nodeID
+		}
+		log.Printf("🪣 Using S3 blobs at %s/%s/%s", b.Endpoint, b.Bucket, prefix)
+		return storage.NewS3BlobStore(storage.S3Config{
+			Endpoint:  b.Endpoint,
+			Bucket:    b.Bucket,
+			Region:    b.Region,
+			AccessKey: b.AccessKey,
+			SecretKey: b.SecretKey,
+			Prefix:    prefix,
+		})
+	default:
+		return nil, fmt.Errorf("unknown blobs backend %q", b.Backend)
+	}
+}
+
+// defaultBlobDir is where a disk blob store lives unless configured. Each
+// node gets its own, since nodes sharing a host would otherwise collect
+// and overwrite each other's chunks and shards.
+func defaultBlobDir() string {
+	return filepath.Join("/tmp/craq", nodeID)
+}
+
+// archiveInfo returns the config of the archive tier, defaulting to the
+// hot store's backend with "archive" appended to its directory or prefix.
+func archiveInfo(hot, archive config.BlobsInfo) config.BlobsInfo {
+	if archive != (config.BlobsInfo{}) {
+		return archive
+	}
+	archive = hot
+	if archive.Dir == "" {
+		archive.Dir = defaultBlobDir()
+	}
+	archive.Dir = filepath.Join(archive.Dir, "archive")
+	if archive.Prefix == "" {
+		archive.Prefix = nodeID
+	}
+	archive.Prefix = path.Join(archive.Prefix, "archive")
+	return archive
+}
+
+// runMigrate implements `node migrate [status]`: it applies the pending
+// schema migrations, or with "status" lists each one and whether it has
+// been applied, without starting a node.
+func runMigrate(args []string) {
+	cfg, err := config.Load("config/config.json")
+	if err != nil {
+		log.Fatalf("failed to load config: %v", err)
+	}
+	if b := cfg.DB.Backend; b != "" && b != "cockroach" {
+		log.Fatalf("db backend %q has no schema to migrate", b)
+	}
+
+	ctx := context.Background()
+	store, err := storage.NewCraqStore(ctx, cfg.DB.Addr)
+	if err != nil {
+		log.Fatalf("store init failed: %v", err)
+	}
+
+	if len(args) > 0 && args[0] == "status" {
+		ms, err := storage.Migrations()
+		if err != nil {
+			log.Fatalf("load migrations: %v", err)
+		}
+		applied, err := store.AppliedMigrations(ctx)
+		if err != nil {
+			log.Fatalf("read applied migrations: %v", err)
+		}
+		done := make(map[int]storage.AppliedMigration, len(applied))
+		for _, a := range applied {
+			done[a.Version] = a
+		}
+		for _, m := range ms {
+			a, ok := done[m.Version]
+			switch {
+			case !ok:
+				fmt.Printf("%04d_%s\tpending\n", m.Version, m.Name)
+			case a.Checksum != m.Checksum:
+				fmt.Printf("%04d_%s\tapplied %s (modified since)\n", m.Version, m.Name, a.AppliedAt.Format(time.RFC3339))
+			default:
+				fmt.Printf("%04d_%s\tapplied %s\n", m.Version, m.Name, a.AppliedAt.Format(time.RFC3339))
+			}
+		}
+		return
+	}
+	if len(args) > 0 {
+		log.Fatalf("usage: node migrate [status]")
+	}
+
+	ran, err := store.Migrate(ctx)
+	if err != nil {
+		log.Fatalf("migrate schema: %v", err)
+	}
+	for _, m := range ran {
+		log.Printf("🗄️ Applied migration %04d_%s", m.Version, m.Name)
+	}
+	log.Printf("✅ Schema up to date (%d applied now)", len(ran))
+}
+
+// erasurePolicies validates the erasure-coded folders in the config.
+func erasurePolicies(folders map[string]config.ErasureInfo, chainLen int) map[string]craq.ErasurePolicy {
+	policies := make(map[string]craq.ErasurePolicy, len(folders))
+	for folder, e := range folders {
+		p := craq.ErasurePolicy{DataShards: e.Data, ParityShards: e.Parity}
+		if err := p.Validate(); err != nil {
+			log.Fatalf("erasure policy for %s: %v", folder, err)
+		}
+		if p.DataShards+p.ParityShards != chainLen {
+			log.Printf("⚠️ Erasure code %d+%d for %s doesn't match the chain's %d nodes, so shards are spread unevenly",
+				p.DataShards, p.ParityShards, folder, chainLen)
+		}
+		log.Printf("🧩 Erasure-coding %s as %d data + %d parity shards", folder, p.DataShards, p.ParityShards)
+		policies[storage.CleanDir(folder)] = p
+	}
+	return policies
+}
+
+// gcPolicy builds the collector policy from the config, filling defaults
+// for unset fields.
+func gcPolicy(c config.GCInfo) craq.GCPolicy {
+	p := craq.GCPolicy{
+		Interval:     durationOr(c.Interval, 10*time.Minute),
+		KeepVersions: c.KeepVersions,
+		Grace:        durationOr(c.Grace, 10*time.Minute),
+	}
+	if p.KeepVersions <= 0 {
+		p.KeepVersions = 1
+	}
+	log.Printf("🧹 GC every %v, keeping %d versions, grace %v", p.Interval, p.KeepVersions, p.Grace)
+	return p
+}
+
+// compressionPolicies checks the per-folder wire compression settings and
+// keys them by cleaned folder.
+func compressionPolicies(c map[string]string) map[string]string {
+	policies := make(map[string]string, len(c))
+	for folder, name := range c {
+		if err := compression.Validate(name); err != nil {
+			log.Fatalf("compression for %s: %v", folder, err)
+		}
+		policies[storage.CleanDir(folder)] = name
+		log.Printf("🗜️ Folder %s replicated and read with %s compression", storage.CleanDir(folder), name)
+	}
+	return policies
+}
+
+// tierPolicy builds the tiering policy from the config, filling defaults
+// for unset fields.
+func tierPolicy(c config.TierInfo) craq.TierPolicy {
+	p := craq.TierPolicy{
+		Interval:        durationOr(c.Interval, time.Hour),
+		IdleAfter:       durationOr(c.IdleAfter, 30*24*time.Hour),
+		SupersededAfter: durationOr(c.SupersededAfter, 7*24*time.Hour),
+	}
+	log.Printf("🧊 Tiering every %v: idle after %v, superseded after %v", p.Interval, p.IdleAfter, p.SupersededAfter)
+	return p
+}
+
+func durationOr(v string, def time.Duration) time.Duration {
+	if v == "" {
+		return def
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		log.Fatalf("Invalid duration %q: %v", v, err)
+	}
+	return d
+}
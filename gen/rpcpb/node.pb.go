@@ -9,6 +9,7 @@ package rpcpb
 import (
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
 	reflect "reflect"
 	sync "sync"
 	unsafe "unsafe"
@@ -21,13 +22,125 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+type SortOrder int32
+
+const (
+	SortOrder_NAME_ASC  SortOrder = 0
+	SortOrder_NAME_DESC SortOrder = 1
+)
+
+// Enum value maps for SortOrder.
+var (
+	SortOrder_name = map[int32]string{
+		0: "NAME_ASC",
+		1: "NAME_DESC",
+	}
+	SortOrder_value = map[string]int32{
+		"NAME_ASC":  0,
+		"NAME_DESC": 1,
+	}
+)
+
+func (x SortOrder) Enum() *SortOrder {
+	p := new(SortOrder)
+	*p = x
+	return p
+}
+
+func (x SortOrder) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (SortOrder) Descriptor() protoreflect.EnumDescriptor {
+	return file_node_proto_enumTypes[0].Descriptor()
+}
+
+func (SortOrder) Type() protoreflect.EnumType {
+	return &file_node_proto_enumTypes[0]
+}
+
+func (x SortOrder) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use SortOrder.Descriptor instead.
+func (SortOrder) EnumDescriptor() ([]byte, []int) {
+	return file_node_proto_rawDescGZIP(), []int{0}
+}
+
+type ChangeKind int32
+
+const (
+	ChangeKind_CREATE ChangeKind = 0
+	ChangeKind_UPDATE ChangeKind = 1
+	ChangeKind_DELETE ChangeKind = 2
+)
+
+// Enum value maps for ChangeKind.
+var (
+	ChangeKind_name = map[int32]string{
+		0: "CREATE",
+		1: "UPDATE",
+		2: "DELETE",
+	}
+	ChangeKind_value = map[string]int32{
+		"CREATE": 0,
+		"UPDATE": 1,
+		"DELETE": 2,
+	}
+)
+
+func (x ChangeKind) Enum() *ChangeKind {
+	p := new(ChangeKind)
+	*p = x
+	return p
+}
+
+func (x ChangeKind) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ChangeKind) Descriptor() protoreflect.EnumDescriptor {
+	return file_node_proto_enumTypes[1].Descriptor()
+}
+
+func (ChangeKind) Type() protoreflect.EnumType {
+	return &file_node_proto_enumTypes[1]
+}
+
+func (x ChangeKind) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ChangeKind.Descriptor instead.
+func (ChangeKind) EnumDescriptor() ([]byte, []int) {
+	return file_node_proto_rawDescGZIP(), []int{1}
+}
+
 type StreamWriteReq struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Folder        string                 `protobuf:"bytes,1,opt,name=folder,proto3" json:"folder,omitempty"`
-	Seq           uint64                 `protobuf:"varint,2,opt,name=seq,proto3" json:"seq,omitempty"`
-	FileName      string                 `protobuf:"bytes,3,opt,name=file_name,json=fileName,proto3" json:"file_name,omitempty"`
-	Path          string                 `protobuf:"bytes,4,opt,name=path,proto3" json:"path,omitempty"`
-	Data          []byte                 `protobuf:"bytes,5,opt,name=data,proto3" json:"data,omitempty"` // ✅ REQUIRED to stream file content
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Folder   string                 `protobuf:"bytes,1,opt,name=folder,proto3" json:"folder,omitempty"`
+	Seq      uint64                 `protobuf:"varint,2,opt,name=seq,proto3" json:"seq,omitempty"`
+	FileName string                 `protobuf:"bytes,3,opt,name=file_name,json=fileName,proto3" json:"file_name,omitempty"`
+	Path     string                 `protobuf:"bytes,4,opt,name=path,proto3" json:"path,omitempty"`
+	Data     []byte                 `protobuf:"bytes,5,opt,name=data,proto3" json:"data,omitempty"` // ✅ REQUIRED to stream file content
+	// Set on the first message of a node-to-node stream. Data in the
+	// following messages belongs to the content chunk named by chunk_id.
+	// In a client stream, a message with chunk_id and no data stands for
+	// that whole content chunk, which MissingChunks reported the node holds;
+	// it must start at a chunk boundary.
+	Manifest *Manifest `protobuf:"bytes,6,opt,name=manifest,proto3" json:"manifest,omitempty"`
+	ChunkId  string    `protobuf:"bytes,7,opt,name=chunk_id,json=chunkId,proto3" json:"chunk_id,omitempty"`
+	// User-defined labels (content-type, producer job id, ...). Read from the
+	// first message of a client stream only.
+	Attributes map[string]string `protobuf:"bytes,8,rep,name=attributes,proto3" json:"attributes,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// Optional lifetime of the version, from the first message of a client
+	// stream. 0 falls back to the folder default; without one it never expires.
+	TtlSeconds int64 `protobuf:"varint,9,opt,name=ttl_seconds,json=ttlSeconds,proto3" json:"ttl_seconds,omitempty"`
+	// Wire compression for this version on every hop down the chain: "gzip",
+	// "zstd" or "none". From the first message of a client stream; empty
+	// uses the folder's setting.
+	Compression   string `protobuf:"bytes,10,opt,name=compression,proto3" json:"compression,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -97,6 +210,41 @@ func (x *StreamWriteReq) GetData() []byte {
 	return nil
 }
 
+func (x *StreamWriteReq) GetManifest() *Manifest {
+	if x != nil {
+		return x.Manifest
+	}
+	return nil
+}
+
+func (x *StreamWriteReq) GetChunkId() string {
+	if x != nil {
+		return x.ChunkId
+	}
+	return ""
+}
+
+func (x *StreamWriteReq) GetAttributes() map[string]string {
+	if x != nil {
+		return x.Attributes
+	}
+	return nil
+}
+
+func (x *StreamWriteReq) GetTtlSeconds() int64 {
+	if x != nil {
+		return x.TtlSeconds
+	}
+	return 0
+}
+
+func (x *StreamWriteReq) GetCompression() string {
+	if x != nil {
+		return x.Compression
+	}
+	return ""
+}
+
 // Sent back by the tail when commit succeeds
 type WriteAck struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
@@ -158,28 +306,33 @@ func (x *WriteAck) GetSeq() uint64 {
 	return 0
 }
 
-type StreamReadReq struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Folder        string                 `protobuf:"bytes,1,opt,name=folder,proto3" json:"folder,omitempty"`
-	FileName      string                 `protobuf:"bytes,2,opt,name=file_name,json=fileName,proto3" json:"file_name,omitempty"`
+type AppendReq struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Folder   string                 `protobuf:"bytes,1,opt,name=folder,proto3" json:"folder,omitempty"`
+	FileName string                 `protobuf:"bytes,2,opt,name=file_name,json=fileName,proto3" json:"file_name,omitempty"`
+	Data     []byte                 `protobuf:"bytes,3,opt,name=data,proto3" json:"data,omitempty"`
+	// Set by the head: the new version and the version it extends.
+	Seq           uint64 `protobuf:"varint,4,opt,name=seq,proto3" json:"seq,omitempty"`
+	BaseSeq       uint64 `protobuf:"varint,5,opt,name=base_seq,json=baseSeq,proto3" json:"base_seq,omitempty"`
+	ExpiresAt     int64  `protobuf:"varint,6,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"` // expiry of a file the append creates
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *StreamReadReq) Reset() {
-	*x = StreamReadReq{}
+func (x *AppendReq) Reset() {
+	*x = AppendReq{}
 	mi := &file_node_proto_msgTypes[2]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *StreamReadReq) String() string {
+func (x *AppendReq) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*StreamReadReq) ProtoMessage() {}
+func (*AppendReq) ProtoMessage() {}
 
-func (x *StreamReadReq) ProtoReflect() protoreflect.Message {
+func (x *AppendReq) ProtoReflect() protoreflect.Message {
 	mi := &file_node_proto_msgTypes[2]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -191,46 +344,78 @@ func (x *StreamReadReq) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use StreamReadReq.ProtoReflect.Descriptor instead.
-func (*StreamReadReq) Descriptor() ([]byte, []int) {
+// Deprecated: Use AppendReq.ProtoReflect.Descriptor instead.
+func (*AppendReq) Descriptor() ([]byte, []int) {
 	return file_node_proto_rawDescGZIP(), []int{2}
 }
 
-func (x *StreamReadReq) GetFolder() string {
+func (x *AppendReq) GetFolder() string {
 	if x != nil {
 		return x.Folder
 	}
 	return ""
 }
 
-func (x *StreamReadReq) GetFileName() string {
+func (x *AppendReq) GetFileName() string {
 	if x != nil {
 		return x.FileName
 	}
 	return ""
 }
 
-type ReadChunk struct {
+func (x *AppendReq) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+func (x *AppendReq) GetSeq() uint64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+func (x *AppendReq) GetBaseSeq() uint64 {
+	if x != nil {
+		return x.BaseSeq
+	}
+	return 0
+}
+
+func (x *AppendReq) GetExpiresAt() int64 {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return 0
+}
+
+type AppendAck struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Data          []byte                 `protobuf:"bytes,1,opt,name=data,proto3" json:"data,omitempty"`
+	Folder        string                 `protobuf:"bytes,1,opt,name=folder,proto3" json:"folder,omitempty"`
+	FileName      string                 `protobuf:"bytes,2,opt,name=file_name,json=fileName,proto3" json:"file_name,omitempty"`
+	Seq           uint64                 `protobuf:"varint,3,opt,name=seq,proto3" json:"seq,omitempty"`
+	Offset        uint64                 `protobuf:"varint,4,opt,name=offset,proto3" json:"offset,omitempty"` // where the appended data starts
+	Size          uint64                 `protobuf:"varint,5,opt,name=size,proto3" json:"size,omitempty"`     // file size after the append
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ReadChunk) Reset() {
-	*x = ReadChunk{}
+func (x *AppendAck) Reset() {
+	*x = AppendAck{}
 	mi := &file_node_proto_msgTypes[3]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ReadChunk) String() string {
+func (x *AppendAck) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ReadChunk) ProtoMessage() {}
+func (*AppendAck) ProtoMessage() {}
 
-func (x *ReadChunk) ProtoReflect() protoreflect.Message {
+func (x *AppendAck) ProtoReflect() protoreflect.Message {
 	mi := &file_node_proto_msgTypes[3]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -242,41 +427,67 @@ func (x *ReadChunk) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ReadChunk.ProtoReflect.Descriptor instead.
-func (*ReadChunk) Descriptor() ([]byte, []int) {
+// Deprecated: Use AppendAck.ProtoReflect.Descriptor instead.
+func (*AppendAck) Descriptor() ([]byte, []int) {
 	return file_node_proto_rawDescGZIP(), []int{3}
 }
 
-func (x *ReadChunk) GetData() []byte {
+func (x *AppendAck) GetFolder() string {
 	if x != nil {
-		return x.Data
+		return x.Folder
 	}
-	return nil
+	return ""
 }
 
-// Used to fetch version info (used by predecessor replicas)
-type VersionQuery struct {
+func (x *AppendAck) GetFileName() string {
+	if x != nil {
+		return x.FileName
+	}
+	return ""
+}
+
+func (x *AppendAck) GetSeq() uint64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+func (x *AppendAck) GetOffset() uint64 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *AppendAck) GetSize() uint64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+type BatchAck struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Folder        string                 `protobuf:"bytes,1,opt,name=folder,proto3" json:"folder,omitempty"`
-	FileName      string                 `protobuf:"bytes,2,opt,name=file_name,json=fileName,proto3" json:"file_name,omitempty"`
+	Acks          []*WriteAck            `protobuf:"bytes,1,rep,name=acks,proto3" json:"acks,omitempty"` // in the order the files were sent
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *VersionQuery) Reset() {
-	*x = VersionQuery{}
+func (x *BatchAck) Reset() {
+	*x = BatchAck{}
 	mi := &file_node_proto_msgTypes[4]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *VersionQuery) String() string {
+func (x *BatchAck) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*VersionQuery) ProtoMessage() {}
+func (*BatchAck) ProtoMessage() {}
 
-func (x *VersionQuery) ProtoReflect() protoreflect.Message {
+func (x *BatchAck) ProtoReflect() protoreflect.Message {
 	mi := &file_node_proto_msgTypes[4]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -288,50 +499,44 @@ func (x *VersionQuery) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use VersionQuery.ProtoReflect.Descriptor instead.
-func (*VersionQuery) Descriptor() ([]byte, []int) {
+// Deprecated: Use BatchAck.ProtoReflect.Descriptor instead.
+func (*BatchAck) Descriptor() ([]byte, []int) {
 	return file_node_proto_rawDescGZIP(), []int{4}
 }
 
-func (x *VersionQuery) GetFolder() string {
-	if x != nil {
-		return x.Folder
-	}
-	return ""
-}
-
-func (x *VersionQuery) GetFileName() string {
+func (x *BatchAck) GetAcks() []*WriteAck {
 	if x != nil {
-		return x.FileName
+		return x.Acks
 	}
-	return ""
+	return nil
 }
 
-// Carries latest clean version info
-type VersionResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Folder        string                 `protobuf:"bytes,1,opt,name=folder,proto3" json:"folder,omitempty"`
-	Seq           uint64                 `protobuf:"varint,2,opt,name=seq,proto3" json:"seq,omitempty"`
-	FileName      string                 `protobuf:"bytes,3,opt,name=file_name,json=fileName,proto3" json:"file_name,omitempty"`
-	Path          string                 `protobuf:"bytes,4,opt,name=path,proto3" json:"path,omitempty"`
+type StreamReadReq struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Folder   string                 `protobuf:"bytes,1,opt,name=folder,proto3" json:"folder,omitempty"`
+	FileName string                 `protobuf:"bytes,2,opt,name=file_name,json=fileName,proto3" json:"file_name,omitempty"`
+	Snapshot string                 `protobuf:"bytes,3,opt,name=snapshot,proto3" json:"snapshot,omitempty"` // read the version recorded in this snapshot
+	// Compression of the StreamRead response; empty uses the folder's
+	// setting. Other reads are answered as the request was compressed.
+	Compression   string `protobuf:"bytes,4,opt,name=compression,proto3" json:"compression,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *VersionResponse) Reset() {
-	*x = VersionResponse{}
+func (x *StreamReadReq) Reset() {
+	*x = StreamReadReq{}
 	mi := &file_node_proto_msgTypes[5]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *VersionResponse) String() string {
+func (x *StreamReadReq) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*VersionResponse) ProtoMessage() {}
+func (*StreamReadReq) ProtoMessage() {}
 
-func (x *VersionResponse) ProtoReflect() protoreflect.Message {
+func (x *StreamReadReq) ProtoReflect() protoreflect.Message {
 	mi := &file_node_proto_msgTypes[5]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -343,61 +548,60 @@ func (x *VersionResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use VersionResponse.ProtoReflect.Descriptor instead.
-func (*VersionResponse) Descriptor() ([]byte, []int) {
+// Deprecated: Use StreamReadReq.ProtoReflect.Descriptor instead.
+func (*StreamReadReq) Descriptor() ([]byte, []int) {
 	return file_node_proto_rawDescGZIP(), []int{5}
 }
 
-func (x *VersionResponse) GetFolder() string {
+func (x *StreamReadReq) GetFolder() string {
 	if x != nil {
 		return x.Folder
 	}
 	return ""
 }
 
-func (x *VersionResponse) GetSeq() uint64 {
+func (x *StreamReadReq) GetFileName() string {
 	if x != nil {
-		return x.Seq
+		return x.FileName
 	}
-	return 0
+	return ""
 }
 
-func (x *VersionResponse) GetFileName() string {
+func (x *StreamReadReq) GetSnapshot() string {
 	if x != nil {
-		return x.FileName
+		return x.Snapshot
 	}
 	return ""
 }
 
-func (x *VersionResponse) GetPath() string {
+func (x *StreamReadReq) GetCompression() string {
 	if x != nil {
-		return x.Path
+		return x.Compression
 	}
 	return ""
 }
 
-// Request to list all files in a given folder
-type FolderQuery struct {
+type ReadChunk struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Folder        string                 `protobuf:"bytes,1,opt,name=folder,proto3" json:"folder,omitempty"`
+	Data          []byte                 `protobuf:"bytes,1,opt,name=data,proto3" json:"data,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *FolderQuery) Reset() {
-	*x = FolderQuery{}
+func (x *ReadChunk) Reset() {
+	*x = ReadChunk{}
 	mi := &file_node_proto_msgTypes[6]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *FolderQuery) String() string {
+func (x *ReadChunk) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*FolderQuery) ProtoMessage() {}
+func (*ReadChunk) ProtoMessage() {}
 
-func (x *FolderQuery) ProtoReflect() protoreflect.Message {
+func (x *ReadChunk) ProtoReflect() protoreflect.Message {
 	mi := &file_node_proto_msgTypes[6]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -409,40 +613,41 @@ func (x *FolderQuery) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use FolderQuery.ProtoReflect.Descriptor instead.
-func (*FolderQuery) Descriptor() ([]byte, []int) {
+// Deprecated: Use ReadChunk.ProtoReflect.Descriptor instead.
+func (*ReadChunk) Descriptor() ([]byte, []int) {
 	return file_node_proto_rawDescGZIP(), []int{6}
 }
 
-func (x *FolderQuery) GetFolder() string {
+func (x *ReadChunk) GetData() []byte {
 	if x != nil {
-		return x.Folder
+		return x.Data
 	}
-	return ""
+	return nil
 }
 
-// Response containing list of files
-type FileList struct {
+// Used to fetch version info (used by predecessor replicas)
+type VersionQuery struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	FileNames     []string               `protobuf:"bytes,1,rep,name=file_names,json=fileNames,proto3" json:"file_names,omitempty"`
+	Folder        string                 `protobuf:"bytes,1,opt,name=folder,proto3" json:"folder,omitempty"`
+	FileName      string                 `protobuf:"bytes,2,opt,name=file_name,json=fileName,proto3" json:"file_name,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *FileList) Reset() {
-	*x = FileList{}
+func (x *VersionQuery) Reset() {
+	*x = VersionQuery{}
 	mi := &file_node_proto_msgTypes[7]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *FileList) String() string {
+func (x *VersionQuery) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*FileList) ProtoMessage() {}
+func (*VersionQuery) ProtoMessage() {}
 
-func (x *FileList) ProtoReflect() protoreflect.Message {
+func (x *VersionQuery) ProtoReflect() protoreflect.Message {
 	mi := &file_node_proto_msgTypes[7]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -454,37 +659,1822 @@ func (x *FileList) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use FileList.ProtoReflect.Descriptor instead.
-func (*FileList) Descriptor() ([]byte, []int) {
+// Deprecated: Use VersionQuery.ProtoReflect.Descriptor instead.
+func (*VersionQuery) Descriptor() ([]byte, []int) {
 	return file_node_proto_rawDescGZIP(), []int{7}
 }
 
-func (x *FileList) GetFileNames() []string {
+func (x *VersionQuery) GetFolder() string {
 	if x != nil {
-		return x.FileNames
+		return x.Folder
 	}
-	return nil
+	return ""
 }
 
-var File_node_proto protoreflect.FileDescriptor
+func (x *VersionQuery) GetFileName() string {
+	if x != nil {
+		return x.FileName
+	}
+	return ""
+}
+
+// Carries latest clean version info
+type VersionResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Folder        string                 `protobuf:"bytes,1,opt,name=folder,proto3" json:"folder,omitempty"`
+	Seq           uint64                 `protobuf:"varint,2,opt,name=seq,proto3" json:"seq,omitempty"`
+	FileName      string                 `protobuf:"bytes,3,opt,name=file_name,json=fileName,proto3" json:"file_name,omitempty"`
+	Path          string                 `protobuf:"bytes,4,opt,name=path,proto3" json:"path,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *VersionResponse) Reset() {
+	*x = VersionResponse{}
+	mi := &file_node_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *VersionResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VersionResponse) ProtoMessage() {}
+
+func (x *VersionResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_node_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VersionResponse.ProtoReflect.Descriptor instead.
+func (*VersionResponse) Descriptor() ([]byte, []int) {
+	return file_node_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *VersionResponse) GetFolder() string {
+	if x != nil {
+		return x.Folder
+	}
+	return ""
+}
+
+func (x *VersionResponse) GetSeq() uint64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+func (x *VersionResponse) GetFileName() string {
+	if x != nil {
+		return x.FileName
+	}
+	return ""
+}
+
+func (x *VersionResponse) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+// Request to list all files in a given folder
+type FolderQuery struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Folder string                 `protobuf:"bytes,1,opt,name=folder,proto3" json:"folder,omitempty"`
+	// Entries are now always returned; kept for wire compatibility.
+	//
+	// Deprecated: Marked as deprecated in node.proto.
+	WithStat      bool      `protobuf:"varint,2,opt,name=with_stat,json=withStat,proto3" json:"with_stat,omitempty"`
+	Recursive     bool      `protobuf:"varint,3,opt,name=recursive,proto3" json:"recursive,omitempty"`                 // walk subfolders; names are relative paths
+	PageSize      uint32    `protobuf:"varint,4,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`   // 0 means the server default
+	PageToken     string    `protobuf:"bytes,5,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"` // next_page_token of the previous page
+	Pattern       string    `protobuf:"bytes,6,opt,name=pattern,proto3" json:"pattern,omitempty"`                      // glob matched against the base name
+	Order         SortOrder `protobuf:"varint,7,opt,name=order,proto3,enum=rpcpb.SortOrder" json:"order,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FolderQuery) Reset() {
+	*x = FolderQuery{}
+	mi := &file_node_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FolderQuery) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FolderQuery) ProtoMessage() {}
+
+func (x *FolderQuery) ProtoReflect() protoreflect.Message {
+	mi := &file_node_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FolderQuery.ProtoReflect.Descriptor instead.
+func (*FolderQuery) Descriptor() ([]byte, []int) {
+	return file_node_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *FolderQuery) GetFolder() string {
+	if x != nil {
+		return x.Folder
+	}
+	return ""
+}
+
+// Deprecated: Marked as deprecated in node.proto.
+func (x *FolderQuery) GetWithStat() bool {
+	if x != nil {
+		return x.WithStat
+	}
+	return false
+}
+
+func (x *FolderQuery) GetRecursive() bool {
+	if x != nil {
+		return x.Recursive
+	}
+	return false
+}
+
+func (x *FolderQuery) GetPageSize() uint32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *FolderQuery) GetPageToken() string {
+	if x != nil {
+		return x.PageToken
+	}
+	return ""
+}
+
+func (x *FolderQuery) GetPattern() string {
+	if x != nil {
+		return x.Pattern
+	}
+	return ""
+}
+
+func (x *FolderQuery) GetOrder() SortOrder {
+	if x != nil {
+		return x.Order
+	}
+	return SortOrder_NAME_ASC
+}
+
+// Response containing list of files
+type FileList struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	FileNames     []string               `protobuf:"bytes,1,rep,name=file_names,json=fileNames,proto3" json:"file_names,omitempty"`               // subfolders end in "/"
+	Entries       []*FileStat            `protobuf:"bytes,2,rep,name=entries,proto3" json:"entries,omitempty"`                                    // one per file in file_names
+	NextPageToken string                 `protobuf:"bytes,3,opt,name=next_page_token,json=nextPageToken,proto3" json:"next_page_token,omitempty"` // empty on the last page
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FileList) Reset() {
+	*x = FileList{}
+	mi := &file_node_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FileList) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FileList) ProtoMessage() {}
+
+func (x *FileList) ProtoReflect() protoreflect.Message {
+	mi := &file_node_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FileList.ProtoReflect.Descriptor instead.
+func (*FileList) Descriptor() ([]byte, []int) {
+	return file_node_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *FileList) GetFileNames() []string {
+	if x != nil {
+		return x.FileNames
+	}
+	return nil
+}
+
+func (x *FileList) GetEntries() []*FileStat {
+	if x != nil {
+		return x.Entries
+	}
+	return nil
+}
+
+func (x *FileList) GetNextPageToken() string {
+	if x != nil {
+		return x.NextPageToken
+	}
+	return ""
+}
+
+type StatReq struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Folder        string                 `protobuf:"bytes,1,opt,name=folder,proto3" json:"folder,omitempty"`
+	FileName      string                 `protobuf:"bytes,2,opt,name=file_name,json=fileName,proto3" json:"file_name,omitempty"`
+	Snapshot      string                 `protobuf:"bytes,3,opt,name=snapshot,proto3" json:"snapshot,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StatReq) Reset() {
+	*x = StatReq{}
+	mi := &file_node_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StatReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatReq) ProtoMessage() {}
+
+func (x *StatReq) ProtoReflect() protoreflect.Message {
+	mi := &file_node_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatReq.ProtoReflect.Descriptor instead.
+func (*StatReq) Descriptor() ([]byte, []int) {
+	return file_node_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *StatReq) GetFolder() string {
+	if x != nil {
+		return x.Folder
+	}
+	return ""
+}
+
+func (x *StatReq) GetFileName() string {
+	if x != nil {
+		return x.FileName
+	}
+	return ""
+}
+
+func (x *StatReq) GetSnapshot() string {
+	if x != nil {
+		return x.Snapshot
+	}
+	return ""
+}
+
+type FileStat struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Folder        string                 `protobuf:"bytes,1,opt,name=folder,proto3" json:"folder,omitempty"`
+	FileName      string                 `protobuf:"bytes,2,opt,name=file_name,json=fileName,proto3" json:"file_name,omitempty"`
+	Size          uint64                 `protobuf:"varint,3,opt,name=size,proto3" json:"size,omitempty"`
+	Seq           uint64                 `protobuf:"varint,4,opt,name=seq,proto3" json:"seq,omitempty"`
+	State         string                 `protobuf:"bytes,5,opt,name=state,proto3" json:"state,omitempty"` // "clean" or "dirty"
+	Checksum      string                 `protobuf:"bytes,6,opt,name=checksum,proto3" json:"checksum,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	ModifiedAt    *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=modified_at,json=modifiedAt,proto3" json:"modified_at,omitempty"`
+	NodeId        string                 `protobuf:"bytes,9,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"` // node that served the request
+	Attributes    map[string]string      `protobuf:"bytes,10,rep,name=attributes,proto3" json:"attributes,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FileStat) Reset() {
+	*x = FileStat{}
+	mi := &file_node_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FileStat) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FileStat) ProtoMessage() {}
+
+func (x *FileStat) ProtoReflect() protoreflect.Message {
+	mi := &file_node_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FileStat.ProtoReflect.Descriptor instead.
+func (*FileStat) Descriptor() ([]byte, []int) {
+	return file_node_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *FileStat) GetFolder() string {
+	if x != nil {
+		return x.Folder
+	}
+	return ""
+}
+
+func (x *FileStat) GetFileName() string {
+	if x != nil {
+		return x.FileName
+	}
+	return ""
+}
+
+func (x *FileStat) GetSize() uint64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *FileStat) GetSeq() uint64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+func (x *FileStat) GetState() string {
+	if x != nil {
+		return x.State
+	}
+	return ""
+}
+
+func (x *FileStat) GetChecksum() string {
+	if x != nil {
+		return x.Checksum
+	}
+	return ""
+}
+
+func (x *FileStat) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *FileStat) GetModifiedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ModifiedAt
+	}
+	return nil
+}
+
+func (x *FileStat) GetNodeId() string {
+	if x != nil {
+		return x.NodeId
+	}
+	return ""
+}
+
+func (x *FileStat) GetAttributes() map[string]string {
+	if x != nil {
+		return x.Attributes
+	}
+	return nil
+}
+
+// A fixed-size piece of file content, addressed by its SHA-256
+type ChunkRef struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Size  uint64                 `protobuf:"varint,2,opt,name=size,proto3" json:"size,omitempty"`
+	// Erasure-coded chunks: ids of the data shards then the parity shards,
+	// each size/data_shards bytes rounded up. The chunk itself isn't stored.
+	Shards     []string `protobuf:"bytes,3,rep,name=shards,proto3" json:"shards,omitempty"`
+	DataShards uint32   `protobuf:"varint,4,opt,name=data_shards,json=dataShards,proto3" json:"data_shards,omitempty"`
+	// Set on FetchChunk requests only, never in manifests: the folder of the
+	// file the chunk is read for, whose compression setting the reply
+	// follows, and the compression the client asks for instead.
+	Folder        string `protobuf:"bytes,5,opt,name=folder,proto3" json:"folder,omitempty"`
+	Compression   string `protobuf:"bytes,6,opt,name=compression,proto3" json:"compression,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ChunkRef) Reset() {
+	*x = ChunkRef{}
+	mi := &file_node_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ChunkRef) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ChunkRef) ProtoMessage() {}
+
+func (x *ChunkRef) ProtoReflect() protoreflect.Message {
+	mi := &file_node_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ChunkRef.ProtoReflect.Descriptor instead.
+func (*ChunkRef) Descriptor() ([]byte, []int) {
+	return file_node_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *ChunkRef) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *ChunkRef) GetSize() uint64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *ChunkRef) GetShards() []string {
+	if x != nil {
+		return x.Shards
+	}
+	return nil
+}
+
+func (x *ChunkRef) GetDataShards() uint32 {
+	if x != nil {
+		return x.DataShards
+	}
+	return 0
+}
+
+func (x *ChunkRef) GetFolder() string {
+	if x != nil {
+		return x.Folder
+	}
+	return ""
+}
+
+func (x *ChunkRef) GetCompression() string {
+	if x != nil {
+		return x.Compression
+	}
+	return ""
+}
+
+// Reed-Solomon layout of an erasure-coded version
+type Erasure struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	DataShards    uint32                 `protobuf:"varint,1,opt,name=data_shards,json=dataShards,proto3" json:"data_shards,omitempty"`
+	ParityShards  uint32                 `protobuf:"varint,2,opt,name=parity_shards,json=parityShards,proto3" json:"parity_shards,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Erasure) Reset() {
+	*x = Erasure{}
+	mi := &file_node_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Erasure) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Erasure) ProtoMessage() {}
+
+func (x *Erasure) ProtoReflect() protoreflect.Message {
+	mi := &file_node_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Erasure.ProtoReflect.Descriptor instead.
+func (*Erasure) Descriptor() ([]byte, []int) {
+	return file_node_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *Erasure) GetDataShards() uint32 {
+	if x != nil {
+		return x.DataShards
+	}
+	return 0
+}
+
+func (x *Erasure) GetParityShards() uint32 {
+	if x != nil {
+		return x.ParityShards
+	}
+	return 0
+}
+
+// Ordered list of content chunks making up one version of a file
+type Manifest struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	Folder     string                 `protobuf:"bytes,1,opt,name=folder,proto3" json:"folder,omitempty"`
+	FileName   string                 `protobuf:"bytes,2,opt,name=file_name,json=fileName,proto3" json:"file_name,omitempty"`
+	Seq        uint64                 `protobuf:"varint,3,opt,name=seq,proto3" json:"seq,omitempty"`
+	Size       uint64                 `protobuf:"varint,4,opt,name=size,proto3" json:"size,omitempty"`
+	ChunkSize  uint32                 `protobuf:"varint,5,opt,name=chunk_size,json=chunkSize,proto3" json:"chunk_size,omitempty"`
+	Checksum   string                 `protobuf:"bytes,6,opt,name=checksum,proto3" json:"checksum,omitempty"` // SHA-256 of the whole file
+	Chunks     []*ChunkRef            `protobuf:"bytes,7,rep,name=chunks,proto3" json:"chunks,omitempty"`
+	Attributes map[string]string      `protobuf:"bytes,8,rep,name=attributes,proto3" json:"attributes,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	HashState  []byte                 `protobuf:"bytes,9,opt,name=hash_state,json=hashState,proto3" json:"hash_state,omitempty"`   // SHA-256 state after the last byte, for appends
+	ExpiresAt  int64                  `protobuf:"varint,10,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"` // unix seconds; 0 = never. Set by the head.
+	Erasure    *Erasure               `protobuf:"bytes,11,opt,name=erasure,proto3" json:"erasure,omitempty"`                       // unset: every node stores every chunk
+	// No longer set. Nodes used to stamp their copy of the manifest when
+	// they archived the version's chunks, which made the rewrite look like a
+	// new write; the mark now lives in a blob of its own.
+	ArchivedAt    int64 `protobuf:"varint,12,opt,name=archived_at,json=archivedAt,proto3" json:"archived_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Manifest) Reset() {
+	*x = Manifest{}
+	mi := &file_node_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Manifest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Manifest) ProtoMessage() {}
+
+func (x *Manifest) ProtoReflect() protoreflect.Message {
+	mi := &file_node_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Manifest.ProtoReflect.Descriptor instead.
+func (*Manifest) Descriptor() ([]byte, []int) {
+	return file_node_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *Manifest) GetFolder() string {
+	if x != nil {
+		return x.Folder
+	}
+	return ""
+}
+
+func (x *Manifest) GetFileName() string {
+	if x != nil {
+		return x.FileName
+	}
+	return ""
+}
+
+func (x *Manifest) GetSeq() uint64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+func (x *Manifest) GetSize() uint64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *Manifest) GetChunkSize() uint32 {
+	if x != nil {
+		return x.ChunkSize
+	}
+	return 0
+}
+
+func (x *Manifest) GetChecksum() string {
+	if x != nil {
+		return x.Checksum
+	}
+	return ""
+}
+
+func (x *Manifest) GetChunks() []*ChunkRef {
+	if x != nil {
+		return x.Chunks
+	}
+	return nil
+}
+
+func (x *Manifest) GetAttributes() map[string]string {
+	if x != nil {
+		return x.Attributes
+	}
+	return nil
+}
+
+func (x *Manifest) GetHashState() []byte {
+	if x != nil {
+		return x.HashState
+	}
+	return nil
+}
+
+func (x *Manifest) GetExpiresAt() int64 {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return 0
+}
+
+func (x *Manifest) GetErasure() *Erasure {
+	if x != nil {
+		return x.Erasure
+	}
+	return nil
+}
+
+func (x *Manifest) GetArchivedAt() int64 {
+	if x != nil {
+		return x.ArchivedAt
+	}
+	return 0
+}
+
+type ChunkSet struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Ids           []string               `protobuf:"bytes,1,rep,name=ids,proto3" json:"ids,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ChunkSet) Reset() {
+	*x = ChunkSet{}
+	mi := &file_node_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ChunkSet) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ChunkSet) ProtoMessage() {}
+
+func (x *ChunkSet) ProtoReflect() protoreflect.Message {
+	mi := &file_node_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ChunkSet.ProtoReflect.Descriptor instead.
+func (*ChunkSet) Descriptor() ([]byte, []int) {
+	return file_node_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *ChunkSet) GetIds() []string {
+	if x != nil {
+		return x.Ids
+	}
+	return nil
+}
+
+type DirReq struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Path  string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	// Mkdir: create missing parents. Rmdir: remove subfolders and files.
+	Recursive bool `protobuf:"varint,2,opt,name=recursive,proto3" json:"recursive,omitempty"`
+	// Assigned by the head; identifies the operation on every replica.
+	OpId          uint64 `protobuf:"varint,3,opt,name=op_id,json=opId,proto3" json:"op_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DirReq) Reset() {
+	*x = DirReq{}
+	mi := &file_node_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DirReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DirReq) ProtoMessage() {}
+
+func (x *DirReq) ProtoReflect() protoreflect.Message {
+	mi := &file_node_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DirReq.ProtoReflect.Descriptor instead.
+func (*DirReq) Descriptor() ([]byte, []int) {
+	return file_node_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *DirReq) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *DirReq) GetRecursive() bool {
+	if x != nil {
+		return x.Recursive
+	}
+	return false
+}
+
+func (x *DirReq) GetOpId() uint64 {
+	if x != nil {
+		return x.OpId
+	}
+	return 0
+}
+
+type DirInfo struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Path          string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DirInfo) Reset() {
+	*x = DirInfo{}
+	mi := &file_node_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DirInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DirInfo) ProtoMessage() {}
+
+func (x *DirInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_node_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DirInfo.ProtoReflect.Descriptor instead.
+func (*DirInfo) Descriptor() ([]byte, []int) {
+	return file_node_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *DirInfo) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *DirInfo) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+type DirEntry struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	IsDir         bool                   `protobuf:"varint,2,opt,name=is_dir,json=isDir,proto3" json:"is_dir,omitempty"`
+	Stat          *FileStat              `protobuf:"bytes,3,opt,name=stat,proto3" json:"stat,omitempty"` // files only
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DirEntry) Reset() {
+	*x = DirEntry{}
+	mi := &file_node_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DirEntry) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DirEntry) ProtoMessage() {}
+
+func (x *DirEntry) ProtoReflect() protoreflect.Message {
+	mi := &file_node_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DirEntry.ProtoReflect.Descriptor instead.
+func (*DirEntry) Descriptor() ([]byte, []int) {
+	return file_node_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *DirEntry) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *DirEntry) GetIsDir() bool {
+	if x != nil {
+		return x.IsDir
+	}
+	return false
+}
+
+func (x *DirEntry) GetStat() *FileStat {
+	if x != nil {
+		return x.Stat
+	}
+	return nil
+}
+
+type DirListing struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Entries       []*DirEntry            `protobuf:"bytes,1,rep,name=entries,proto3" json:"entries,omitempty"`
+	NextPageToken string                 `protobuf:"bytes,2,opt,name=next_page_token,json=nextPageToken,proto3" json:"next_page_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DirListing) Reset() {
+	*x = DirListing{}
+	mi := &file_node_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DirListing) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DirListing) ProtoMessage() {}
+
+func (x *DirListing) ProtoReflect() protoreflect.Message {
+	mi := &file_node_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DirListing.ProtoReflect.Descriptor instead.
+func (*DirListing) Descriptor() ([]byte, []int) {
+	return file_node_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *DirListing) GetEntries() []*DirEntry {
+	if x != nil {
+		return x.Entries
+	}
+	return nil
+}
+
+func (x *DirListing) GetNextPageToken() string {
+	if x != nil {
+		return x.NextPageToken
+	}
+	return ""
+}
+
+type WatchReq struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Folder    string                 `protobuf:"bytes,1,opt,name=folder,proto3" json:"folder,omitempty"`
+	Recursive bool                   `protobuf:"varint,2,opt,name=recursive,proto3" json:"recursive,omitempty"`
+	// Resume after this event cursor; 0 starts with events from now on.
+	SinceSeq      uint64 `protobuf:"varint,3,opt,name=since_seq,json=sinceSeq,proto3" json:"since_seq,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchReq) Reset() {
+	*x = WatchReq{}
+	mi := &file_node_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchReq) ProtoMessage() {}
+
+func (x *WatchReq) ProtoReflect() protoreflect.Message {
+	mi := &file_node_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchReq.ProtoReflect.Descriptor instead.
+func (*WatchReq) Descriptor() ([]byte, []int) {
+	return file_node_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *WatchReq) GetFolder() string {
+	if x != nil {
+		return x.Folder
+	}
+	return ""
+}
+
+func (x *WatchReq) GetRecursive() bool {
+	if x != nil {
+		return x.Recursive
+	}
+	return false
+}
+
+func (x *WatchReq) GetSinceSeq() uint64 {
+	if x != nil {
+		return x.SinceSeq
+	}
+	return 0
+}
+
+type ChangeEvent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Cursor        uint64                 `protobuf:"varint,1,opt,name=cursor,proto3" json:"cursor,omitempty"` // pass as since_seq to resume after this event
+	Kind          ChangeKind             `protobuf:"varint,2,opt,name=kind,proto3,enum=rpcpb.ChangeKind" json:"kind,omitempty"`
+	Folder        string                 `protobuf:"bytes,3,opt,name=folder,proto3" json:"folder,omitempty"`
+	FileName      string                 `protobuf:"bytes,4,opt,name=file_name,json=fileName,proto3" json:"file_name,omitempty"` // directory name when is_dir is set
+	Seq           uint64                 `protobuf:"varint,5,opt,name=seq,proto3" json:"seq,omitempty"`                          // committed file version
+	IsDir         bool                   `protobuf:"varint,6,opt,name=is_dir,json=isDir,proto3" json:"is_dir,omitempty"`
+	CommittedAt   *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=committed_at,json=committedAt,proto3" json:"committed_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ChangeEvent) Reset() {
+	*x = ChangeEvent{}
+	mi := &file_node_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ChangeEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ChangeEvent) ProtoMessage() {}
+
+func (x *ChangeEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_node_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ChangeEvent.ProtoReflect.Descriptor instead.
+func (*ChangeEvent) Descriptor() ([]byte, []int) {
+	return file_node_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *ChangeEvent) GetCursor() uint64 {
+	if x != nil {
+		return x.Cursor
+	}
+	return 0
+}
+
+func (x *ChangeEvent) GetKind() ChangeKind {
+	if x != nil {
+		return x.Kind
+	}
+	return ChangeKind_CREATE
+}
+
+func (x *ChangeEvent) GetFolder() string {
+	if x != nil {
+		return x.Folder
+	}
+	return ""
+}
+
+func (x *ChangeEvent) GetFileName() string {
+	if x != nil {
+		return x.FileName
+	}
+	return ""
+}
+
+func (x *ChangeEvent) GetSeq() uint64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+func (x *ChangeEvent) GetIsDir() bool {
+	if x != nil {
+		return x.IsDir
+	}
+	return false
+}
+
+func (x *ChangeEvent) GetCommittedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CommittedAt
+	}
+	return nil
+}
+
+type SnapshotReq struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Name   string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Folder string                 `protobuf:"bytes,2,opt,name=folder,proto3" json:"folder,omitempty"` // only for CreateSnapshot
+	// Set by the head when it forwards CreateSnapshot: the file versions it
+	// pinned and when, so every replica pins the same ones.
+	Files         []*SnapshotFile        `protobuf:"bytes,3,rep,name=files,proto3" json:"files,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SnapshotReq) Reset() {
+	*x = SnapshotReq{}
+	mi := &file_node_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SnapshotReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SnapshotReq) ProtoMessage() {}
+
+func (x *SnapshotReq) ProtoReflect() protoreflect.Message {
+	mi := &file_node_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SnapshotReq.ProtoReflect.Descriptor instead.
+func (*SnapshotReq) Descriptor() ([]byte, []int) {
+	return file_node_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *SnapshotReq) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *SnapshotReq) GetFolder() string {
+	if x != nil {
+		return x.Folder
+	}
+	return ""
+}
+
+func (x *SnapshotReq) GetFiles() []*SnapshotFile {
+	if x != nil {
+		return x.Files
+	}
+	return nil
+}
+
+func (x *SnapshotReq) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+type SnapshotFile struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Folder        string                 `protobuf:"bytes,1,opt,name=folder,proto3" json:"folder,omitempty"`
+	FileName      string                 `protobuf:"bytes,2,opt,name=file_name,json=fileName,proto3" json:"file_name,omitempty"`
+	Seq           uint64                 `protobuf:"varint,3,opt,name=seq,proto3" json:"seq,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SnapshotFile) Reset() {
+	*x = SnapshotFile{}
+	mi := &file_node_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SnapshotFile) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SnapshotFile) ProtoMessage() {}
+
+func (x *SnapshotFile) ProtoReflect() protoreflect.Message {
+	mi := &file_node_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SnapshotFile.ProtoReflect.Descriptor instead.
+func (*SnapshotFile) Descriptor() ([]byte, []int) {
+	return file_node_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *SnapshotFile) GetFolder() string {
+	if x != nil {
+		return x.Folder
+	}
+	return ""
+}
+
+func (x *SnapshotFile) GetFileName() string {
+	if x != nil {
+		return x.FileName
+	}
+	return ""
+}
+
+func (x *SnapshotFile) GetSeq() uint64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+type SnapshotInfo struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Folder        string                 `protobuf:"bytes,2,opt,name=folder,proto3" json:"folder,omitempty"`
+	FileCount     uint64                 `protobuf:"varint,3,opt,name=file_count,json=fileCount,proto3" json:"file_count,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SnapshotInfo) Reset() {
+	*x = SnapshotInfo{}
+	mi := &file_node_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SnapshotInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SnapshotInfo) ProtoMessage() {}
+
+func (x *SnapshotInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_node_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SnapshotInfo.ProtoReflect.Descriptor instead.
+func (*SnapshotInfo) Descriptor() ([]byte, []int) {
+	return file_node_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *SnapshotInfo) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *SnapshotInfo) GetFolder() string {
+	if x != nil {
+		return x.Folder
+	}
+	return ""
+}
+
+func (x *SnapshotInfo) GetFileCount() uint64 {
+	if x != nil {
+		return x.FileCount
+	}
+	return 0
+}
+
+func (x *SnapshotInfo) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+type ListSnapshotsReq struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListSnapshotsReq) Reset() {
+	*x = ListSnapshotsReq{}
+	mi := &file_node_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListSnapshotsReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListSnapshotsReq) ProtoMessage() {}
+
+func (x *ListSnapshotsReq) ProtoReflect() protoreflect.Message {
+	mi := &file_node_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListSnapshotsReq.ProtoReflect.Descriptor instead.
+func (*ListSnapshotsReq) Descriptor() ([]byte, []int) {
+	return file_node_proto_rawDescGZIP(), []int{26}
+}
+
+type SnapshotList struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Snapshots     []*SnapshotInfo        `protobuf:"bytes,1,rep,name=snapshots,proto3" json:"snapshots,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SnapshotList) Reset() {
+	*x = SnapshotList{}
+	mi := &file_node_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SnapshotList) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SnapshotList) ProtoMessage() {}
+
+func (x *SnapshotList) ProtoReflect() protoreflect.Message {
+	mi := &file_node_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SnapshotList.ProtoReflect.Descriptor instead.
+func (*SnapshotList) Descriptor() ([]byte, []int) {
+	return file_node_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *SnapshotList) GetSnapshots() []*SnapshotInfo {
+	if x != nil {
+		return x.Snapshots
+	}
+	return nil
+}
+
+type Quota struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Folder        string                 `protobuf:"bytes,1,opt,name=folder,proto3" json:"folder,omitempty"`
+	MaxBytes      uint64                 `protobuf:"varint,2,opt,name=max_bytes,json=maxBytes,proto3" json:"max_bytes,omitempty"`
+	MaxFiles      uint64                 `protobuf:"varint,3,opt,name=max_files,json=maxFiles,proto3" json:"max_files,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Quota) Reset() {
+	*x = Quota{}
+	mi := &file_node_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Quota) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Quota) ProtoMessage() {}
+
+func (x *Quota) ProtoReflect() protoreflect.Message {
+	mi := &file_node_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Quota.ProtoReflect.Descriptor instead.
+func (*Quota) Descriptor() ([]byte, []int) {
+	return file_node_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *Quota) GetFolder() string {
+	if x != nil {
+		return x.Folder
+	}
+	return ""
+}
+
+func (x *Quota) GetMaxBytes() uint64 {
+	if x != nil {
+		return x.MaxBytes
+	}
+	return 0
+}
+
+func (x *Quota) GetMaxFiles() uint64 {
+	if x != nil {
+		return x.MaxFiles
+	}
+	return 0
+}
+
+type UsageReq struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Folder        string                 `protobuf:"bytes,1,opt,name=folder,proto3" json:"folder,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UsageReq) Reset() {
+	*x = UsageReq{}
+	mi := &file_node_proto_msgTypes[29]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UsageReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UsageReq) ProtoMessage() {}
+
+func (x *UsageReq) ProtoReflect() protoreflect.Message {
+	mi := &file_node_proto_msgTypes[29]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UsageReq.ProtoReflect.Descriptor instead.
+func (*UsageReq) Descriptor() ([]byte, []int) {
+	return file_node_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *UsageReq) GetFolder() string {
+	if x != nil {
+		return x.Folder
+	}
+	return ""
+}
+
+// QuotaUsage reports what a folder tree holds against its quota, if any.
+type QuotaUsage struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Quota         *Quota                 `protobuf:"bytes,1,opt,name=quota,proto3" json:"quota,omitempty"`
+	UsedBytes     uint64                 `protobuf:"varint,2,opt,name=used_bytes,json=usedBytes,proto3" json:"used_bytes,omitempty"`
+	UsedFiles     uint64                 `protobuf:"varint,3,opt,name=used_files,json=usedFiles,proto3" json:"used_files,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *QuotaUsage) Reset() {
+	*x = QuotaUsage{}
+	mi := &file_node_proto_msgTypes[30]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *QuotaUsage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*QuotaUsage) ProtoMessage() {}
+
+func (x *QuotaUsage) ProtoReflect() protoreflect.Message {
+	mi := &file_node_proto_msgTypes[30]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use QuotaUsage.ProtoReflect.Descriptor instead.
+func (*QuotaUsage) Descriptor() ([]byte, []int) {
+	return file_node_proto_rawDescGZIP(), []int{30}
+}
+
+func (x *QuotaUsage) GetQuota() *Quota {
+	if x != nil {
+		return x.Quota
+	}
+	return nil
+}
+
+func (x *QuotaUsage) GetUsedBytes() uint64 {
+	if x != nil {
+		return x.UsedBytes
+	}
+	return 0
+}
+
+func (x *QuotaUsage) GetUsedFiles() uint64 {
+	if x != nil {
+		return x.UsedFiles
+	}
+	return 0
+}
+
+type ListQuotasReq struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListQuotasReq) Reset() {
+	*x = ListQuotasReq{}
+	mi := &file_node_proto_msgTypes[31]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListQuotasReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListQuotasReq) ProtoMessage() {}
+
+func (x *ListQuotasReq) ProtoReflect() protoreflect.Message {
+	mi := &file_node_proto_msgTypes[31]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListQuotasReq.ProtoReflect.Descriptor instead.
+func (*ListQuotasReq) Descriptor() ([]byte, []int) {
+	return file_node_proto_rawDescGZIP(), []int{31}
+}
+
+type QuotaList struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Quotas        []*QuotaUsage          `protobuf:"bytes,1,rep,name=quotas,proto3" json:"quotas,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *QuotaList) Reset() {
+	*x = QuotaList{}
+	mi := &file_node_proto_msgTypes[32]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *QuotaList) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*QuotaList) ProtoMessage() {}
+
+func (x *QuotaList) ProtoReflect() protoreflect.Message {
+	mi := &file_node_proto_msgTypes[32]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use QuotaList.ProtoReflect.Descriptor instead.
+func (*QuotaList) Descriptor() ([]byte, []int) {
+	return file_node_proto_rawDescGZIP(), []int{32}
+}
+
+func (x *QuotaList) GetQuotas() []*QuotaUsage {
+	if x != nil {
+		return x.Quotas
+	}
+	return nil
+}
+
+type DeleteReq struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Folder        string                 `protobuf:"bytes,1,opt,name=folder,proto3" json:"folder,omitempty"`
+	FileName      string                 `protobuf:"bytes,2,opt,name=file_name,json=fileName,proto3" json:"file_name,omitempty"`
+	Seq           uint64                 `protobuf:"varint,3,opt,name=seq,proto3" json:"seq,omitempty"`                              // set by the head
+	IfExpired     bool                   `protobuf:"varint,4,opt,name=if_expired,json=ifExpired,proto3" json:"if_expired,omitempty"` // only delete if the latest version has expired
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteReq) Reset() {
+	*x = DeleteReq{}
+	mi := &file_node_proto_msgTypes[33]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteReq) ProtoMessage() {}
+
+func (x *DeleteReq) ProtoReflect() protoreflect.Message {
+	mi := &file_node_proto_msgTypes[33]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteReq.ProtoReflect.Descriptor instead.
+func (*DeleteReq) Descriptor() ([]byte, []int) {
+	return file_node_proto_rawDescGZIP(), []int{33}
+}
+
+func (x *DeleteReq) GetFolder() string {
+	if x != nil {
+		return x.Folder
+	}
+	return ""
+}
+
+func (x *DeleteReq) GetFileName() string {
+	if x != nil {
+		return x.FileName
+	}
+	return ""
+}
+
+func (x *DeleteReq) GetSeq() uint64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+func (x *DeleteReq) GetIfExpired() bool {
+	if x != nil {
+		return x.IfExpired
+	}
+	return false
+}
+
+type FolderTTL struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Folder        string                 `protobuf:"bytes,1,opt,name=folder,proto3" json:"folder,omitempty"`
+	TtlSeconds    int64                  `protobuf:"varint,2,opt,name=ttl_seconds,json=ttlSeconds,proto3" json:"ttl_seconds,omitempty"` // 0 removes the default
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FolderTTL) Reset() {
+	*x = FolderTTL{}
+	mi := &file_node_proto_msgTypes[34]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FolderTTL) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FolderTTL) ProtoMessage() {}
+
+func (x *FolderTTL) ProtoReflect() protoreflect.Message {
+	mi := &file_node_proto_msgTypes[34]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FolderTTL.ProtoReflect.Descriptor instead.
+func (*FolderTTL) Descriptor() ([]byte, []int) {
+	return file_node_proto_rawDescGZIP(), []int{34}
+}
+
+func (x *FolderTTL) GetFolder() string {
+	if x != nil {
+		return x.Folder
+	}
+	return ""
+}
+
+func (x *FolderTTL) GetTtlSeconds() int64 {
+	if x != nil {
+		return x.TtlSeconds
+	}
+	return 0
+}
+
+var File_node_proto protoreflect.FileDescriptor
 
 const file_node_proto_rawDesc = "" +
 	"\n" +
 	"\n" +
-	"node.proto\x12\x05rpcpb\"\x7f\n" +
+	"node.proto\x12\x05rpcpb\x1a\x1fgoogle/protobuf/timestamp.proto\"\x90\x03\n" +
 	"\x0eStreamWriteReq\x12\x16\n" +
 	"\x06folder\x18\x01 \x01(\tR\x06folder\x12\x10\n" +
 	"\x03seq\x18\x02 \x01(\x04R\x03seq\x12\x1b\n" +
 	"\tfile_name\x18\x03 \x01(\tR\bfileName\x12\x12\n" +
 	"\x04path\x18\x04 \x01(\tR\x04path\x12\x12\n" +
-	"\x04data\x18\x05 \x01(\fR\x04data\"Q\n" +
+	"\x04data\x18\x05 \x01(\fR\x04data\x12+\n" +
+	"\bmanifest\x18\x06 \x01(\v2\x0f.rpcpb.ManifestR\bmanifest\x12\x19\n" +
+	"\bchunk_id\x18\a \x01(\tR\achunkId\x12E\n" +
+	"\n" +
+	"attributes\x18\b \x03(\v2%.rpcpb.StreamWriteReq.AttributesEntryR\n" +
+	"attributes\x12\x1f\n" +
+	"\vttl_seconds\x18\t \x01(\x03R\n" +
+	"ttlSeconds\x12 \n" +
+	"\vcompression\x18\n" +
+	" \x01(\tR\vcompression\x1a=\n" +
+	"\x0fAttributesEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"Q\n" +
 	"\bWriteAck\x12\x16\n" +
 	"\x06folder\x18\x01 \x01(\tR\x06folder\x12\x1b\n" +
 	"\tfile_name\x18\x02 \x01(\tR\bfileName\x12\x10\n" +
-	"\x03seq\x18\x03 \x01(\x04R\x03seq\"D\n" +
+	"\x03seq\x18\x03 \x01(\x04R\x03seq\"\xa0\x01\n" +
+	"\tAppendReq\x12\x16\n" +
+	"\x06folder\x18\x01 \x01(\tR\x06folder\x12\x1b\n" +
+	"\tfile_name\x18\x02 \x01(\tR\bfileName\x12\x12\n" +
+	"\x04data\x18\x03 \x01(\fR\x04data\x12\x10\n" +
+	"\x03seq\x18\x04 \x01(\x04R\x03seq\x12\x19\n" +
+	"\bbase_seq\x18\x05 \x01(\x04R\abaseSeq\x12\x1d\n" +
+	"\n" +
+	"expires_at\x18\x06 \x01(\x03R\texpiresAt\"~\n" +
+	"\tAppendAck\x12\x16\n" +
+	"\x06folder\x18\x01 \x01(\tR\x06folder\x12\x1b\n" +
+	"\tfile_name\x18\x02 \x01(\tR\bfileName\x12\x10\n" +
+	"\x03seq\x18\x03 \x01(\x04R\x03seq\x12\x16\n" +
+	"\x06offset\x18\x04 \x01(\x04R\x06offset\x12\x12\n" +
+	"\x04size\x18\x05 \x01(\x04R\x04size\"/\n" +
+	"\bBatchAck\x12#\n" +
+	"\x04acks\x18\x01 \x03(\v2\x0f.rpcpb.WriteAckR\x04acks\"\x82\x01\n" +
 	"\rStreamReadReq\x12\x16\n" +
 	"\x06folder\x18\x01 \x01(\tR\x06folder\x12\x1b\n" +
-	"\tfile_name\x18\x02 \x01(\tR\bfileName\"\x1f\n" +
+	"\tfile_name\x18\x02 \x01(\tR\bfileName\x12\x1a\n" +
+	"\bsnapshot\x18\x03 \x01(\tR\bsnapshot\x12 \n" +
+	"\vcompression\x18\x04 \x01(\tR\vcompression\"\x1f\n" +
 	"\tReadChunk\x12\x12\n" +
 	"\x04data\x18\x01 \x01(\fR\x04data\"C\n" +
 	"\fVersionQuery\x12\x16\n" +
@@ -494,18 +2484,193 @@ const file_node_proto_rawDesc = "" +
 	"\x06folder\x18\x01 \x01(\tR\x06folder\x12\x10\n" +
 	"\x03seq\x18\x02 \x01(\x04R\x03seq\x12\x1b\n" +
 	"\tfile_name\x18\x03 \x01(\tR\bfileName\x12\x12\n" +
-	"\x04path\x18\x04 \x01(\tR\x04path\"%\n" +
+	"\x04path\x18\x04 \x01(\tR\x04path\"\xe2\x01\n" +
 	"\vFolderQuery\x12\x16\n" +
-	"\x06folder\x18\x01 \x01(\tR\x06folder\")\n" +
+	"\x06folder\x18\x01 \x01(\tR\x06folder\x12\x1f\n" +
+	"\twith_stat\x18\x02 \x01(\bB\x02\x18\x01R\bwithStat\x12\x1c\n" +
+	"\trecursive\x18\x03 \x01(\bR\trecursive\x12\x1b\n" +
+	"\tpage_size\x18\x04 \x01(\rR\bpageSize\x12\x1d\n" +
+	"\n" +
+	"page_token\x18\x05 \x01(\tR\tpageToken\x12\x18\n" +
+	"\apattern\x18\x06 \x01(\tR\apattern\x12&\n" +
+	"\x05order\x18\a \x01(\x0e2\x10.rpcpb.SortOrderR\x05order\"|\n" +
 	"\bFileList\x12\x1d\n" +
 	"\n" +
-	"file_names\x18\x01 \x03(\tR\tfileNames2\xe6\x01\n" +
+	"file_names\x18\x01 \x03(\tR\tfileNames\x12)\n" +
+	"\aentries\x18\x02 \x03(\v2\x0f.rpcpb.FileStatR\aentries\x12&\n" +
+	"\x0fnext_page_token\x18\x03 \x01(\tR\rnextPageToken\"Z\n" +
+	"\aStatReq\x12\x16\n" +
+	"\x06folder\x18\x01 \x01(\tR\x06folder\x12\x1b\n" +
+	"\tfile_name\x18\x02 \x01(\tR\bfileName\x12\x1a\n" +
+	"\bsnapshot\x18\x03 \x01(\tR\bsnapshot\"\xa8\x03\n" +
+	"\bFileStat\x12\x16\n" +
+	"\x06folder\x18\x01 \x01(\tR\x06folder\x12\x1b\n" +
+	"\tfile_name\x18\x02 \x01(\tR\bfileName\x12\x12\n" +
+	"\x04size\x18\x03 \x01(\x04R\x04size\x12\x10\n" +
+	"\x03seq\x18\x04 \x01(\x04R\x03seq\x12\x14\n" +
+	"\x05state\x18\x05 \x01(\tR\x05state\x12\x1a\n" +
+	"\bchecksum\x18\x06 \x01(\tR\bchecksum\x129\n" +
+	"\n" +
+	"created_at\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x12;\n" +
+	"\vmodified_at\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"modifiedAt\x12\x17\n" +
+	"\anode_id\x18\t \x01(\tR\x06nodeId\x12?\n" +
+	"\n" +
+	"attributes\x18\n" +
+	" \x03(\v2\x1f.rpcpb.FileStat.AttributesEntryR\n" +
+	"attributes\x1a=\n" +
+	"\x0fAttributesEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xa1\x01\n" +
+	"\bChunkRef\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04size\x18\x02 \x01(\x04R\x04size\x12\x16\n" +
+	"\x06shards\x18\x03 \x03(\tR\x06shards\x12\x1f\n" +
+	"\vdata_shards\x18\x04 \x01(\rR\n" +
+	"dataShards\x12\x16\n" +
+	"\x06folder\x18\x05 \x01(\tR\x06folder\x12 \n" +
+	"\vcompression\x18\x06 \x01(\tR\vcompression\"O\n" +
+	"\aErasure\x12\x1f\n" +
+	"\vdata_shards\x18\x01 \x01(\rR\n" +
+	"dataShards\x12#\n" +
+	"\rparity_shards\x18\x02 \x01(\rR\fparityShards\"\xd2\x03\n" +
+	"\bManifest\x12\x16\n" +
+	"\x06folder\x18\x01 \x01(\tR\x06folder\x12\x1b\n" +
+	"\tfile_name\x18\x02 \x01(\tR\bfileName\x12\x10\n" +
+	"\x03seq\x18\x03 \x01(\x04R\x03seq\x12\x12\n" +
+	"\x04size\x18\x04 \x01(\x04R\x04size\x12\x1d\n" +
+	"\n" +
+	"chunk_size\x18\x05 \x01(\rR\tchunkSize\x12\x1a\n" +
+	"\bchecksum\x18\x06 \x01(\tR\bchecksum\x12'\n" +
+	"\x06chunks\x18\a \x03(\v2\x0f.rpcpb.ChunkRefR\x06chunks\x12?\n" +
+	"\n" +
+	"attributes\x18\b \x03(\v2\x1f.rpcpb.Manifest.AttributesEntryR\n" +
+	"attributes\x12\x1d\n" +
+	"\n" +
+	"hash_state\x18\t \x01(\fR\thashState\x12\x1d\n" +
+	"\n" +
+	"expires_at\x18\n" +
+	" \x01(\x03R\texpiresAt\x12(\n" +
+	"\aerasure\x18\v \x01(\v2\x0e.rpcpb.ErasureR\aerasure\x12\x1f\n" +
+	"\varchived_at\x18\f \x01(\x03R\n" +
+	"archivedAt\x1a=\n" +
+	"\x0fAttributesEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\x1c\n" +
+	"\bChunkSet\x12\x10\n" +
+	"\x03ids\x18\x01 \x03(\tR\x03ids\"O\n" +
+	"\x06DirReq\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x12\x1c\n" +
+	"\trecursive\x18\x02 \x01(\bR\trecursive\x12\x13\n" +
+	"\x05op_id\x18\x03 \x01(\x04R\x04opId\"X\n" +
+	"\aDirInfo\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x129\n" +
+	"\n" +
+	"created_at\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\"Z\n" +
+	"\bDirEntry\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x15\n" +
+	"\x06is_dir\x18\x02 \x01(\bR\x05isDir\x12#\n" +
+	"\x04stat\x18\x03 \x01(\v2\x0f.rpcpb.FileStatR\x04stat\"_\n" +
+	"\n" +
+	"DirListing\x12)\n" +
+	"\aentries\x18\x01 \x03(\v2\x0f.rpcpb.DirEntryR\aentries\x12&\n" +
+	"\x0fnext_page_token\x18\x02 \x01(\tR\rnextPageToken\"]\n" +
+	"\bWatchReq\x12\x16\n" +
+	"\x06folder\x18\x01 \x01(\tR\x06folder\x12\x1c\n" +
+	"\trecursive\x18\x02 \x01(\bR\trecursive\x12\x1b\n" +
+	"\tsince_seq\x18\x03 \x01(\x04R\bsinceSeq\"\xe9\x01\n" +
+	"\vChangeEvent\x12\x16\n" +
+	"\x06cursor\x18\x01 \x01(\x04R\x06cursor\x12%\n" +
+	"\x04kind\x18\x02 \x01(\x0e2\x11.rpcpb.ChangeKindR\x04kind\x12\x16\n" +
+	"\x06folder\x18\x03 \x01(\tR\x06folder\x12\x1b\n" +
+	"\tfile_name\x18\x04 \x01(\tR\bfileName\x12\x10\n" +
+	"\x03seq\x18\x05 \x01(\x04R\x03seq\x12\x15\n" +
+	"\x06is_dir\x18\x06 \x01(\bR\x05isDir\x12=\n" +
+	"\fcommitted_at\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\vcommittedAt\"\x9f\x01\n" +
+	"\vSnapshotReq\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x16\n" +
+	"\x06folder\x18\x02 \x01(\tR\x06folder\x12)\n" +
+	"\x05files\x18\x03 \x03(\v2\x13.rpcpb.SnapshotFileR\x05files\x129\n" +
+	"\n" +
+	"created_at\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\"U\n" +
+	"\fSnapshotFile\x12\x16\n" +
+	"\x06folder\x18\x01 \x01(\tR\x06folder\x12\x1b\n" +
+	"\tfile_name\x18\x02 \x01(\tR\bfileName\x12\x10\n" +
+	"\x03seq\x18\x03 \x01(\x04R\x03seq\"\x94\x01\n" +
+	"\fSnapshotInfo\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x16\n" +
+	"\x06folder\x18\x02 \x01(\tR\x06folder\x12\x1d\n" +
+	"\n" +
+	"file_count\x18\x03 \x01(\x04R\tfileCount\x129\n" +
+	"\n" +
+	"created_at\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\"\x12\n" +
+	"\x10ListSnapshotsReq\"A\n" +
+	"\fSnapshotList\x121\n" +
+	"\tsnapshots\x18\x01 \x03(\v2\x13.rpcpb.SnapshotInfoR\tsnapshots\"Y\n" +
+	"\x05Quota\x12\x16\n" +
+	"\x06folder\x18\x01 \x01(\tR\x06folder\x12\x1b\n" +
+	"\tmax_bytes\x18\x02 \x01(\x04R\bmaxBytes\x12\x1b\n" +
+	"\tmax_files\x18\x03 \x01(\x04R\bmaxFiles\"\"\n" +
+	"\bUsageReq\x12\x16\n" +
+	"\x06folder\x18\x01 \x01(\tR\x06folder\"n\n" +
+	"\n" +
+	"QuotaUsage\x12\"\n" +
+	"\x05quota\x18\x01 \x01(\v2\f.rpcpb.QuotaR\x05quota\x12\x1d\n" +
+	"\n" +
+	"used_bytes\x18\x02 \x01(\x04R\tusedBytes\x12\x1d\n" +
+	"\n" +
+	"used_files\x18\x03 \x01(\x04R\tusedFiles\"\x0f\n" +
+	"\rListQuotasReq\"6\n" +
+	"\tQuotaList\x12)\n" +
+	"\x06quotas\x18\x01 \x03(\v2\x11.rpcpb.QuotaUsageR\x06quotas\"q\n" +
+	"\tDeleteReq\x12\x16\n" +
+	"\x06folder\x18\x01 \x01(\tR\x06folder\x12\x1b\n" +
+	"\tfile_name\x18\x02 \x01(\tR\bfileName\x12\x10\n" +
+	"\x03seq\x18\x03 \x01(\x04R\x03seq\x12\x1d\n" +
+	"\n" +
+	"if_expired\x18\x04 \x01(\bR\tifExpired\"D\n" +
+	"\tFolderTTL\x12\x16\n" +
+	"\x06folder\x18\x01 \x01(\tR\x06folder\x12\x1f\n" +
+	"\vttl_seconds\x18\x02 \x01(\x03R\n" +
+	"ttlSeconds*(\n" +
+	"\tSortOrder\x12\f\n" +
+	"\bNAME_ASC\x10\x00\x12\r\n" +
+	"\tNAME_DESC\x10\x01*0\n" +
+	"\n" +
+	"ChangeKind\x12\n" +
+	"\n" +
+	"\x06CREATE\x10\x00\x12\n" +
+	"\n" +
+	"\x06UPDATE\x10\x01\x12\n" +
+	"\n" +
+	"\x06DELETE\x10\x022\xec\b\n" +
 	"\x04Node\x127\n" +
 	"\vStreamWrite\x12\x15.rpcpb.StreamWriteReq\x1a\x0f.rpcpb.WriteAck(\x01\x126\n" +
 	"\n" +
-	"StreamRead\x12\x14.rpcpb.StreamReadReq\x1a\x10.rpcpb.ReadChunk0\x01\x12;\n" +
+	"StreamRead\x12\x14.rpcpb.StreamReadReq\x1a\x10.rpcpb.ReadChunk0\x01\x12,\n" +
+	"\x06Append\x12\x10.rpcpb.AppendReq\x1a\x10.rpcpb.AppendAck\x126\n" +
+	"\n" +
+	"BatchWrite\x12\x15.rpcpb.StreamWriteReq\x1a\x0f.rpcpb.BatchAck(\x01\x12;\n" +
 	"\fQueryVersion\x12\x13.rpcpb.VersionQuery\x1a\x16.rpcpb.VersionResponse\x120\n" +
-	"\tListFiles\x12\x12.rpcpb.FolderQuery\x1a\x0f.rpcpb.FileListB\tZ\a.;rpcpbb\x06proto3"
+	"\tListFiles\x12\x12.rpcpb.FolderQuery\x1a\x0f.rpcpb.FileList\x12'\n" +
+	"\x04Stat\x12\x0e.rpcpb.StatReq\x1a\x0f.rpcpb.FileStat\x12&\n" +
+	"\x05Mkdir\x12\r.rpcpb.DirReq\x1a\x0e.rpcpb.DirInfo\x12&\n" +
+	"\x05Rmdir\x12\r.rpcpb.DirReq\x1a\x0e.rpcpb.DirInfo\x120\n" +
+	"\aReadDir\x12\x12.rpcpb.FolderQuery\x1a\x11.rpcpb.DirListing\x12.\n" +
+	"\x05Watch\x12\x0f.rpcpb.WatchReq\x1a\x12.rpcpb.ChangeEvent0\x01\x129\n" +
+	"\x0eCreateSnapshot\x12\x12.rpcpb.SnapshotReq\x1a\x13.rpcpb.SnapshotInfo\x129\n" +
+	"\x0eDeleteSnapshot\x12\x12.rpcpb.SnapshotReq\x1a\x13.rpcpb.SnapshotInfo\x12=\n" +
+	"\rListSnapshots\x12\x17.rpcpb.ListSnapshotsReq\x1a\x13.rpcpb.SnapshotList\x12+\n" +
+	"\bSetQuota\x12\f.rpcpb.Quota\x1a\x11.rpcpb.QuotaUsage\x12.\n" +
+	"\bGetUsage\x12\x0f.rpcpb.UsageReq\x1a\x11.rpcpb.QuotaUsage\x124\n" +
+	"\n" +
+	"ListQuotas\x12\x14.rpcpb.ListQuotasReq\x1a\x10.rpcpb.QuotaList\x12+\n" +
+	"\x06Delete\x12\x10.rpcpb.DeleteReq\x1a\x0f.rpcpb.WriteAck\x122\n" +
+	"\fSetFolderTTL\x12\x10.rpcpb.FolderTTL\x1a\x10.rpcpb.FolderTTL\x121\n" +
+	"\rMissingChunks\x12\x0f.rpcpb.ChunkSet\x1a\x0f.rpcpb.ChunkSet\x124\n" +
+	"\vGetManifest\x12\x14.rpcpb.StreamReadReq\x1a\x0f.rpcpb.Manifest\x121\n" +
+	"\n" +
+	"FetchChunk\x12\x0f.rpcpb.ChunkRef\x1a\x10.rpcpb.ReadChunk0\x01B\tZ\a.;rpcpbb\x06proto3"
 
 var (
 	file_node_proto_rawDescOnce sync.Once
@@ -519,31 +2684,123 @@ func file_node_proto_rawDescGZIP() []byte {
 	return file_node_proto_rawDescData
 }
 
-var file_node_proto_msgTypes = make([]protoimpl.MessageInfo, 8)
+var file_node_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
+var file_node_proto_msgTypes = make([]protoimpl.MessageInfo, 38)
 var file_node_proto_goTypes = []any{
-	(*StreamWriteReq)(nil),  // 0: rpcpb.StreamWriteReq
-	(*WriteAck)(nil),        // 1: rpcpb.WriteAck
-	(*StreamReadReq)(nil),   // 2: rpcpb.StreamReadReq
-	(*ReadChunk)(nil),       // 3: rpcpb.ReadChunk
-	(*VersionQuery)(nil),    // 4: rpcpb.VersionQuery
-	(*VersionResponse)(nil), // 5: rpcpb.VersionResponse
-	(*FolderQuery)(nil),     // 6: rpcpb.FolderQuery
-	(*FileList)(nil),        // 7: rpcpb.FileList
+	(SortOrder)(0),                // 0: rpcpb.SortOrder
+	(ChangeKind)(0),               // 1: rpcpb.ChangeKind
+	(*StreamWriteReq)(nil),        // 2: rpcpb.StreamWriteReq
+	(*WriteAck)(nil),              // 3: rpcpb.WriteAck
+	(*AppendReq)(nil),             // 4: rpcpb.AppendReq
+	(*AppendAck)(nil),             // 5: rpcpb.AppendAck
+	(*BatchAck)(nil),              // 6: rpcpb.BatchAck
+	(*StreamReadReq)(nil),         // 7: rpcpb.StreamReadReq
+	(*ReadChunk)(nil),             // 8: rpcpb.ReadChunk
+	(*VersionQuery)(nil),          // 9: rpcpb.VersionQuery
+	(*VersionResponse)(nil),       // 10: rpcpb.VersionResponse
+	(*FolderQuery)(nil),           // 11: rpcpb.FolderQuery
+	(*FileList)(nil),              // 12: rpcpb.FileList
+	(*StatReq)(nil),               // 13: rpcpb.StatReq
+	(*FileStat)(nil),              // 14: rpcpb.FileStat
+	(*ChunkRef)(nil),              // 15: rpcpb.ChunkRef
+	(*Erasure)(nil),               // 16: rpcpb.Erasure
+	(*Manifest)(nil),              // 17: rpcpb.Manifest
+	(*ChunkSet)(nil),              // 18: rpcpb.ChunkSet
+	(*DirReq)(nil),                // 19: rpcpb.DirReq
+	(*DirInfo)(nil),               // 20: rpcpb.DirInfo
+	(*DirEntry)(nil),              // 21: rpcpb.DirEntry
+	(*DirListing)(nil),            // 22: rpcpb.DirListing
+	(*WatchReq)(nil),              // 23: rpcpb.WatchReq
+	(*ChangeEvent)(nil),           // 24: rpcpb.ChangeEvent
+	(*SnapshotReq)(nil),           // 25: rpcpb.SnapshotReq
+	(*SnapshotFile)(nil),          // 26: rpcpb.SnapshotFile
+	(*SnapshotInfo)(nil),          // 27: rpcpb.SnapshotInfo
+	(*ListSnapshotsReq)(nil),      // 28: rpcpb.ListSnapshotsReq
+	(*SnapshotList)(nil),          // 29: rpcpb.SnapshotList
+	(*Quota)(nil),                 // 30: rpcpb.Quota
+	(*UsageReq)(nil),              // 31: rpcpb.UsageReq
+	(*QuotaUsage)(nil),            // 32: rpcpb.QuotaUsage
+	(*ListQuotasReq)(nil),         // 33: rpcpb.ListQuotasReq
+	(*QuotaList)(nil),             // 34: rpcpb.QuotaList
+	(*DeleteReq)(nil),             // 35: rpcpb.DeleteReq
+	(*FolderTTL)(nil),             // 36: rpcpb.FolderTTL
+	nil,                           // 37: rpcpb.StreamWriteReq.AttributesEntry
+	nil,                           // 38: rpcpb.FileStat.AttributesEntry
+	nil,                           // 39: rpcpb.Manifest.AttributesEntry
+	(*timestamppb.Timestamp)(nil), // 40: google.protobuf.Timestamp
 }
 var file_node_proto_depIdxs = []int32{
-	0, // 0: rpcpb.Node.StreamWrite:input_type -> rpcpb.StreamWriteReq
-	2, // 1: rpcpb.Node.StreamRead:input_type -> rpcpb.StreamReadReq
-	4, // 2: rpcpb.Node.QueryVersion:input_type -> rpcpb.VersionQuery
-	6, // 3: rpcpb.Node.ListFiles:input_type -> rpcpb.FolderQuery
-	1, // 4: rpcpb.Node.StreamWrite:output_type -> rpcpb.WriteAck
-	3, // 5: rpcpb.Node.StreamRead:output_type -> rpcpb.ReadChunk
-	5, // 6: rpcpb.Node.QueryVersion:output_type -> rpcpb.VersionResponse
-	7, // 7: rpcpb.Node.ListFiles:output_type -> rpcpb.FileList
-	4, // [4:8] is the sub-list for method output_type
-	0, // [0:4] is the sub-list for method input_type
-	0, // [0:0] is the sub-list for extension type_name
-	0, // [0:0] is the sub-list for extension extendee
-	0, // [0:0] is the sub-list for field type_name
+	17, // 0: rpcpb.StreamWriteReq.manifest:type_name -> rpcpb.Manifest
+	37, // 1: rpcpb.StreamWriteReq.attributes:type_name -> rpcpb.StreamWriteReq.AttributesEntry
+	3,  // 2: rpcpb.BatchAck.acks:type_name -> rpcpb.WriteAck
+	0,  // 3: rpcpb.FolderQuery.order:type_name -> rpcpb.SortOrder
+	14, // 4: rpcpb.FileList.entries:type_name -> rpcpb.FileStat
+	40, // 5: rpcpb.FileStat.created_at:type_name -> google.protobuf.Timestamp
+	40, // 6: rpcpb.FileStat.modified_at:type_name -> google.protobuf.Timestamp
+	38, // 7: rpcpb.FileStat.attributes:type_name -> rpcpb.FileStat.AttributesEntry
+	15, // 8: rpcpb.Manifest.chunks:type_name -> rpcpb.ChunkRef
+	39, // 9: rpcpb.Manifest.attributes:type_name -> rpcpb.Manifest.AttributesEntry
+	16, // 10: rpcpb.Manifest.erasure:type_name -> rpcpb.Erasure
+	40, // 11: rpcpb.DirInfo.created_at:type_name -> google.protobuf.Timestamp
+	14, // 12: rpcpb.DirEntry.stat:type_name -> rpcpb.FileStat
+	21, // 13: rpcpb.DirListing.entries:type_name -> rpcpb.DirEntry
+	1,  // 14: rpcpb.ChangeEvent.kind:type_name -> rpcpb.ChangeKind
+	40, // 15: rpcpb.ChangeEvent.committed_at:type_name -> google.protobuf.Timestamp
+	26, // 16: rpcpb.SnapshotReq.files:type_name -> rpcpb.SnapshotFile
+	40, // 17: rpcpb.SnapshotReq.created_at:type_name -> google.protobuf.Timestamp
+	40, // 18: rpcpb.SnapshotInfo.created_at:type_name -> google.protobuf.Timestamp
+	27, // 19: rpcpb.SnapshotList.snapshots:type_name -> rpcpb.SnapshotInfo
+	30, // 20: rpcpb.QuotaUsage.quota:type_name -> rpcpb.Quota
+	32, // 21: rpcpb.QuotaList.quotas:type_name -> rpcpb.QuotaUsage
+	2,  // 22: rpcpb.Node.StreamWrite:input_type -> rpcpb.StreamWriteReq
+	7,  // 23: rpcpb.Node.StreamRead:input_type -> rpcpb.StreamReadReq
+	4,  // 24: rpcpb.Node.Append:input_type -> rpcpb.AppendReq
+	2,  // 25: rpcpb.Node.BatchWrite:input_type -> rpcpb.StreamWriteReq
+	9,  // 26: rpcpb.Node.QueryVersion:input_type -> rpcpb.VersionQuery
+	11, // 27: rpcpb.Node.ListFiles:input_type -> rpcpb.FolderQuery
+	13, // 28: rpcpb.Node.Stat:input_type -> rpcpb.StatReq
+	19, // 29: rpcpb.Node.Mkdir:input_type -> rpcpb.DirReq
+	19, // 30: rpcpb.Node.Rmdir:input_type -> rpcpb.DirReq
+	11, // 31: rpcpb.Node.ReadDir:input_type -> rpcpb.FolderQuery
+	23, // 32: rpcpb.Node.Watch:input_type -> rpcpb.WatchReq
+	25, // 33: rpcpb.Node.CreateSnapshot:input_type -> rpcpb.SnapshotReq
+	25, // 34: rpcpb.Node.DeleteSnapshot:input_type -> rpcpb.SnapshotReq
+	28, // 35: rpcpb.Node.ListSnapshots:input_type -> rpcpb.ListSnapshotsReq
+	30, // 36: rpcpb.Node.SetQuota:input_type -> rpcpb.Quota
+	31, // 37: rpcpb.Node.GetUsage:input_type -> rpcpb.UsageReq
+	33, // 38: rpcpb.Node.ListQuotas:input_type -> rpcpb.ListQuotasReq
+	35, // 39: rpcpb.Node.Delete:input_type -> rpcpb.DeleteReq
+	36, // 40: rpcpb.Node.SetFolderTTL:input_type -> rpcpb.FolderTTL
+	18, // 41: rpcpb.Node.MissingChunks:input_type -> rpcpb.ChunkSet
+	7,  // 42: rpcpb.Node.GetManifest:input_type -> rpcpb.StreamReadReq
+	15, // 43: rpcpb.Node.FetchChunk:input_type -> rpcpb.ChunkRef
+	3,  // 44: rpcpb.Node.StreamWrite:output_type -> rpcpb.WriteAck
+	8,  // 45: rpcpb.Node.StreamRead:output_type -> rpcpb.ReadChunk
+	5,  // 46: rpcpb.Node.Append:output_type -> rpcpb.AppendAck
+	6,  // 47: rpcpb.Node.BatchWrite:output_type -> rpcpb.BatchAck
+	10, // 48: rpcpb.Node.QueryVersion:output_type -> rpcpb.VersionResponse
+	12, // 49: rpcpb.Node.ListFiles:output_type -> rpcpb.FileList
+	14, // 50: rpcpb.Node.Stat:output_type -> rpcpb.FileStat
+	20, // 51: rpcpb.Node.Mkdir:output_type -> rpcpb.DirInfo
+	20, // 52: rpcpb.Node.Rmdir:output_type -> rpcpb.DirInfo
+	22, // 53: rpcpb.Node.ReadDir:output_type -> rpcpb.DirListing
+	24, // 54: rpcpb.Node.Watch:output_type -> rpcpb.ChangeEvent
+	27, // 55: rpcpb.Node.CreateSnapshot:output_type -> rpcpb.SnapshotInfo
+	27, // 56: rpcpb.Node.DeleteSnapshot:output_type -> rpcpb.SnapshotInfo
+	29, // 57: rpcpb.Node.ListSnapshots:output_type -> rpcpb.SnapshotList
+	32, // 58: rpcpb.Node.SetQuota:output_type -> rpcpb.QuotaUsage
+	32, // 59: rpcpb.Node.GetUsage:output_type -> rpcpb.QuotaUsage
+	34, // 60: rpcpb.Node.ListQuotas:output_type -> rpcpb.QuotaList
+	3,  // 61: rpcpb.Node.Delete:output_type -> rpcpb.WriteAck
+	36, // 62: rpcpb.Node.SetFolderTTL:output_type -> rpcpb.FolderTTL
+	18, // 63: rpcpb.Node.MissingChunks:output_type -> rpcpb.ChunkSet
+	17, // 64: rpcpb.Node.GetManifest:output_type -> rpcpb.Manifest
+	8,  // 65: rpcpb.Node.FetchChunk:output_type -> rpcpb.ReadChunk
+	44, // [44:66] is the sub-list for method output_type
+	22, // [22:44] is the sub-list for method input_type
+	22, // [22:22] is the sub-list for extension type_name
+	22, // [22:22] is the sub-list for extension extendee
+	0,  // [0:22] is the sub-list for field type_name
 }
 
 func init() { file_node_proto_init() }
@@ -556,13 +2813,14 @@ func file_node_proto_init() {
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_node_proto_rawDesc), len(file_node_proto_rawDesc)),
-			NumEnums:      0,
-			NumMessages:   8,
+			NumEnums:      2,
+			NumMessages:   38,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
 		GoTypes:           file_node_proto_goTypes,
 		DependencyIndexes: file_node_proto_depIdxs,
+		EnumInfos:         file_node_proto_enumTypes,
 		MessageInfos:      file_node_proto_msgTypes,
 	}.Build()
 	File_node_proto = out.File
@@ -19,10 +19,28 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	Node_StreamWrite_FullMethodName  = "/rpcpb.Node/StreamWrite"
-	Node_StreamRead_FullMethodName   = "/rpcpb.Node/StreamRead"
-	Node_QueryVersion_FullMethodName = "/rpcpb.Node/QueryVersion"
-	Node_ListFiles_FullMethodName    = "/rpcpb.Node/ListFiles"
+	Node_StreamWrite_FullMethodName    = "/rpcpb.Node/StreamWrite"
+	Node_StreamRead_FullMethodName     = "/rpcpb.Node/StreamRead"
+	Node_Append_FullMethodName         = "/rpcpb.Node/Append"
+	Node_BatchWrite_FullMethodName     = "/rpcpb.Node/BatchWrite"
+	Node_QueryVersion_FullMethodName   = "/rpcpb.Node/QueryVersion"
+	Node_ListFiles_FullMethodName      = "/rpcpb.Node/ListFiles"
+	Node_Stat_FullMethodName           = "/rpcpb.Node/Stat"
+	Node_Mkdir_FullMethodName          = "/rpcpb.Node/Mkdir"
+	Node_Rmdir_FullMethodName          = "/rpcpb.Node/Rmdir"
+	Node_ReadDir_FullMethodName        = "/rpcpb.Node/ReadDir"
+	Node_Watch_FullMethodName          = "/rpcpb.Node/Watch"
+	Node_CreateSnapshot_FullMethodName = "/rpcpb.Node/CreateSnapshot"
+	Node_DeleteSnapshot_FullMethodName = "/rpcpb.Node/DeleteSnapshot"
+	Node_ListSnapshots_FullMethodName  = "/rpcpb.Node/ListSnapshots"
+	Node_SetQuota_FullMethodName       = "/rpcpb.Node/SetQuota"
+	Node_GetUsage_FullMethodName       = "/rpcpb.Node/GetUsage"
+	Node_ListQuotas_FullMethodName     = "/rpcpb.Node/ListQuotas"
+	Node_Delete_FullMethodName         = "/rpcpb.Node/Delete"
+	Node_SetFolderTTL_FullMethodName   = "/rpcpb.Node/SetFolderTTL"
+	Node_MissingChunks_FullMethodName  = "/rpcpb.Node/MissingChunks"
+	Node_GetManifest_FullMethodName    = "/rpcpb.Node/GetManifest"
+	Node_FetchChunk_FullMethodName     = "/rpcpb.Node/FetchChunk"
 )
 
 // NodeClient is the client API for Node service.
@@ -34,9 +52,45 @@ type NodeClient interface {
 	// stream
 	StreamWrite(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[StreamWriteReq, WriteAck], error)
 	StreamRead(ctx context.Context, in *StreamReadReq, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ReadChunk], error)
+	// Append data to a file as a new version. The head orders appends per
+	// key; replicas receive only the appended bytes.
+	Append(ctx context.Context, in *AppendReq, opts ...grpc.CallOption) (*AppendAck, error)
+	// Several files committed as a unit. A message naming a different file
+	// than the previous one (or, between nodes, carrying a manifest) starts
+	// the next file. Readers see all of the files or none of them.
+	BatchWrite(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[StreamWriteReq, BatchAck], error)
 	QueryVersion(ctx context.Context, in *VersionQuery, opts ...grpc.CallOption) (*VersionResponse, error)
 	// List all files in a folder
 	ListFiles(ctx context.Context, in *FolderQuery, opts ...grpc.CallOption) (*FileList, error)
+	// Metadata of the latest version of a file, without its content
+	Stat(ctx context.Context, in *StatReq, opts ...grpc.CallOption) (*FileStat, error)
+	// Directories. Mkdir and Rmdir go to the head and are replicated down
+	// the chain like writes.
+	Mkdir(ctx context.Context, in *DirReq, opts ...grpc.CallOption) (*DirInfo, error)
+	Rmdir(ctx context.Context, in *DirReq, opts ...grpc.CallOption) (*DirInfo, error)
+	ReadDir(ctx context.Context, in *FolderQuery, opts ...grpc.CallOption) (*DirListing, error)
+	// Stream create/update/delete events as versions commit under a folder.
+	Watch(ctx context.Context, in *WatchReq, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ChangeEvent], error)
+	// Point-in-time snapshots of a folder tree: the committed seq of every
+	// file under the folder, readable by name until deleted.
+	CreateSnapshot(ctx context.Context, in *SnapshotReq, opts ...grpc.CallOption) (*SnapshotInfo, error)
+	DeleteSnapshot(ctx context.Context, in *SnapshotReq, opts ...grpc.CallOption) (*SnapshotInfo, error)
+	ListSnapshots(ctx context.Context, in *ListSnapshotsReq, opts ...grpc.CallOption) (*SnapshotList, error)
+	// Admin: per-folder quotas on bytes and file count, enforced by the head.
+	// Limits of 0 mean unlimited; setting both to 0 removes the quota.
+	SetQuota(ctx context.Context, in *Quota, opts ...grpc.CallOption) (*QuotaUsage, error)
+	GetUsage(ctx context.Context, in *UsageReq, opts ...grpc.CallOption) (*QuotaUsage, error)
+	ListQuotas(ctx context.Context, in *ListQuotasReq, opts ...grpc.CallOption) (*QuotaList, error)
+	// Tombstone a file. Replicated down the chain like a write; the head
+	// issues it for expired files.
+	Delete(ctx context.Context, in *DeleteReq, opts ...grpc.CallOption) (*WriteAck, error)
+	// Admin: default TTL for files written under a folder tree.
+	SetFolderTTL(ctx context.Context, in *FolderTTL, opts ...grpc.CallOption) (*FolderTTL, error)
+	// Content chunks: replication only ships chunks the successor lacks,
+	// and readers can fetch the chunks of a manifest in parallel.
+	MissingChunks(ctx context.Context, in *ChunkSet, opts ...grpc.CallOption) (*ChunkSet, error)
+	GetManifest(ctx context.Context, in *StreamReadReq, opts ...grpc.CallOption) (*Manifest, error)
+	FetchChunk(ctx context.Context, in *ChunkRef, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ReadChunk], error)
 }
 
 type nodeClient struct {
@@ -79,6 +133,29 @@ func (c *nodeClient) StreamRead(ctx context.Context, in *StreamReadReq, opts ...
 // This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
 type Node_StreamReadClient = grpc.ServerStreamingClient[ReadChunk]
 
+func (c *nodeClient) Append(ctx context.Context, in *AppendReq, opts ...grpc.CallOption) (*AppendAck, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AppendAck)
+	err := c.cc.Invoke(ctx, Node_Append_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *nodeClient) BatchWrite(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[StreamWriteReq, BatchAck], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Node_ServiceDesc.Streams[2], Node_BatchWrite_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[StreamWriteReq, BatchAck]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Node_BatchWriteClient = grpc.ClientStreamingClient[StreamWriteReq, BatchAck]
+
 func (c *nodeClient) QueryVersion(ctx context.Context, in *VersionQuery, opts ...grpc.CallOption) (*VersionResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(VersionResponse)
@@ -99,6 +176,184 @@ func (c *nodeClient) ListFiles(ctx context.Context, in *FolderQuery, opts ...grp
 	return out, nil
 }
 
+func (c *nodeClient) Stat(ctx context.Context, in *StatReq, opts ...grpc.CallOption) (*FileStat, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(FileStat)
+	err := c.cc.Invoke(ctx, Node_Stat_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *nodeClient) Mkdir(ctx context.Context, in *DirReq, opts ...grpc.CallOption) (*DirInfo, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DirInfo)
+	err := c.cc.Invoke(ctx, Node_Mkdir_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *nodeClient) Rmdir(ctx context.Context, in *DirReq, opts ...grpc.CallOption) (*DirInfo, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DirInfo)
+	err := c.cc.Invoke(ctx, Node_Rmdir_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *nodeClient) ReadDir(ctx context.Context, in *FolderQuery, opts ...grpc.CallOption) (*DirListing, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DirListing)
+	err := c.cc.Invoke(ctx, Node_ReadDir_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *nodeClient) Watch(ctx context.Context, in *WatchReq, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ChangeEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Node_ServiceDesc.Streams[3], Node_Watch_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchReq, ChangeEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Node_WatchClient = grpc.ServerStreamingClient[ChangeEvent]
+
+func (c *nodeClient) CreateSnapshot(ctx context.Context, in *SnapshotReq, opts ...grpc.CallOption) (*SnapshotInfo, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SnapshotInfo)
+	err := c.cc.Invoke(ctx, Node_CreateSnapshot_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *nodeClient) DeleteSnapshot(ctx context.Context, in *SnapshotReq, opts ...grpc.CallOption) (*SnapshotInfo, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SnapshotInfo)
+	err := c.cc.Invoke(ctx, Node_DeleteSnapshot_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *nodeClient) ListSnapshots(ctx context.Context, in *ListSnapshotsReq, opts ...grpc.CallOption) (*SnapshotList, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SnapshotList)
+	err := c.cc.Invoke(ctx, Node_ListSnapshots_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *nodeClient) SetQuota(ctx context.Context, in *Quota, opts ...grpc.CallOption) (*QuotaUsage, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(QuotaUsage)
+	err := c.cc.Invoke(ctx, Node_SetQuota_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *nodeClient) GetUsage(ctx context.Context, in *UsageReq, opts ...grpc.CallOption) (*QuotaUsage, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(QuotaUsage)
+	err := c.cc.Invoke(ctx, Node_GetUsage_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *nodeClient) ListQuotas(ctx context.Context, in *ListQuotasReq, opts ...grpc.CallOption) (*QuotaList, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(QuotaList)
+	err := c.cc.Invoke(ctx, Node_ListQuotas_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *nodeClient) Delete(ctx context.Context, in *DeleteReq, opts ...grpc.CallOption) (*WriteAck, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(WriteAck)
+	err := c.cc.Invoke(ctx, Node_Delete_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *nodeClient) SetFolderTTL(ctx context.Context, in *FolderTTL, opts ...grpc.CallOption) (*FolderTTL, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(FolderTTL)
+	err := c.cc.Invoke(ctx, Node_SetFolderTTL_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *nodeClient) MissingChunks(ctx context.Context, in *ChunkSet, opts ...grpc.CallOption) (*ChunkSet, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ChunkSet)
+	err := c.cc.Invoke(ctx, Node_MissingChunks_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *nodeClient) GetManifest(ctx context.Context, in *StreamReadReq, opts ...grpc.CallOption) (*Manifest, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Manifest)
+	err := c.cc.Invoke(ctx, Node_GetManifest_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *nodeClient) FetchChunk(ctx context.Context, in *ChunkRef, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ReadChunk], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Node_ServiceDesc.Streams[4], Node_FetchChunk_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ChunkRef, ReadChunk]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Node_FetchChunkClient = grpc.ServerStreamingClient[ReadChunk]
+
 // NodeServer is the server API for Node service.
 // All implementations must embed UnimplementedNodeServer
 // for forward compatibility.
@@ -108,9 +363,45 @@ type NodeServer interface {
 	// stream
 	StreamWrite(grpc.ClientStreamingServer[StreamWriteReq, WriteAck]) error
 	StreamRead(*StreamReadReq, grpc.ServerStreamingServer[ReadChunk]) error
+	// Append data to a file as a new version. The head orders appends per
+	// key; replicas receive only the appended bytes.
+	Append(context.Context, *AppendReq) (*AppendAck, error)
+	// Several files committed as a unit. A message naming a different file
+	// than the previous one (or, between nodes, carrying a manifest) starts
+	// the next file. Readers see all of the files or none of them.
+	BatchWrite(grpc.ClientStreamingServer[StreamWriteReq, BatchAck]) error
 	QueryVersion(context.Context, *VersionQuery) (*VersionResponse, error)
 	// List all files in a folder
 	ListFiles(context.Context, *FolderQuery) (*FileList, error)
+	// Metadata of the latest version of a file, without its content
+	Stat(context.Context, *StatReq) (*FileStat, error)
+	// Directories. Mkdir and Rmdir go to the head and are replicated down
+	// the chain like writes.
+	Mkdir(context.Context, *DirReq) (*DirInfo, error)
+	Rmdir(context.Context, *DirReq) (*DirInfo, error)
+	ReadDir(context.Context, *FolderQuery) (*DirListing, error)
+	// Stream create/update/delete events as versions commit under a folder.
+	Watch(*WatchReq, grpc.ServerStreamingServer[ChangeEvent]) error
+	// Point-in-time snapshots of a folder tree: the committed seq of every
+	// file under the folder, readable by name until deleted.
+	CreateSnapshot(context.Context, *SnapshotReq) (*SnapshotInfo, error)
+	DeleteSnapshot(context.Context, *SnapshotReq) (*SnapshotInfo, error)
+	ListSnapshots(context.Context, *ListSnapshotsReq) (*SnapshotList, error)
+	// Admin: per-folder quotas on bytes and file count, enforced by the head.
+	// Limits of 0 mean unlimited; setting both to 0 removes the quota.
+	SetQuota(context.Context, *Quota) (*QuotaUsage, error)
+	GetUsage(context.Context, *UsageReq) (*QuotaUsage, error)
+	ListQuotas(context.Context, *ListQuotasReq) (*QuotaList, error)
+	// Tombstone a file. Replicated down the chain like a write; the head
+	// issues it for expired files.
+	Delete(context.Context, *DeleteReq) (*WriteAck, error)
+	// Admin: default TTL for files written under a folder tree.
+	SetFolderTTL(context.Context, *FolderTTL) (*FolderTTL, error)
+	// Content chunks: replication only ships chunks the successor lacks,
+	// and readers can fetch the chunks of a manifest in parallel.
+	MissingChunks(context.Context, *ChunkSet) (*ChunkSet, error)
+	GetManifest(context.Context, *StreamReadReq) (*Manifest, error)
+	FetchChunk(*ChunkRef, grpc.ServerStreamingServer[ReadChunk]) error
 	mustEmbedUnimplementedNodeServer()
 }
 
@@ -127,12 +418,66 @@ func (UnimplementedNodeServer) StreamWrite(grpc.ClientStreamingServer[StreamWrit
 func (UnimplementedNodeServer) StreamRead(*StreamReadReq, grpc.ServerStreamingServer[ReadChunk]) error {
 	return status.Errorf(codes.Unimplemented, "method StreamRead not implemented")
 }
+func (UnimplementedNodeServer) Append(context.Context, *AppendReq) (*AppendAck, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Append not implemented")
+}
+func (UnimplementedNodeServer) BatchWrite(grpc.ClientStreamingServer[StreamWriteReq, BatchAck]) error {
+	return status.Errorf(codes.Unimplemented, "method BatchWrite not implemented")
+}
 func (UnimplementedNodeServer) QueryVersion(context.Context, *VersionQuery) (*VersionResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method QueryVersion not implemented")
 }
 func (UnimplementedNodeServer) ListFiles(context.Context, *FolderQuery) (*FileList, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ListFiles not implemented")
 }
+func (UnimplementedNodeServer) Stat(context.Context, *StatReq) (*FileStat, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Stat not implemented")
+}
+func (UnimplementedNodeServer) Mkdir(context.Context, *DirReq) (*DirInfo, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Mkdir not implemented")
+}
+func (UnimplementedNodeServer) Rmdir(context.Context, *DirReq) (*DirInfo, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Rmdir not implemented")
+}
+func (UnimplementedNodeServer) ReadDir(context.Context, *FolderQuery) (*DirListing, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReadDir not implemented")
+}
+func (UnimplementedNodeServer) Watch(*WatchReq, grpc.ServerStreamingServer[ChangeEvent]) error {
+	return status.Errorf(codes.Unimplemented, "method Watch not implemented")
+}
+func (UnimplementedNodeServer) CreateSnapshot(context.Context, *SnapshotReq) (*SnapshotInfo, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateSnapshot not implemented")
+}
+func (UnimplementedNodeServer) DeleteSnapshot(context.Context, *SnapshotReq) (*SnapshotInfo, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteSnapshot not implemented")
+}
+func (UnimplementedNodeServer) ListSnapshots(context.Context, *ListSnapshotsReq) (*SnapshotList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListSnapshots not implemented")
+}
+func (UnimplementedNodeServer) SetQuota(context.Context, *Quota) (*QuotaUsage, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetQuota not implemented")
+}
+func (UnimplementedNodeServer) GetUsage(context.Context, *UsageReq) (*QuotaUsage, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetUsage not implemented")
+}
+func (UnimplementedNodeServer) ListQuotas(context.Context, *ListQuotasReq) (*QuotaList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListQuotas not implemented")
+}
+func (UnimplementedNodeServer) Delete(context.Context, *DeleteReq) (*WriteAck, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Delete not implemented")
+}
+func (UnimplementedNodeServer) SetFolderTTL(context.Context, *FolderTTL) (*FolderTTL, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetFolderTTL not implemented")
+}
+func (UnimplementedNodeServer) MissingChunks(context.Context, *ChunkSet) (*ChunkSet, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method MissingChunks not implemented")
+}
+func (UnimplementedNodeServer) GetManifest(context.Context, *StreamReadReq) (*Manifest, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetManifest not implemented")
+}
+func (UnimplementedNodeServer) FetchChunk(*ChunkRef, grpc.ServerStreamingServer[ReadChunk]) error {
+	return status.Errorf(codes.Unimplemented, "method FetchChunk not implemented")
+}
 func (UnimplementedNodeServer) mustEmbedUnimplementedNodeServer() {}
 func (UnimplementedNodeServer) testEmbeddedByValue()              {}
 
@@ -172,6 +517,31 @@ func _Node_StreamRead_Handler(srv interface{}, stream grpc.ServerStream) error {
 // This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
 type Node_StreamReadServer = grpc.ServerStreamingServer[ReadChunk]
 
+func _Node_Append_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AppendReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NodeServer).Append(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Node_Append_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NodeServer).Append(ctx, req.(*AppendReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Node_BatchWrite_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(NodeServer).BatchWrite(&grpc.GenericServerStream[StreamWriteReq, BatchAck]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Node_BatchWriteServer = grpc.ClientStreamingServer[StreamWriteReq, BatchAck]
+
 func _Node_QueryVersion_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(VersionQuery)
 	if err := dec(in); err != nil {
@@ -208,6 +578,280 @@ func _Node_ListFiles_Handler(srv interface{}, ctx context.Context, dec func(inte
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Node_Stat_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StatReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NodeServer).Stat(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Node_Stat_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NodeServer).Stat(ctx, req.(*StatReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Node_Mkdir_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DirReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NodeServer).Mkdir(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Node_Mkdir_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NodeServer).Mkdir(ctx, req.(*DirReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Node_Rmdir_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DirReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NodeServer).Rmdir(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Node_Rmdir_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NodeServer).Rmdir(ctx, req.(*DirReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Node_ReadDir_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FolderQuery)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NodeServer).ReadDir(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Node_ReadDir_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NodeServer).ReadDir(ctx, req.(*FolderQuery))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Node_Watch_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchReq)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(NodeServer).Watch(m, &grpc.GenericServerStream[WatchReq, ChangeEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Node_WatchServer = grpc.ServerStreamingServer[ChangeEvent]
+
+func _Node_CreateSnapshot_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SnapshotReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NodeServer).CreateSnapshot(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Node_CreateSnapshot_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NodeServer).CreateSnapshot(ctx, req.(*SnapshotReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Node_DeleteSnapshot_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SnapshotReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NodeServer).DeleteSnapshot(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Node_DeleteSnapshot_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NodeServer).DeleteSnapshot(ctx, req.(*SnapshotReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Node_ListSnapshots_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListSnapshotsReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NodeServer).ListSnapshots(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Node_ListSnapshots_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NodeServer).ListSnapshots(ctx, req.(*ListSnapshotsReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Node_SetQuota_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Quota)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NodeServer).SetQuota(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Node_SetQuota_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NodeServer).SetQuota(ctx, req.(*Quota))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Node_GetUsage_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UsageReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NodeServer).GetUsage(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Node_GetUsage_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NodeServer).GetUsage(ctx, req.(*UsageReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Node_ListQuotas_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListQuotasReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NodeServer).ListQuotas(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Node_ListQuotas_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NodeServer).ListQuotas(ctx, req.(*ListQuotasReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Node_Delete_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NodeServer).Delete(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Node_Delete_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NodeServer).Delete(ctx, req.(*DeleteReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Node_SetFolderTTL_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FolderTTL)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NodeServer).SetFolderTTL(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Node_SetFolderTTL_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NodeServer).SetFolderTTL(ctx, req.(*FolderTTL))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Node_MissingChunks_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ChunkSet)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NodeServer).MissingChunks(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Node_MissingChunks_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NodeServer).MissingChunks(ctx, req.(*ChunkSet))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Node_GetManifest_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StreamReadReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NodeServer).GetManifest(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Node_GetManifest_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NodeServer).GetManifest(ctx, req.(*StreamReadReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Node_FetchChunk_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ChunkRef)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(NodeServer).FetchChunk(m, &grpc.GenericServerStream[ChunkRef, ReadChunk]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Node_FetchChunkServer = grpc.ServerStreamingServer[ReadChunk]
+
 // Node_ServiceDesc is the grpc.ServiceDesc for Node service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -215,6 +859,10 @@ var Node_ServiceDesc = grpc.ServiceDesc{
 	ServiceName: "rpcpb.Node",
 	HandlerType: (*NodeServer)(nil),
 	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Append",
+			Handler:    _Node_Append_Handler,
+		},
 		{
 			MethodName: "QueryVersion",
 			Handler:    _Node_QueryVersion_Handler,
@@ -223,6 +871,62 @@ var Node_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "ListFiles",
 			Handler:    _Node_ListFiles_Handler,
 		},
+		{
+			MethodName: "Stat",
+			Handler:    _Node_Stat_Handler,
+		},
+		{
+			MethodName: "Mkdir",
+			Handler:    _Node_Mkdir_Handler,
+		},
+		{
+			MethodName: "Rmdir",
+			Handler:    _Node_Rmdir_Handler,
+		},
+		{
+			MethodName: "ReadDir",
+			Handler:    _Node_ReadDir_Handler,
+		},
+		{
+			MethodName: "CreateSnapshot",
+			Handler:    _Node_CreateSnapshot_Handler,
+		},
+		{
+			MethodName: "DeleteSnapshot",
+			Handler:    _Node_DeleteSnapshot_Handler,
+		},
+		{
+			MethodName: "ListSnapshots",
+			Handler:    _Node_ListSnapshots_Handler,
+		},
+		{
+			MethodName: "SetQuota",
+			Handler:    _Node_SetQuota_Handler,
+		},
+		{
+			MethodName: "GetUsage",
+			Handler:    _Node_GetUsage_Handler,
+		},
+		{
+			MethodName: "ListQuotas",
+			Handler:    _Node_ListQuotas_Handler,
+		},
+		{
+			MethodName: "Delete",
+			Handler:    _Node_Delete_Handler,
+		},
+		{
+			MethodName: "SetFolderTTL",
+			Handler:    _Node_SetFolderTTL_Handler,
+		},
+		{
+			MethodName: "MissingChunks",
+			Handler:    _Node_MissingChunks_Handler,
+		},
+		{
+			MethodName: "GetManifest",
+			Handler:    _Node_GetManifest_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -235,6 +939,21 @@ var Node_ServiceDesc = grpc.ServiceDesc{
 			Handler:       _Node_StreamRead_Handler,
 			ServerStreams: true,
 		},
+		{
+			StreamName:    "BatchWrite",
+			Handler:       _Node_BatchWrite_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "Watch",
+			Handler:       _Node_Watch_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "FetchChunk",
+			Handler:       _Node_FetchChunk_Handler,
+			ServerStreams: true,
+		},
 	},
 	Metadata: "node.proto",
 }
@@ -1,16 +1,20 @@
 module craq-cluster
 
-go 1.23.4
+go 1.25
 
 require (
 	github.com/cockroachdb/cockroach-go/v2 v2.4.1
 	github.com/jackc/pgx/v5 v5.7.5
+	github.com/klauspost/compress v1.20.1
+	github.com/klauspost/reedsolomon v1.14.2
+	go.etcd.io/bbolt v1.3.11
 	google.golang.org/grpc v1.73.0
 	google.golang.org/protobuf v1.36.6
 )
 
 require (
 	github.com/inconshreveable/mousetrap v1.1.0 // indirect
+	github.com/klauspost/cpuid/v2 v2.3.0 // indirect
 	github.com/spf13/cobra v1.9.1 // indirect
 	github.com/spf13/pflag v1.0.6 // indirect
 )
@@ -6,19 +6,102 @@ import (
 )
 
 type NodeInfo struct {
-	ID     string `json:"id"`
-	Addr   string `json:"addr"`
-	IsHead bool   `json:"isHead,omitempty"`
-	IsTail bool   `json:"isTail,omitempty"`
+	ID      string `json:"id"`
+	Addr    string `json:"addr"`
+	IsHead  bool   `json:"isHead,omitempty"`
+	IsTail  bool   `json:"isTail,omitempty"`
+	ChainID int    `json:"chainId,omitempty"`
 }
 
+// DBInfo selects the metadata backend: "cockroach" (the default) at Addr,
+// shared by every node; "embedded", a file at Path private to each node;
+// or "memory", private to each node and lost on restart.
+//
+// BatchWindow and MaxBatch tune cockroach group commit: concurrent
+// metadata updates within the window share a transaction. "0" turns it off.
 type DBInfo struct {
-	Addr string `json:"addr"`
+	Backend     string `json:"backend,omitempty"`
+	Addr        string `json:"addr"`
+	Path        string `json:"path,omitempty"`
+	BatchWindow string `json:"batchWindow,omitempty"`
+	MaxBatch    int    `json:"maxBatch,omitempty"`
+}
+
+// BlobsInfo selects where a node keeps the bytes of chunks and manifests:
+// "disk" (the default) under Dir; "memory", lost on restart; or "s3", a
+// bucket on an S3-compatible endpoint such as MinIO, under Prefix, which
+// defaults to the node's id so nodes sharing a bucket stay apart.
+// Compress, "gzip" or "zstd", keeps blobs compressed at rest.
+type BlobsInfo struct {
+	Backend   string `json:"backend,omitempty"`
+	Dir       string `json:"dir,omitempty"`
+	Endpoint  string `json:"endpoint,omitempty"`
+	Bucket    string `json:"bucket,omitempty"`
+	Region    string `json:"region,omitempty"`
+	AccessKey string `json:"accessKey,omitempty"`
+	SecretKey string `json:"secretKey,omitempty"`
+	Prefix    string `json:"prefix,omitempty"`
+	Compress  string `json:"compress,omitempty"`
+}
+
+// ErasureInfo stores a folder tree erasure-coded: each chunk becomes Data
+// data shards plus Parity parity shards spread across the chain.
+type ErasureInfo struct {
+	Data   int `json:"data"`
+	Parity int `json:"parity"`
+}
+
+// GCInfo configures the node's garbage collector. Durations use Go syntax,
+// e.g. "10m"; an empty value takes the node's default and "0" turns
+// collection off.
+type GCInfo struct {
+	Interval     string `json:"interval,omitempty"`
+	KeepVersions int    `json:"keepVersions,omitempty"`
+	Grace        string `json:"grace,omitempty"`
+}
+
+// TierInfo configures the archive tier: versions left unread for
+// IdleAfter, and superseded versions SupersededAfter after they were
+// written, move gzipped to Archive. Archive is configured like Blobs; left
+// empty it is the hot store's backend with "archive" appended to its Dir
+// or Prefix. Durations as in GCInfo.
+type TierInfo struct {
+	Interval        string    `json:"interval,omitempty"`
+	IdleAfter       string    `json:"idleAfter,omitempty"`
+	SupersededAfter string    `json:"supersededAfter,omitempty"`
+	Archive         BlobsInfo `json:"archive,omitempty"`
+}
+
+// TTLInfo configures how often a head deletes expired files.
+type TTLInfo struct {
+	Interval string `json:"interval,omitempty"`
+}
+
+// WatchInfo configures how long the change log behind Watch keeps events.
+type WatchInfo struct {
+	Retention string `json:"retention,omitempty"`
+}
+
+// ScrubInfo configures how often a node verifies its blobs.
+type ScrubInfo struct {
+	Interval string `json:"interval,omitempty"`
 }
 
 type Config struct {
-	Manager string `json:"manager"`
-	DB      DBInfo `json:"db"`
+	Manager string    `json:"manager"`
+	DB      DBInfo    `json:"db"`
+	Blobs   BlobsInfo `json:"blobs,omitempty"`
+	// Erasure maps folders to the code files under them are stored with;
+	// other folders are fully replicated.
+	Erasure map[string]ErasureInfo `json:"erasure,omitempty"`
+	// Compression maps folders to the wire compression, "gzip", "zstd" or
+	// "none", their versions are replicated and read with.
+	Compression map[string]string `json:"compression,omitempty"`
+	GC          GCInfo            `json:"gc,omitempty"`
+	Tier        TierInfo          `json:"tier,omitempty"`
+	TTL         TTLInfo           `json:"ttl,omitempty"`
+	Scrub       ScrubInfo         `json:"scrub,omitempty"`
+	Watch       WatchInfo         `json:"watch,omitempty"`
 }
 
 func Load(path string) (*Config, error) {
@@ -0,0 +1,67 @@
+package compression
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"testing"
+
+	"google.golang.org/grpc/encoding"
+)
+
+func TestCompressorsRoundTrip(t *testing.T) {
+	random := make([]byte, 64<<10)
+	rand.New(rand.NewSource(1)).Read(random)
+	messages := [][]byte{nil, []byte("x"), bytes.Repeat([]byte("craq "), 20000), random}
+
+	for _, name := range []string{Gzip, Zstd} {
+		c := encoding.GetCompressor(name)
+		if c == nil {
+			t.Fatalf("%s is not registered", name)
+		}
+		// Twice over, so pooled encoders and decoders are reused.
+		for round := range 2 {
+			for i, msg := range messages {
+				var buf bytes.Buffer
+				w, err := c.Compress(&buf)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if _, err := w.Write(msg); err != nil {
+					t.Fatal(err)
+				}
+				if err := w.Close(); err != nil {
+					t.Fatal(err)
+				}
+				if i == 2 && buf.Len() >= len(msg)/10 {
+					t.Fatalf("%s: %d repetitive bytes compressed only to %d", name, len(msg), buf.Len())
+				}
+				r, err := c.Decompress(&buf)
+				if err != nil {
+					t.Fatalf("%s: decompress: %v", name, err)
+				}
+				got, err := io.ReadAll(r)
+				if err != nil || !bytes.Equal(got, msg) {
+					t.Fatalf("%s round %d, message %d: got %d bytes, %v; want the %d written", name, round, i, len(got), err, len(msg))
+				}
+			}
+		}
+	}
+}
+
+func TestValidateAndEnabled(t *testing.T) {
+	for _, name := range []string{"", Gzip, Zstd, None} {
+		if err := Validate(name); err != nil {
+			t.Fatalf("validate %q: %v", name, err)
+		}
+		if want := name == Gzip || name == Zstd; Enabled(name) != want {
+			t.Fatalf("enabled %q = %v", name, !want)
+		}
+		if got := len(CallOptions(name)); (got > 0) != Enabled(name) {
+			t.Fatalf("call options for %q: %d", name, got)
+		}
+	}
+	if err := Validate("brotli"); err == nil {
+		t.Fatal("unknown compressor accepted")
+	}
+}
@@ -0,0 +1,146 @@
+package craq
+
+import (
+	"context"
+	"craq-cluster/gen/rpcpb"
+	"craq-cluster/pkg/compression"
+	"craq-cluster/pkg/storage"
+	"errors"
+	"fmt"
+	"path"
+	"time"
+)
+
+// ErrBaseMissing is returned by a replica asked to extend a version whose
+// manifest it doesn't hold.
+var ErrBaseMissing = errors.New("base version not held by this node")
+
+// HandleAppend adds req.Data to the end of a file as a new version. The
+// head picks the new seq and the committed version it extends, and keeps
+// the key locked until the tail acks, so appends to one file are applied in
+// the same order on every node. Only the appended bytes travel down the
+// chain; each node rebuilds the new manifest from its copy of the base.
+func (n *Node) HandleAppend(ctx context.Context, req *rpcpb.AppendReq, ack *rpcpb.AppendAck) error {
+	if n.IsHead {
+		unlock := n.keys.lock(req.Folder, req.FileName)
+		defer unlock()
+
+		if _, err := n.Storage.GetDir(ctx, path.Join(req.Folder, req.FileName)); err == nil {
+			return fmt.Errorf("append %s/%s: %w", req.Folder, req.FileName, ErrIsDirectory)
+		}
+		req.Seq, req.BaseSeq = 1, 0
+		latest, found, err := n.latestVersion(ctx, req.Folder, req.FileName)
+		if err != nil {
+			return err
+		}
+		if found {
+			req.Seq = latest.Seq + 1
+			if !latest.Deleted && !latest.Expired(time.Now()) {
+				req.BaseSeq = latest.CleanSeq
+			}
+		}
+		if req.BaseSeq == 0 {
+			// A new file takes the folder's default TTL; appends to an
+			// existing one keep its expiry.
+			ttl, err := n.Storage.FolderTTL(ctx, req.Folder)
+			if err != nil {
+				return fmt.Errorf("look up TTL of %s: %w", req.Folder, err)
+			}
+			req.ExpiresAt = expiryAt(ttl)
+		}
+	}
+
+	manifest, offset, err := n.appendVersion(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	releaseQuota := func() {}
+	if n.IsHead {
+		if releaseQuota, err = n.reserveQuota(ctx, []quotaWrite{{req.Folder, req.FileName, manifest.Size}}); err != nil {
+			return err
+		}
+	}
+
+	chunk, err := n.prepareVersion(ctx, &rpcpb.StreamWriteReq{
+		Folder:   req.Folder,
+		FileName: req.FileName,
+		Seq:      req.Seq,
+		Manifest: manifest,
+	})
+	if err != nil {
+		releaseQuota()
+		return err
+	}
+
+	if err := n.logVersion(walReceived, req.Folder, req.FileName, req.Seq); err != nil {
+		releaseQuota()
+		return err
+	}
+
+	err = n.Storage.Put(ctx, chunk)
+	releaseQuota()
+	if err != nil {
+		return fmt.Errorf("Storage Put failed: %w", err)
+	}
+
+	if !n.IsTail {
+		nextAck, err := n.Next.Append(ctx, req, compression.CallOptions(n.compressionFor(req.Folder, ""))...)
+		if err != nil {
+			return fmt.Errorf("forward Append to successor failed: %w", err)
+		}
+		if nextAck.Seq != req.Seq || nextAck.Size != manifest.Size {
+			return fmt.Errorf("successor applied append as seq %d size %d, want seq %d size %d",
+				nextAck.Seq, nextAck.Size, req.Seq, manifest.Size)
+		}
+		n.noteVersion(walForwarded, req.Folder, req.FileName, req.Seq)
+		// The tail has committed, so finish here even if the client gave up.
+		ctx = context.WithoutCancel(ctx)
+	}
+
+	if err := n.Storage.MarkClean(ctx, req.Folder, req.FileName, req.Seq); err != nil {
+		return fmt.Errorf("MarkClean failed: %w", err)
+	}
+	n.recordCommit(ctx, req.Folder, req.FileName, req.Seq)
+	n.noteVersion(walCommitted, req.Folder, req.FileName, req.Seq)
+
+	ack.Folder = req.Folder
+	ack.FileName = req.FileName
+	ack.Seq = req.Seq
+	ack.Offset = offset
+	ack.Size = manifest.Size
+	return nil
+}
+
+// appendVersion builds the manifest of req.Data appended to version
+// req.BaseSeq, storing any new chunks. It returns the manifest and the
+// offset the data starts at.
+func (n *Node) appendVersion(ctx context.Context, req *rpcpb.AppendReq) (*rpcpb.Manifest, uint64, error) {
+	base := &rpcpb.Manifest{Folder: req.Folder, FileName: req.FileName}
+	if req.BaseSeq > 0 {
+		var err error
+		base, err = n.loadManifest(ctx, req.Folder, req.FileName, req.BaseSeq)
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, 0, fmt.Errorf("append %s/%s onto seq %d: %w", req.Folder, req.FileName, req.BaseSeq, ErrBaseMissing)
+		}
+		if err != nil {
+			return nil, 0, fmt.Errorf("load base manifest: %w", err)
+		}
+	}
+
+	c, err := n.newAppendChunker(ctx, base)
+	if err != nil {
+		return nil, 0, err
+	}
+	if req.BaseSeq == 0 {
+		c.manifest.ExpiresAt = req.ExpiresAt
+	}
+	if _, err := c.Write(req.Data); err != nil {
+		return nil, 0, err
+	}
+	manifest, err := c.Close()
+	if err != nil {
+		return nil, 0, err
+	}
+	return manifest, base.Size, nil
+}
@@ -0,0 +1,88 @@
+package craq
+
+import (
+	"bytes"
+	"context"
+	"craq-cluster/gen/rpcpb"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestAppendRehashesAcrossChunks(t *testing.T) {
+	ctx := context.Background()
+	nodes, clients := newTestChain(t, 3)
+	first := testData(1, ContentChunkSize+ContentChunkSize/2)
+	more := testData(2, ContentChunkSize)
+
+	if _, err := writeFile(ctx, nodes[0], "/docs", "log", first); err != nil {
+		t.Fatal(err)
+	}
+	ack := &rpcpb.AppendAck{}
+	if err := nodes[0].HandleAppend(ctx, &rpcpb.AppendReq{Folder: "/docs", FileName: "log", Data: more}, ack); err != nil {
+		t.Fatalf("append: %v", err)
+	}
+	if ack.Seq != 2 || ack.Offset != uint64(len(first)) {
+		t.Fatalf("append acked seq %d offset %d; want 2, %d", ack.Seq, ack.Offset, len(first))
+	}
+
+	want := append(append([]byte{}, first...), more...)
+	for i, n := range nodes {
+		m, err := n.loadManifest(ctx, "/docs", "log", 2)
+		if err != nil {
+			t.Fatalf("%s: %v", n.ID, err)
+		}
+		if m.Checksum != sha256Hex(want) || m.Size != uint64(len(want)) {
+			t.Fatalf("%s: checksum %s size %d; want %s, %d", n.ID, m.Checksum, m.Size, sha256Hex(want), len(want))
+		}
+		for j, ref := range m.Chunks {
+			if j < len(m.Chunks)-1 && ref.Size != ContentChunkSize {
+				t.Fatalf("%s: chunk %d of %d bytes before the last", n.ID, j, ref.Size)
+			}
+		}
+		if got := readFile(t, clients[i], "/docs", "log"); !bytes.Equal(got, want) {
+			t.Fatalf("%s: appended file reads back wrong", n.ID)
+		}
+	}
+}
+
+func TestConcurrentAppendsApplyInOrder(t *testing.T) {
+	ctx := context.Background()
+	nodes, clients := newTestChain(t, 3)
+	const appenders = 8
+
+	acks := make([]*rpcpb.AppendAck, appenders)
+	errs := make([]error, appenders)
+	var wg sync.WaitGroup
+	for i := range appenders {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			acks[i] = &rpcpb.AppendAck{}
+			errs[i] = nodes[0].HandleAppend(ctx, &rpcpb.AppendReq{
+				Folder: "/docs", FileName: "log", Data: []byte(fmt.Sprintf("<%d>", i)),
+			}, acks[i])
+		}()
+	}
+	wg.Wait()
+
+	want := readFile(t, clients[0], "/docs", "log")
+	for i := range appenders {
+		if errs[i] != nil {
+			t.Fatalf("append %d: %v", i, errs[i])
+		}
+		piece := fmt.Sprintf("<%d>", i)
+		if off := acks[i].Offset; int(off)+len(piece) > len(want) || string(want[off:int(off)+len(piece)]) != piece {
+			t.Fatalf("append %d not at its acked offset %d in %q", i, off, want)
+		}
+	}
+	for i, n := range nodes {
+		c, err := n.Storage.GetLatest(ctx, "/docs", "log")
+		if err != nil || c.CleanSeq != appenders {
+			t.Fatalf("%s: clean seq %d, %v; want %d", n.ID, c.CleanSeq, err, appenders)
+		}
+		if got := readFile(t, clients[i], "/docs", "log"); !bytes.Equal(got, want) {
+			t.Fatalf("%s: reads %q, head reads %q", n.ID, got, want)
+		}
+	}
+}
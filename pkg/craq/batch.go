@@ -0,0 +1,190 @@
+package craq
+
+import (
+	"context"
+	"craq-cluster/gen/rpcpb"
+	"craq-cluster/pkg/compression"
+	"craq-cluster/pkg/storage"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// ErrWrongChain is returned by a head for a batch naming a file another
+// chain is responsible for.
+var ErrWrongChain = errors.New("file belongs to another chain")
+
+// HandleBatch stores several writes as dirty versions in one transaction,
+// forwards them to the successor as one stream and, once the tail has
+// them, marks them all clean in one transaction. Readers only see clean
+// versions, so they see every file of the batch or none.
+func (n *Node) HandleBatch(ctx context.Context, reqs []*rpcpb.StreamWriteReq, ack *rpcpb.BatchAck) error {
+	chunks := make([]storage.Chunk, 0, len(reqs))
+	if n.IsHead {
+		// The batch commits on one chain only, so every file in it must
+		// belong to that chain.
+		if n.Owns != nil {
+			for _, req := range reqs {
+				if !n.Owns(req.Folder, req.FileName) {
+					return fmt.Errorf("%s/%s: %w", req.Folder, req.FileName, ErrWrongChain)
+				}
+			}
+		}
+		defer n.lockKeys(reqs)()
+	}
+
+	// The head checks the whole batch against folder quotas before storing
+	// any of it.
+	releaseQuota := func() {}
+	if n.IsHead {
+		writes := make([]quotaWrite, 0, len(reqs))
+		for _, req := range reqs {
+			if req.Manifest != nil {
+				writes = append(writes, quotaWrite{req.Folder, req.FileName, req.Manifest.Size})
+			}
+		}
+		var err error
+		if releaseQuota, err = n.reserveQuota(ctx, writes); err != nil {
+			return err
+		}
+	}
+
+	// The head holds every file's key lock, so the seqs it assigns here
+	// can't race another write to the same files.
+	for _, req := range reqs {
+		if n.IsHead {
+			if err := n.assignSeq(ctx, req); err != nil {
+				releaseQuota()
+				return err
+			}
+			if err := n.assignExpiry(ctx, req); err != nil {
+				releaseQuota()
+				return err
+			}
+		}
+		chunk, err := n.prepareVersion(ctx, req)
+		if err != nil {
+			releaseQuota()
+			return err
+		}
+		chunks = append(chunks, chunk)
+	}
+	if err := n.logRecord(batchRecord(walReceived, chunks)); err != nil {
+		releaseQuota()
+		return err
+	}
+	err := n.Storage.PutBatch(ctx, chunks)
+	releaseQuota()
+	if err != nil {
+		return fmt.Errorf("Storage PutBatch failed: %w", err)
+	}
+
+	if err := n.finishBatch(ctx, reqs, chunks, false); err != nil {
+		return err
+	}
+	for _, c := range chunks {
+		ack.Acks = append(ack.Acks, &rpcpb.WriteAck{Folder: c.Folder, FileName: c.FileName, Seq: c.Seq})
+	}
+	return nil
+}
+
+// finishBatch forwards a stored batch to the successor, unless this is the
+// tail or it already was forwarded, and then marks it clean here.
+func (n *Node) finishBatch(ctx context.Context, reqs []*rpcpb.StreamWriteReq, chunks []storage.Chunk, forwarded bool) error {
+	if !n.IsTail && !forwarded {
+		nextAck, err := n.streamBatchToNext(ctx, reqs)
+		if err != nil {
+			return fmt.Errorf("forward BatchWrite to successor failed: %w", err)
+		}
+		if len(nextAck.Acks) != len(chunks) {
+			return fmt.Errorf("successor acked %d of %d batch files", len(nextAck.Acks), len(chunks))
+		}
+		n.noteRecord(batchRecord(walForwarded, chunks))
+		// The tail has committed, so finish here even if the client gave up.
+		ctx = context.WithoutCancel(ctx)
+	}
+
+	if err := n.Storage.MarkCleanBatch(ctx, chunks); err != nil {
+		return fmt.Errorf("MarkCleanBatch failed: %w", err)
+	}
+	for _, c := range chunks {
+		n.recordCommit(ctx, c.Folder, c.FileName, c.Seq)
+	}
+	n.noteRecord(batchRecord(walCommitted, chunks))
+	return nil
+}
+
+// streamBatchToNext replicates a batch as one BatchWrite stream: each
+// file's manifest followed by the chunks the successor is missing. A chunk
+// shared by several files is sent once.
+func (n *Node) streamBatchToNext(ctx context.Context, reqs []*rpcpb.StreamWriteReq) (*rpcpb.BatchAck, error) {
+	var ids []string
+	for _, req := range reqs {
+		ids = append(ids, n.heldChunkIDs(req.Manifest, n.ChainPos+1)...)
+	}
+	missing, err := n.Next.MissingChunks(ctx, &rpcpb.ChunkSet{Ids: ids})
+	if err != nil {
+		return nil, fmt.Errorf("query missing chunks failed: %w", err)
+	}
+	toSend := make(map[string]bool, len(missing.Ids))
+	for _, id := range missing.Ids {
+		toSend[id] = true
+	}
+
+	// The batch travels as one stream, compressed as its first file is.
+	stream, err := n.Next.BatchWrite(ctx, compression.CallOptions(n.compressionFor(reqs[0].Folder, reqs[0].Compression))...)
+	if err != nil {
+		return nil, fmt.Errorf("start batch stream to next node failed: %w", err)
+	}
+
+	for _, req := range reqs {
+		var send []string
+		for _, id := range n.heldChunkIDs(req.Manifest, n.ChainPos+1) {
+			if toSend[id] {
+				send = append(send, id)
+				delete(toSend, id)
+			}
+		}
+		if err := n.sendVersion(ctx, stream, req, send); err != nil {
+			return nil, err
+		}
+	}
+
+	ack, err := stream.CloseAndRecv()
+	if err != nil {
+		return nil, fmt.Errorf("batch stream close failed: %w", err)
+	}
+	return ack, nil
+}
+
+// lockKeys takes the key lock of every file in a batch, in sorted order so
+// two batches sharing files can't deadlock, and returns the function that
+// releases them.
+func (n *Node) lockKeys(reqs []*rpcpb.StreamWriteReq) func() {
+	type key struct{ folder, fileName string }
+	seen := make(map[key]bool, len(reqs))
+	keys := make([]key, 0, len(reqs))
+	for _, req := range reqs {
+		k := key{req.Folder, req.FileName}
+		if !seen[k] {
+			seen[k] = true
+			keys = append(keys, k)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].folder != keys[j].folder {
+			return keys[i].folder < keys[j].folder
+		}
+		return keys[i].fileName < keys[j].fileName
+	})
+
+	unlocks := make([]func(), 0, len(keys))
+	for _, k := range keys {
+		unlocks = append(unlocks, n.keys.lock(k.folder, k.fileName))
+	}
+	return func() {
+		for i := len(unlocks) - 1; i >= 0; i-- {
+			unlocks[i]()
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package craq
+
+import (
+	"context"
+	"craq-cluster/pkg/compression"
+	"craq-cluster/pkg/storage"
+	"log"
+	"path"
+	"slices"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/encoding"
+)
+
+// folderSetting returns the setting of the nearest folder at or above
+// folder that has one.
+func folderSetting[T any](settings map[string]T, folder string) (T, bool) {
+	for dir := storage.CleanDir(folder); ; dir = path.Dir(dir) {
+		if v, ok := settings[dir]; ok {
+			return v, true
+		}
+		if dir == "/" {
+			var zero T
+			return zero, false
+		}
+	}
+}
+
+// compressionFor returns the wire compression for a version under folder:
+// requested if the client named one, otherwise the folder's setting.
+func (n *Node) compressionFor(folder, requested string) string {
+	if requested != "" {
+		return requested
+	}
+	name, _ := folderSetting(n.Compression, folder)
+	return name
+}
+
+// setSendCompression compresses the response stream of a read with name,
+// if the client accepts it; otherwise the response goes as gRPC would
+// send it.
+func setSendCompression(ctx context.Context, name string) {
+	if name == "" {
+		return
+	}
+	if name == compression.None {
+		name = encoding.Identity
+	} else if accepted, err := grpc.ClientSupportedCompressors(ctx); err != nil || !slices.Contains(accepted, name) {
+		log.Printf("⚠️ Client does not accept %s, reply uncompressed", name)
+		return
+	}
+	if err := grpc.SetSendCompressor(ctx, name); err != nil {
+		log.Printf("⚠️ Failed to set %s reply compression: %v", name, err)
+	}
+}
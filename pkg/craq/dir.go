@@ -0,0 +1,87 @@
+package craq
+
+import (
+	"context"
+	"craq-cluster/gen/rpcpb"
+	"craq-cluster/pkg/storage"
+	"errors"
+	"fmt"
+	"path"
+	"time"
+)
+
+// Errors the head returns when a name is already taken by the other kind
+// of entry.
+var (
+	ErrIsDirectory = errors.New("a directory with that name exists")
+	ErrIsFile      = errors.New("a file with that name exists")
+)
+
+// HandleMkdir creates a directory locally and forwards the request down
+// the chain. Only the head checks for a clashing file; replicas apply.
+func (n *Node) HandleMkdir(ctx context.Context, req *rpcpb.DirReq) error {
+	dir := storage.CleanDir(req.Path)
+
+	if n.IsHead {
+		if dir != "/" {
+			c, found, err := n.latestVersion(ctx, path.Dir(dir), path.Base(dir))
+			if err != nil {
+				return fmt.Errorf("mkdir %s: %w", dir, err)
+			}
+			if found && !c.Deleted {
+				return fmt.Errorf("mkdir %s: %w", dir, ErrIsFile)
+			}
+		}
+		req.OpId = uint64(time.Now().UnixNano())
+	}
+
+	if err := n.Storage.Mkdir(ctx, dir, req.Recursive); err != nil {
+		return fmt.Errorf("mkdir %s: %w", dir, err)
+	}
+
+	if !n.IsTail {
+		if _, err := n.Next.Mkdir(ctx, req); err != nil {
+			return fmt.Errorf("forward Mkdir to successor failed: %w", err)
+		}
+	}
+	n.recordDirEvent(ctx, storage.EventCreate, dir, req.OpId)
+	return nil
+}
+
+// HandleRmdir removes a directory locally and forwards the request down
+// the chain. A replica that finds the directory already gone treats the
+// removal as applied, since replicas may share metadata with the head.
+func (n *Node) HandleRmdir(ctx context.Context, req *rpcpb.DirReq) error {
+	dir := storage.CleanDir(req.Path)
+	if n.IsHead {
+		req.OpId = uint64(time.Now().UnixNano())
+	}
+
+	err := n.Storage.Rmdir(ctx, dir, req.Recursive)
+	if err != nil && (n.IsHead || !errors.Is(err, storage.ErrDirNotFound)) {
+		return fmt.Errorf("rmdir %s: %w", dir, err)
+	}
+
+	if !n.IsTail {
+		if _, err := n.Next.Rmdir(ctx, req); err != nil {
+			return fmt.Errorf("forward Rmdir to successor failed: %w", err)
+		}
+	}
+	n.recordDirEvent(ctx, storage.EventDelete, dir, req.OpId)
+	return nil
+}
+
+// recordDirEvent logs a directory change once the tail has applied it. The
+// op id stands in for a seq so repeated mkdirs of a path stay distinct.
+func (n *Node) recordDirEvent(ctx context.Context, kind storage.EventKind, dir string, opID uint64) {
+	if dir == "/" {
+		return
+	}
+	n.recordEvent(ctx, storage.Event{
+		Kind:     kind,
+		Folder:   path.Dir(dir),
+		FileName: path.Base(dir),
+		Seq:      opID,
+		IsDir:    true,
+	})
+}
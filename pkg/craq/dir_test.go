@@ -0,0 +1,80 @@
+package craq
+
+import (
+	"context"
+	"craq-cluster/gen/rpcpb"
+	"slices"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// dirNames returns the entries of a ReadDir, subfolders ending in "/".
+func dirNames(t *testing.T, client rpcpb.NodeClient, folder string) []string {
+	t.Helper()
+	listing, err := client.ReadDir(context.Background(), &rpcpb.FolderQuery{Folder: folder, PageSize: 100})
+	if err != nil {
+		t.Fatalf("read dir %s: %v", folder, err)
+	}
+	var names []string
+	for _, e := range listing.Entries {
+		if e.IsDir {
+			names = append(names, e.Name+"/")
+		} else {
+			names = append(names, e.Name)
+		}
+	}
+	return names
+}
+
+func TestDirectoriesReplicateAndRefuseRemovingContents(t *testing.T) {
+	ctx := context.Background()
+	nodes, clients := newTestChain(t, 3)
+	head := clients[0]
+
+	if _, err := head.Mkdir(ctx, &rpcpb.DirReq{Path: "/docs/empty"}); status.Code(err) != codes.NotFound {
+		t.Fatalf("mkdir without its parent: %v, want NotFound", err)
+	}
+	if _, err := head.Mkdir(ctx, &rpcpb.DirReq{Path: "docs//empty/", Recursive: true}); err != nil {
+		t.Fatalf("mkdir -p: %v", err)
+	}
+	if _, err := writeFile(ctx, nodes[0], "/docs", "a", []byte("a")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := head.Mkdir(ctx, &rpcpb.DirReq{Path: "/docs/a"}); status.Code(err) != codes.AlreadyExists {
+		t.Fatalf("mkdir over a file: %v, want AlreadyExists", err)
+	}
+
+	// An empty folder is listed on every node, beside the file.
+	for i, client := range clients {
+		if names := dirNames(t, client, "/docs"); !slices.Equal(names, []string{"a", "empty/"}) {
+			t.Fatalf("%s: /docs lists %v, want a and empty/", nodes[i].ID, names)
+		}
+	}
+
+	if _, err := head.Rmdir(ctx, &rpcpb.DirReq{Path: "/docs"}); status.Code(err) != codes.FailedPrecondition {
+		t.Fatalf("rmdir of a folder with contents: %v, want FailedPrecondition", err)
+	}
+	if _, err := head.Rmdir(ctx, &rpcpb.DirReq{Path: "/docs/empty"}); err != nil {
+		t.Fatalf("rmdir of an empty folder: %v", err)
+	}
+	if names := dirNames(t, clients[2], "/docs"); !slices.Equal(names, []string{"a"}) {
+		t.Fatalf("tail lists %v after rmdir, want a", names)
+	}
+	if _, err := head.Rmdir(ctx, &rpcpb.DirReq{Path: "/"}); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("rmdir /: %v, want InvalidArgument", err)
+	}
+
+	if _, err := head.Rmdir(ctx, &rpcpb.DirReq{Path: "/docs", Recursive: true}); err != nil {
+		t.Fatalf("rmdir -r: %v", err)
+	}
+	for i, client := range clients {
+		if _, err := client.ReadDir(ctx, &rpcpb.FolderQuery{Folder: "/docs"}); status.Code(err) != codes.NotFound {
+			t.Fatalf("%s: read dir of a removed folder: %v, want NotFound", nodes[i].ID, err)
+		}
+		if _, err := tryReadFile(client, "/docs", "a"); status.Code(err) != codes.NotFound {
+			t.Fatalf("%s: read of a file in a removed folder: %v, want NotFound", nodes[i].ID, err)
+		}
+	}
+}
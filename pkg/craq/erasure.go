@@ -0,0 +1,297 @@
+package craq
+
+import (
+	"bytes"
+	"context"
+	"craq-cluster/gen/rpcpb"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/klauspost/reedsolomon"
+)
+
+// ErasurePolicy stores a folder tree Reed-Solomon coded instead of fully
+// replicated: each content chunk is split into DataShards pieces plus
+// ParityShards parity pieces, and shard i is kept only by the node at
+// chain position i mod the chain length. Any DataShards shards rebuild the
+// chunk, so with DataShards+ParityShards equal to the chain length a file
+// survives the loss of ParityShards nodes at (d+p)/d times its size
+// rather than one full copy per node.
+type ErasurePolicy struct {
+	DataShards   int
+	ParityShards int
+}
+
+// Validate checks that the policy describes a usable code.
+func (p ErasurePolicy) Validate() error {
+	if p.DataShards < 1 || p.ParityShards < 1 || p.DataShards+p.ParityShards > 256 {
+		return fmt.Errorf("invalid erasure code %d+%d: need at least one data and one parity shard, 256 in all",
+			p.DataShards, p.ParityShards)
+	}
+	return nil
+}
+
+// ErrShardsLost is returned when too few shards of an erasure-coded chunk
+// can be read to rebuild it.
+var ErrShardsLost = errors.New("too few shards to rebuild chunk")
+
+// erasureFor returns the layout new versions under folder are written
+// with: that of the nearest folder with a policy, or nil for full
+// replication.
+func (n *Node) erasureFor(folder string) *rpcpb.Erasure {
+	p, ok := folderSetting(n.Erasure, folder)
+	if !ok {
+		return nil
+	}
+	return &rpcpb.Erasure{DataShards: uint32(p.DataShards), ParityShards: uint32(p.ParityShards)}
+}
+
+func (n *Node) chainLen() int {
+	if len(n.Chain) == 0 {
+		return 1
+	}
+	return len(n.Chain)
+}
+
+// shardOwner returns the chain position that keeps shard i of a chunk.
+func (n *Node) shardOwner(i int) int {
+	return i % n.chainLen()
+}
+
+// heldChunkIDs returns the blobs of m that the nodes from chain position
+// from onward keep between them: every chunk of a replicated version, but
+// only the shards those nodes own of an erasure-coded one. A node receives
+// what it and its successors hold and forwards what only they hold.
+func (n *Node) heldChunkIDs(m *rpcpb.Manifest, from int) []string {
+	seen := make(map[string]bool)
+	var ids []string
+	add := func(id string) {
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	for _, c := range m.Chunks {
+		if len(c.Shards) == 0 {
+			add(c.Id)
+			continue
+		}
+		for i, id := range c.Shards {
+			if n.shardOwner(i) >= from {
+				add(id)
+			}
+		}
+	}
+	return ids
+}
+
+// ownedChunkIDs returns the blobs of m this node keeps once the version
+// has committed.
+func (n *Node) ownedChunkIDs(m *rpcpb.Manifest) []string {
+	var ids []string
+	for _, c := range m.Chunks {
+		if len(c.Shards) == 0 {
+			ids = append(ids, c.Id)
+			continue
+		}
+		for i, id := range c.Shards {
+			if n.shardOwner(i) == n.ChainPos {
+				ids = append(ids, id)
+			}
+		}
+	}
+	return ids
+}
+
+// shardFetchTimeout bounds each fetch of a shard from its owner.
+const shardFetchTimeout = 30 * time.Second
+
+var encoders sync.Map // [2]int{data, parity} -> reedsolomon.Encoder
+
+func encoderFor(data, parity int) (reedsolomon.Encoder, error) {
+	key := [2]int{data, parity}
+	if enc, ok := encoders.Load(key); ok {
+		return enc.(reedsolomon.Encoder), nil
+	}
+	enc, err := reedsolomon.New(data, parity)
+	if err != nil {
+		return nil, fmt.Errorf("erasure code %d+%d: %w", data, parity, err)
+	}
+	encoders.Store(key, enc)
+	return enc, nil
+}
+
+// shardSize is the size of each shard of an erasure-coded chunk.
+func shardSize(ref *rpcpb.ChunkRef) uint64 {
+	k := uint64(ref.DataShards)
+	return (ref.Size + k - 1) / k
+}
+
+// encodeChunk splits data into the shards of e.
+func encodeChunk(data []byte, e *rpcpb.Erasure) ([][]byte, error) {
+	enc, err := encoderFor(int(e.DataShards), int(e.ParityShards))
+	if err != nil {
+		return nil, err
+	}
+	// Split pads into spare capacity of its argument, so give it a copy.
+	shards, err := enc.Split(append([]byte(nil), data...))
+	if err != nil {
+		return nil, err
+	}
+	if err := enc.Encode(shards); err != nil {
+		return nil, err
+	}
+	return shards, nil
+}
+
+// storeErasureChunk encodes data and stores every shard. The node keeps
+// shards it doesn't own only until they have been forwarded; the
+// collector reclaims them after its grace period.
+func (n *Node) storeErasureChunk(ctx context.Context, data []byte, e *rpcpb.Erasure) (*rpcpb.ChunkRef, error) {
+	shards, err := encodeChunk(data, e)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(data)
+	ref := &rpcpb.ChunkRef{Id: hex.EncodeToString(sum[:]), Size: uint64(len(data)), DataShards: e.DataShards}
+	for _, shard := range shards {
+		sref, err := n.storeChunk(ctx, shard)
+		if err != nil {
+			return nil, err
+		}
+		ref.Shards = append(ref.Shards, sref.Id)
+	}
+	return ref, nil
+}
+
+// readChunkRef returns the bytes of a content chunk: read whole if the
+// node holds it, or rebuilt from any DataShards of its shards, read
+// locally where the node has them and fetched from their owners
+// otherwise. Data shards are tried before parity, so a healthy chunk needs
+// no decoding.
+func (n *Node) readChunkRef(ctx context.Context, ref *rpcpb.ChunkRef) ([]byte, error) {
+	if len(ref.Shards) == 0 {
+		return n.readChunk(ctx, ref.Id)
+	}
+	k := int(ref.DataShards)
+	enc, err := encoderFor(k, len(ref.Shards)-k)
+	if err != nil {
+		return nil, err
+	}
+
+	size := shardSize(ref)
+	shards := make([][]byte, len(ref.Shards))
+	have := 0
+	for _, local := range []bool{true, false} {
+		for i, id := range ref.Shards {
+			if have == k {
+				break
+			}
+			if shards[i] != nil {
+				continue
+			}
+			var data []byte
+			if local {
+				data, err = n.readChunk(ctx, id)
+			} else {
+				data, err = n.fetchShard(ctx, i, id, size)
+			}
+			if err == nil {
+				err = checkBlob(id, size, data)
+			}
+			if err != nil {
+				if !local {
+					log.Printf("⚠️ Node %s shard %d of chunk %s unavailable: %v", n.ID, i, ref.Id, err)
+				}
+				continue
+			}
+			shards[i] = data
+			have++
+		}
+	}
+	if have < k {
+		return nil, fmt.Errorf("chunk %s: %d of %d shards readable: %w", ref.Id, have, k, ErrShardsLost)
+	}
+
+	if err := enc.ReconstructData(shards); err != nil {
+		return nil, fmt.Errorf("rebuild chunk %s: %w", ref.Id, err)
+	}
+	var buf bytes.Buffer
+	if err := enc.Join(&buf, shards, int(ref.Size)); err != nil {
+		return nil, fmt.Errorf("rebuild chunk %s: %w", ref.Id, err)
+	}
+	if err := checkBlob(ref.Id, ref.Size, buf.Bytes()); err != nil {
+		return nil, fmt.Errorf("rebuild chunk %s: %w", ref.Id, err)
+	}
+	return buf.Bytes(), nil
+}
+
+// fetchShard reads shard i from the node that owns it.
+func (n *Node) fetchShard(ctx context.Context, i int, id string, size uint64) ([]byte, error) {
+	owner := n.shardOwner(i)
+	if owner >= len(n.Chain) || n.Chain[owner] == nil {
+		return nil, fmt.Errorf("no peer at chain position %d", owner)
+	}
+	ctx, cancel := context.WithTimeout(ctx, shardFetchTimeout)
+	defer cancel()
+	stream, err := n.Chain[owner].FetchChunk(ctx, &rpcpb.ChunkRef{Id: id, Size: size})
+	if err != nil {
+		return nil, err
+	}
+	data := make([]byte, 0, size)
+	for {
+		msg, err := stream.Recv()
+		if err == io.EOF {
+			return data, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, msg.Data...)
+	}
+}
+
+// repairShards restores the shards of ref this node owns by rebuilding
+// the chunk from the other shards and encoding it again.
+func (n *Node) repairShards(ctx context.Context, ref *rpcpb.ChunkRef) bool {
+	data, err := n.readChunkRef(ctx, ref)
+	if err != nil {
+		log.Printf("⚠️ Node %s could not repair shards of chunk %s: %v", n.ID, ref.Id, err)
+		return false
+	}
+	shards, err := encodeChunk(data, &rpcpb.Erasure{DataShards: ref.DataShards, ParityShards: uint32(len(ref.Shards)) - ref.DataShards})
+	if err != nil {
+		log.Printf("⚠️ Node %s could not repair shards of chunk %s: %v", n.ID, ref.Id, err)
+		return false
+	}
+	for i, id := range ref.Shards {
+		if n.shardOwner(i) != n.ChainPos {
+			continue
+		}
+		n.Blobs.Delete(ctx, chunkKey(id))
+		if err := n.storeChunkAs(ctx, id, shards[i]); err != nil {
+			log.Printf("⚠️ Node %s rebuilt shard %d of chunk %s rejected: %v", n.ID, i, ref.Id, err)
+			return false
+		}
+	}
+	log.Printf("🩹 Node %s rebuilt its shards of chunk %s", n.ID, ref.Id)
+	return true
+}
+
+// checkBlob checks that data has the given size and hashes to id.
+func checkBlob(id string, size uint64, data []byte) error {
+	if uint64(len(data)) != size {
+		return fmt.Errorf("size %d, want %d", len(data), size)
+	}
+	sum := sha256.Sum256(data)
+	if got := hex.EncodeToString(sum[:]); got != id {
+		return fmt.Errorf("content hashes to %s", got)
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package craq
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestErasureRebuildsChunksWithParityShardsLost(t *testing.T) {
+	ctx := context.Background()
+	nodes, clients := newTestChain(t, 4)
+	for _, n := range nodes {
+		n.Erasure = map[string]ErasurePolicy{"/ec": {DataShards: 2, ParityShards: 2}}
+	}
+	data := testData(5, ContentChunkSize+ContentChunkSize/3)
+	if _, err := writeFile(ctx, nodes[0], "/ec", "f", data); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	m, err := nodes[1].loadManifest(ctx, "/ec", "f", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Each node keeps the shard its chain position owns.
+	for i, n := range nodes {
+		if owned := n.ownedChunkIDs(m); len(owned) != len(m.Chunks) {
+			t.Fatalf("%s owns %d shards, want one per chunk", n.ID, len(owned))
+		}
+		for _, ref := range m.Chunks {
+			if len(ref.Shards) != 4 {
+				t.Fatalf("chunk %s has %d shards, want 4", ref.Id, len(ref.Shards))
+			}
+			if !n.hasChunk(ctx, ref.Shards[i]) {
+				t.Fatalf("%s lacks its shard %d of chunk %s", n.ID, i, ref.Id)
+			}
+		}
+	}
+
+	// Lose as many shards as there are parity shards, everywhere.
+	lose := func(i int) {
+		for _, n := range nodes {
+			for _, ref := range m.Chunks {
+				n.Blobs.Delete(ctx, chunkKey(ref.Shards[i]))
+			}
+		}
+	}
+	lose(0)
+	lose(3)
+	for i, client := range clients {
+		if got := readFile(t, client, "/ec", "f"); !bytes.Equal(got, data) {
+			t.Fatalf("%s: rebuilt file differs from the one written", nodes[i].ID)
+		}
+	}
+
+	// One more is too many.
+	lose(1)
+	if _, err := tryReadFile(clients[2], "/ec", "f"); status.Code(err) != codes.Unavailable {
+		t.Fatalf("read with 3 of 4 shards lost: %v, want Unavailable", err)
+	}
+}
@@ -0,0 +1,159 @@
+package craq
+
+import (
+	"context"
+	"craq-cluster/gen/rpcpb"
+	"craq-cluster/pkg/storage"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+var (
+	ErrFileNotFound = fmt.Errorf("file %w", storage.ErrNotFound)
+	// ErrNotExpired is returned for an expiry delete of a file that was
+	// rewritten, or had its expiry moved, since it was picked up.
+	ErrNotExpired = errors.New("file has not expired")
+)
+
+// expiryBatch is how many expired files the head reads per query.
+const expiryBatch = 100
+
+// assignExpiry stamps the manifest of a write with its expiry: the TTL the
+// client asked for, else the folder default. Only the head calls it, so
+// every replica stores the same instant.
+func (n *Node) assignExpiry(ctx context.Context, req *rpcpb.StreamWriteReq) error {
+	if req.Manifest == nil {
+		return nil
+	}
+	ttl := time.Duration(req.TtlSeconds) * time.Second
+	if ttl == 0 {
+		var err error
+		if ttl, err = n.Storage.FolderTTL(ctx, req.Folder); err != nil {
+			return fmt.Errorf("look up TTL of %s: %w", req.Folder, err)
+		}
+	}
+	req.Manifest.ExpiresAt = expiryAt(ttl)
+	return nil
+}
+
+// expiryAt returns the unix time ttl from now, or 0 for no TTL.
+func expiryAt(ttl time.Duration) int64 {
+	if ttl <= 0 {
+		return 0
+	}
+	return time.Now().Add(ttl).Unix()
+}
+
+// HandleDelete tombstones a file and forwards the delete down the chain.
+// The head gives the tombstone the next seq, so it orders after every
+// write to the file; replicas that already see it treat it as applied.
+func (n *Node) HandleDelete(ctx context.Context, req *rpcpb.DeleteReq, ack *rpcpb.WriteAck) error {
+	if n.IsHead {
+		unlock := n.keys.lock(req.Folder, req.FileName)
+		defer unlock()
+
+		latest, found, err := n.latestVersion(ctx, req.Folder, req.FileName)
+		if err != nil {
+			return fmt.Errorf("delete %s/%s: %w", req.Folder, req.FileName, err)
+		}
+		if !found || latest.Deleted {
+			return fmt.Errorf("delete %s/%s: %w", req.Folder, req.FileName, ErrFileNotFound)
+		}
+		if req.IfExpired && !latest.Expired(time.Now()) {
+			return fmt.Errorf("delete %s/%s: %w", req.Folder, req.FileName, ErrNotExpired)
+		}
+		req.Seq = latest.Seq + 1
+	}
+
+	if err := n.Storage.DeleteFile(ctx, req.Folder, req.FileName, req.Seq); err != nil {
+		return fmt.Errorf("delete %s/%s: %w", req.Folder, req.FileName, err)
+	}
+
+	if !n.IsTail {
+		if _, err := n.Next.Delete(ctx, req); err != nil {
+			return fmt.Errorf("forward Delete to successor failed: %w", err)
+		}
+	}
+	n.recordEvent(ctx, storage.Event{Kind: storage.EventDelete, Folder: req.Folder, FileName: req.FileName, Seq: req.Seq})
+
+	ack.Folder = req.Folder
+	ack.FileName = req.FileName
+	ack.Seq = req.Seq
+	return nil
+}
+
+// HandleSetFolderTTL sets a folder's default TTL locally and forwards it
+// down the chain, so whichever replica a file's write reaches it gets the
+// same expiry. Setting it again is harmless where replicas share the
+// head's metadata.
+func (n *Node) HandleSetFolderTTL(ctx context.Context, folder string, ttl time.Duration) error {
+	if err := n.Storage.SetFolderTTL(ctx, folder, ttl); err != nil {
+		return fmt.Errorf("ttl %s: %w", folder, err)
+	}
+	if !n.IsTail {
+		req := &rpcpb.FolderTTL{Folder: folder, TtlSeconds: int64(ttl / time.Second)}
+		if _, err := n.Next.SetFolderTTL(ctx, req); err != nil {
+			return fmt.Errorf("forward SetFolderTTL to successor failed: %w", err)
+		}
+	}
+	return nil
+}
+
+// RunExpiry deletes expired files every interval until ctx is done. Only
+// a head does this, and only for files owns says its chain is responsible
+// for, since every chain shares the metadata.
+func (n *Node) RunExpiry(ctx context.Context, interval time.Duration, owns func(folder, fileName string) bool) {
+	if !n.IsHead || interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		deleted, err := n.expireFiles(ctx, owns)
+		if err != nil {
+			log.Printf("⚠️ Node %s expiry pass failed: %v", n.ID, err)
+		}
+		if deleted > 0 {
+			log.Printf("⏳ Node %s deleted %d expired files", n.ID, deleted)
+		}
+	}
+}
+
+func (n *Node) expireFiles(ctx context.Context, owns func(folder, fileName string) bool) (int, error) {
+	deleted := 0
+	after := ""
+	for {
+		expired, next, err := n.Storage.ExpiredFiles(ctx, after, expiryBatch)
+		if err != nil {
+			return deleted, err
+		}
+		for _, c := range expired {
+			if !owns(c.Folder, c.FileName) {
+				continue
+			}
+			req := &rpcpb.DeleteReq{Folder: c.Folder, FileName: c.FileName, IfExpired: true}
+			err := n.HandleDelete(ctx, req, &rpcpb.WriteAck{})
+			switch {
+			case err == nil:
+				deleted++
+			case errors.Is(err, ErrNotExpired), errors.Is(err, ErrFileNotFound):
+				// Rewritten or deleted since the query.
+			default:
+				log.Printf("⚠️ Node %s failed to expire %s/%s: %v", n.ID, c.Folder, c.FileName, err)
+			}
+		}
+		if next == "" {
+			return deleted, nil
+		}
+		after = next
+	}
+}
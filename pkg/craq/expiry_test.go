@@ -0,0 +1,83 @@
+package craq
+
+import (
+	"bytes"
+	"context"
+	"craq-cluster/gen/rpcpb"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestExpiredFilesHideAndHeadDeletesThem(t *testing.T) {
+	ctx := context.Background()
+	nodes, clients := newTestChain(t, 3)
+	head := nodes[0]
+	if err := head.HandleSetFolderTTL(ctx, "/tmp", time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, f := range []struct {
+		folder, name string
+		ttl          int64
+	}{{"/tmp", "default", 0}, {"/docs", "short", 1}, {"/docs", "keep", 0}, {"/other", "unowned", 1}} {
+		c := head.newChunker(ctx, f.folder, f.name, nil)
+		c.Write([]byte(f.name))
+		m, err := c.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		req := &rpcpb.StreamWriteReq{Folder: f.folder, FileName: f.name, Manifest: m, TtlSeconds: f.ttl}
+		if err := head.HandleWrite(ctx, req, &rpcpb.WriteAck{}); err != nil {
+			t.Fatalf("write %s/%s: %v", f.folder, f.name, err)
+		}
+	}
+
+	// Expiry has whole-second resolution, so wait on the tail for the
+	// file written last.
+	deadline := time.Now().Add(3 * time.Second)
+	for {
+		_, err := tryReadFile(clients[2], "/other", "unowned")
+		if status.Code(err) == codes.NotFound {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("file with a 1s TTL still read after 3s: %v", err)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	// Expired files are hidden everywhere before any delete is issued.
+	for i, client := range clients {
+		for _, f := range [][2]string{{"/tmp", "default"}, {"/docs", "short"}, {"/other", "unowned"}} {
+			if _, err := tryReadFile(client, f[0], f[1]); status.Code(err) != codes.NotFound {
+				t.Fatalf("%s: read of expired %s/%s: %v, want NotFound", nodes[i].ID, f[0], f[1], err)
+			}
+		}
+		if got := readFile(t, client, "/docs", "keep"); !bytes.Equal(got, []byte("keep")) {
+			t.Fatalf("%s: file without a TTL reads %q", nodes[i].ID, got)
+		}
+	}
+
+	owns := func(folder, _ string) bool { return folder != "/other" }
+	deleted, err := head.expireFiles(ctx, owns)
+	if err != nil || deleted != 2 {
+		t.Fatalf("expiry pass deleted %d files, %v; want the 2 this chain owns", deleted, err)
+	}
+	for _, n := range nodes {
+		for _, f := range [][2]string{{"/tmp", "default"}, {"/docs", "short"}} {
+			c, err := n.Storage.GetLatest(ctx, f[0], f[1])
+			if err != nil || !c.Deleted || c.Seq != 2 {
+				t.Fatalf("%s: %s/%s after expiry: %+v, %v; want a tombstone at seq 2", n.ID, f[0], f[1], c, err)
+			}
+		}
+		if c, err := n.Storage.GetLatest(ctx, "/other", "unowned"); err != nil || c.Deleted {
+			t.Fatalf("%s: file of another chain deleted: %+v, %v", n.ID, c, err)
+		}
+	}
+	if deleted, err := head.expireFiles(ctx, owns); err != nil || deleted != 0 {
+		t.Fatalf("second pass deleted %d, %v; want nothing left", deleted, err)
+	}
+}
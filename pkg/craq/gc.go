@@ -0,0 +1,204 @@
+package craq
+
+import (
+	"context"
+	"craq-cluster/pkg/storage"
+	"fmt"
+	"log"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GCPolicy controls the background collector that reclaims manifests of
+// superseded versions and the content chunks no manifest references.
+type GCPolicy struct {
+	Interval     time.Duration // time between passes; 0 disables collection
+	KeepVersions int           // newest versions kept per live file, at least 1
+	// Grace is how long a version is kept after it was superseded or
+	// tombstoned, and how old an unreferenced chunk must be, so reads and
+	// writes in flight when a pass starts can finish.
+	Grace time.Duration
+}
+
+// GCStats counts what one collection pass removed.
+type GCStats struct {
+	Manifests int
+	Chunks    int
+	Bytes     int64
+}
+
+// RunGC collects garbage every p.Interval until ctx is done.
+func (n *Node) RunGC(ctx context.Context, p GCPolicy) {
+	if p.Interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(p.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		stats, err := n.CollectGarbage(ctx, p)
+		if err != nil {
+			log.Printf("⚠️ Node %s GC pass failed: %v", n.ID, err)
+			continue
+		}
+		if stats.Manifests > 0 || stats.Chunks > 0 {
+			chunks, refs := n.refs.stats()
+			log.Printf("🧹 Node %s GC removed %d manifests, %d chunks (%d bytes); %d chunks left for %d references",
+				n.ID, stats.Manifests, stats.Chunks, stats.Bytes, chunks, refs)
+		}
+	}
+}
+
+// CollectGarbage runs one pass: it drops the manifests of versions the
+// policy no longer keeps, releasing their chunk references, then sweeps
+// chunks no remaining manifest references. Versions pinned by a snapshot
+// are always kept, and a chunk shared with another file or version stays
+// as long as that one does.
+func (n *Node) CollectGarbage(ctx context.Context, p GCPolicy) (GCStats, error) {
+	var stats GCStats
+	if p.KeepVersions < 1 {
+		p.KeepVersions = 1
+	}
+	if err := n.LoadRefs(ctx); err != nil {
+		return stats, err
+	}
+
+	files, err := n.listManifests(ctx)
+	if err != nil {
+		return stats, err
+	}
+
+	for f, seqs := range files {
+		keep, err := n.versionsToKeep(ctx, f.folder, f.fileName, seqs, p)
+		if err != nil {
+			return stats, fmt.Errorf("%s/%s: %w", f.folder, f.fileName, err)
+		}
+
+		for _, seq := range seqs {
+			if keep[seq] {
+				continue
+			}
+			key := manifestKey(f.folder, f.fileName, seq)
+			if n.removeBlob(ctx, key, &stats.Bytes) {
+				n.refs.remove(key)
+				n.removeBlob(ctx, archivedKey(f.folder, f.fileName, seq), &stats.Bytes)
+				stats.Manifests++
+			}
+		}
+	}
+
+	// Shards of erasure-coded versions owned by other nodes are never
+	// counted: they were only held to forward them, and go once the grace
+	// is up.
+	cutoff := time.Now().Add(-p.Grace)
+	err = n.Blobs.List(ctx, "chunks/", func(info storage.BlobInfo) error {
+		if n.refs.refs(path.Base(info.Key)) > 0 || info.ModTime.After(cutoff) {
+			return nil // referenced, or written or reused recently
+		}
+		if n.removeBlob(ctx, info.Key, &stats.Bytes) {
+			stats.Chunks++
+		}
+		return nil
+	})
+	return stats, err
+}
+
+// versionsToKeep decides which stored versions of one file survive. The
+// metadata is read before the snapshot pins so a snapshot taken before the
+// version was superseded is always seen.
+func (n *Node) versionsToKeep(ctx context.Context, folder, fileName string, seqs []uint64, p GCPolicy) (map[uint64]bool, error) {
+	latest, found, err := n.latestVersion(ctx, folder, fileName)
+	if err != nil {
+		return nil, err
+	}
+	pins, err := n.Storage.SnapshotSeqs(ctx, folder, fileName)
+	if err != nil {
+		return nil, err
+	}
+
+	keep := make(map[uint64]bool, len(seqs))
+	for _, seq := range pins {
+		keep[seq] = true
+	}
+
+	sort.Slice(seqs, func(i, j int) bool { return seqs[i] < seqs[j] })
+	cutoff := time.Now().Add(-p.Grace)
+	for i, seq := range seqs {
+		switch {
+		case !found:
+			// No metadata yet: a write whose Put hasn't landed.
+			keep[seq] = true
+		case latest.Deleted:
+			keep[seq] = keep[seq] || latest.ModifiedAt.After(cutoff)
+		case seq+uint64(p.KeepVersions) > latest.CleanSeq:
+			// Within the retention window, or newer than the committed
+			// version and so still in flight.
+			keep[seq] = true
+		default:
+			// Superseded: keep it for a grace period after the next
+			// version was written, so reads that resolved it can finish.
+			keep[seq] = keep[seq] || i+1 == len(seqs) || n.writtenAfter(ctx, manifestKey(folder, fileName, seqs[i+1]), cutoff)
+		}
+	}
+	return keep, nil
+}
+
+// writtenAfter reports whether the blob at key was modified after t. A
+// blob that can't be checked counts as recent.
+func (n *Node) writtenAfter(ctx context.Context, key string, t time.Time) bool {
+	info, err := n.Blobs.Stat(ctx, key)
+	return err != nil || info.ModTime.After(t)
+}
+
+// fileKey names a file by its folder and name.
+type fileKey struct {
+	folder, fileName string
+}
+
+// listManifests returns the stored seqs of every file.
+func (n *Node) listManifests(ctx context.Context) (map[fileKey][]uint64, error) {
+	files := make(map[fileKey][]uint64)
+	err := n.Blobs.List(ctx, "manifests/", func(info storage.BlobInfo) error {
+		if f, seq, ok := parseManifestKey(info.Key); ok {
+			files[f] = append(files[f], seq)
+		}
+		return nil
+	})
+	return files, err
+}
+
+// parseManifestKey is the inverse of manifestKey.
+func parseManifestKey(key string) (fileKey, uint64, bool) {
+	rel := strings.TrimPrefix(key, "manifests/")
+	name := path.Base(rel)
+	seq, err := strconv.ParseUint(strings.TrimSuffix(name, ".manifest"), 10, 64)
+	if err != nil || !strings.HasSuffix(name, ".manifest") {
+		return fileKey{}, 0, false
+	}
+	dir := path.Dir(rel)
+	return fileKey{storage.CleanDir(path.Dir(dir)), path.Base(dir)}, seq, true
+}
+
+// removeBlob deletes the blob at key, adding its size to bytes, and
+// reports whether it was removed.
+func (n *Node) removeBlob(ctx context.Context, key string, bytes *int64) bool {
+	info, err := n.Blobs.Stat(ctx, key)
+	if err != nil {
+		return false
+	}
+	if err := n.Blobs.Delete(ctx, key); err != nil {
+		log.Printf("⚠️ GC failed to remove %s: %v", key, err)
+		return false
+	}
+	*bytes += info.Size
+	return true
+}
@@ -0,0 +1,145 @@
+package craq
+
+import (
+	"context"
+	"craq-cluster/gen/rpcpb"
+	"craq-cluster/pkg/storage"
+	"testing"
+	"time"
+)
+
+// writeRefs writes folder/fileName as the chunks ids, already held by n,
+// the way a client sending chunk references does.
+func writeRefs(ctx context.Context, n *Node, folder, fileName string, ids ...string) error {
+	c := n.newChunker(ctx, folder, fileName, nil)
+	for _, id := range ids {
+		if err := c.WriteRef(id); err != nil {
+			return err
+		}
+	}
+	m, err := c.Close()
+	if err != nil {
+		return err
+	}
+	return n.HandleWrite(ctx, &rpcpb.StreamWriteReq{Folder: folder, FileName: fileName, Manifest: m}, &rpcpb.WriteAck{})
+}
+
+func hasBlob(ctx context.Context, n *Node, key string) bool {
+	_, err := n.Blobs.Stat(ctx, key)
+	return err == nil
+}
+
+func TestGCKeepsSharedAndPinnedChunks(t *testing.T) {
+	ctx := context.Background()
+	n := newSoloNode(storage.NewMemStore())
+	policy := GCPolicy{KeepVersions: 1}
+	shared, newer, dropped, kept := testData(1, ContentChunkSize), testData(2, 100), testData(3, 100), testData(4, 100)
+
+	// a@1 and b@1 share one chunk, b sent by reference.
+	if _, err := writeFile(ctx, n, "/docs", "a", shared); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeRefs(ctx, n, "/docs", "b", sha256Hex(shared)); err != nil {
+		t.Fatalf("write by reference: %v", err)
+	}
+	if _, err := n.Storage.CreateSnapshot(ctx, "s1", "/docs"); err != nil {
+		t.Fatal(err)
+	}
+	for _, w := range []struct {
+		name string
+		data []byte
+	}{{"a", newer}, {"c", dropped}, {"c", kept}} {
+		if _, err := writeFile(ctx, n, "/docs", w.name, w.data); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	stats, err := n.CollectGarbage(ctx, policy)
+	if err != nil {
+		t.Fatalf("gc: %v", err)
+	}
+	if stats.Manifests != 1 || stats.Chunks != 1 {
+		t.Fatalf("gc removed %d manifests, %d chunks; want c@1 and its chunk", stats.Manifests, stats.Chunks)
+	}
+	for key, want := range map[string]bool{
+		manifestKey("/docs", "a", 1): true, // pinned by s1
+		manifestKey("/docs", "c", 1): false,
+		chunkKey(sha256Hex(shared)):  true,
+		chunkKey(sha256Hex(newer)):   true,
+		chunkKey(sha256Hex(dropped)): false,
+		chunkKey(sha256Hex(kept)):    true,
+		manifestKey("/docs", "b", 1): true,
+		manifestKey("/docs", "c", 2): true,
+		manifestKey("/docs", "a", 2): true,
+	} {
+		if hasBlob(ctx, n, key) != want {
+			t.Fatalf("after gc, %s present = %v, want %v", key, !want, want)
+		}
+	}
+	if refs := n.refs.refs(sha256Hex(shared)); refs != 2 {
+		t.Fatalf("shared chunk has %d references, want 2", refs)
+	}
+
+	// Unpinned, a@1 goes; the shared chunk stays while b@1 needs it.
+	if err := n.Storage.DeleteSnapshot(ctx, "s1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := n.CollectGarbage(ctx, policy); err != nil {
+		t.Fatal(err)
+	}
+	if hasBlob(ctx, n, manifestKey("/docs", "a", 1)) || !hasBlob(ctx, n, chunkKey(sha256Hex(shared))) {
+		t.Fatalf("after unpinning: a@1 should go, the chunk b@1 shares stay")
+	}
+
+	// Once b moves on too, nothing references the chunk.
+	if _, err := writeFile(ctx, n, "/docs", "b", newer); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := n.CollectGarbage(ctx, policy); err != nil {
+		t.Fatal(err)
+	}
+	if hasBlob(ctx, n, chunkKey(sha256Hex(shared))) {
+		t.Fatalf("unreferenced shared chunk survived gc")
+	}
+	if refs := n.refs.refs(sha256Hex(newer)); refs != 2 {
+		t.Fatalf("chunk of a@2 and b@2 has %d references, want 2", refs)
+	}
+}
+
+func TestTieringDoesNotExtendGCGrace(t *testing.T) {
+	ctx := context.Background()
+	tiers := storage.NewTieredBlobStore(storage.NewMemBlobStore(), storage.NewMemBlobStore())
+	n := NewNode("solo", true, true, storage.NewMemStore(), tiers, nil, nil)
+	data := [][]byte{testData(1, 100), testData(2, 100), testData(3, 100)}
+	for _, d := range data {
+		if _, err := writeFile(ctx, n, "/docs", "a", d); err != nil {
+			t.Fatal(err)
+		}
+	}
+	grace := 20 * time.Millisecond
+	time.Sleep(2 * grace)
+
+	// a@1 and a@2 are superseded and go cold; a@3, just read, stays hot.
+	n.access.note("/docs", "a", time.Now())
+	stats, err := n.ArchiveCold(ctx, TierPolicy{IdleAfter: grace})
+	if err != nil || stats.Versions != 2 || stats.Chunks != 2 {
+		t.Fatalf("archive: %+v, %v; want a@1 and a@2 with their chunks", stats, err)
+	}
+	m, err := n.loadManifest(ctx, "/docs", "a", 2)
+	if err != nil || !n.archived(ctx, m) {
+		t.Fatalf("a@2 not marked archived: %v", err)
+	}
+	// Reading a@2 brings its chunk back without touching its manifest.
+	n.noteRead(ctx, m)
+	if hot, err := tiers.IsHot(ctx, chunkKey(sha256Hex(data[1]))); err != nil || !hot || n.archived(ctx, m) {
+		t.Fatalf("after read, a@2 chunk hot = %v (%v), archived = %v", hot, err, n.archived(ctx, m))
+	}
+
+	gc, err := n.CollectGarbage(ctx, GCPolicy{KeepVersions: 1, Grace: grace})
+	if err != nil || gc.Manifests != 2 {
+		t.Fatalf("gc removed %d manifests, %v; want a@1 and a@2, superseded past their grace", gc.Manifests, err)
+	}
+	if hasBlob(ctx, n, archivedKey("/docs", "a", 1)) {
+		t.Fatalf("archive mark of collected a@1 left behind")
+	}
+}
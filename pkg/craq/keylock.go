@@ -0,0 +1,45 @@
+package craq
+
+import "sync"
+
+// keyLocks hands out one mutex per file key. The head holds a key's lock
+// while a version of it travels down the chain, so versions of one file
+// reach every replica in seq order while different files proceed in
+// parallel.
+type keyLocks struct {
+	mu    sync.Mutex
+	locks map[string]*keyLock
+}
+
+type keyLock struct {
+	sync.Mutex
+	refs int
+}
+
+// lock blocks until the key folder/fileName is free and returns the
+// function that releases it.
+func (k *keyLocks) lock(folder, fileName string) func() {
+	key := folder + "\x00" + fileName
+
+	k.mu.Lock()
+	if k.locks == nil {
+		k.locks = make(map[string]*keyLock)
+	}
+	l, ok := k.locks[key]
+	if !ok {
+		l = &keyLock{}
+		k.locks[key] = l
+	}
+	l.refs++
+	k.mu.Unlock()
+
+	l.Lock()
+	return func() {
+		l.Unlock()
+		k.mu.Lock()
+		if l.refs--; l.refs == 0 {
+			delete(k.locks, key)
+		}
+		k.mu.Unlock()
+	}
+}
@@ -0,0 +1,304 @@
+package craq
+
+import (
+	"context"
+	"craq-cluster/gen/rpcpb"
+	"craq-cluster/pkg/storage"
+	"crypto/sha256"
+	"encoding"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"path"
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// ContentChunkSize is the fixed size files are split into. Only the last
+// chunk of a file may be shorter.
+const ContentChunkSize = 1 << 20 // 1 MiB
+
+// Limits on user-defined attributes, which travel in every manifest and
+// in read response headers.
+const (
+	maxAttributes     = 64
+	maxAttributeKey   = 128
+	maxAttributeValue = 1024
+)
+
+// chunkKey returns the blob key of a content chunk. Chunks are addressed
+// by the hex SHA-256 of their bytes, so identical content is stored once
+// per node.
+func chunkKey(id string) string {
+	return "chunks/" + id[:2] + "/" + id
+}
+
+// manifestKey returns the blob key of the manifest for one version of a
+// file.
+func manifestKey(folder, fileName string, seq uint64) string {
+	return path.Join("manifests", folder, fileName, fmt.Sprintf("%d.manifest", seq))
+}
+
+// validChunkID reports whether id looks like a hex SHA-256, so ids coming
+// from peers can't be used to escape the chunk directory.
+func validChunkID(id string) bool {
+	if len(id) != sha256.Size*2 {
+		return false
+	}
+	_, err := hex.DecodeString(id)
+	return err == nil
+}
+
+func (n *Node) hasChunk(ctx context.Context, id string) bool {
+	_, err := n.Blobs.Stat(ctx, chunkKey(id))
+	return err == nil
+}
+
+// touchChunk refreshes the mtime of a chunk the node holds, so a new
+// reference to it counts as recent use, and reports whether it exists.
+func (n *Node) touchChunk(ctx context.Context, id string) bool {
+	return n.Blobs.Touch(ctx, chunkKey(id)) == nil
+}
+
+// storeChunk writes data under its content id unless the node already
+// holds it. The blob store publishes it whole, so a crash never leaves a
+// truncated chunk under a valid id.
+func (n *Node) storeChunk(ctx context.Context, data []byte) (*rpcpb.ChunkRef, error) {
+	sum := sha256.Sum256(data)
+	ref := &rpcpb.ChunkRef{Id: hex.EncodeToString(sum[:]), Size: uint64(len(data))}
+
+	if n.touchChunk(ctx, ref.Id) {
+		return ref, nil
+	}
+	if err := storage.WriteBlob(ctx, n.Blobs, chunkKey(ref.Id), data); err != nil {
+		return nil, fmt.Errorf("store chunk %s: %w", ref.Id, err)
+	}
+	return ref, nil
+}
+
+// storeChunkAs stores data received from a peer, rejecting it if the bytes
+// don't hash to the id the peer claimed.
+func (n *Node) storeChunkAs(ctx context.Context, id string, data []byte) error {
+	if !validChunkID(id) {
+		return fmt.Errorf("invalid chunk id %q", id)
+	}
+	ref, err := n.storeChunk(ctx, data)
+	if err != nil {
+		return err
+	}
+	if ref.Id != id {
+		n.Blobs.Delete(ctx, chunkKey(ref.Id))
+		return fmt.Errorf("chunk %s: content hashes to %s", id, ref.Id)
+	}
+	return nil
+}
+
+// readChunk returns the bytes of a chunk the node holds.
+func (n *Node) readChunk(ctx context.Context, id string) ([]byte, error) {
+	data, err := storage.ReadBlob(ctx, n.Blobs, chunkKey(id))
+	if err != nil {
+		return nil, fmt.Errorf("read chunk %s: %w", id, err)
+	}
+	return data, nil
+}
+
+// saveManifest stores m, counts its chunk references and returns its
+// blob key.
+func (n *Node) saveManifest(ctx context.Context, m *rpcpb.Manifest) (string, error) {
+	b, err := proto.Marshal(m)
+	if err != nil {
+		return "", err
+	}
+	key := manifestKey(m.Folder, m.FileName, m.Seq)
+	if err := storage.WriteBlob(ctx, n.Blobs, key, b); err != nil {
+		return "", fmt.Errorf("save manifest: %w", err)
+	}
+	n.indexManifest(key, m)
+	return key, nil
+}
+
+// loadManifest reads the manifest of one version of a file. A missing
+// manifest is storage.ErrNotFound.
+func (n *Node) loadManifest(ctx context.Context, folder, fileName string, seq uint64) (*rpcpb.Manifest, error) {
+	key := manifestKey(folder, fileName, seq)
+	b, err := storage.ReadBlob(ctx, n.Blobs, key)
+	if err != nil {
+		return nil, err
+	}
+	m := &rpcpb.Manifest{}
+	if err := proto.Unmarshal(b, m); err != nil {
+		return nil, fmt.Errorf("decode manifest %s: %w", key, err)
+	}
+	return m, nil
+}
+
+func validateAttributes(attrs map[string]string) error {
+	if len(attrs) > maxAttributes {
+		return fmt.Errorf("too many attributes: %d > %d", len(attrs), maxAttributes)
+	}
+	for k, v := range attrs {
+		if k == "" || len(k) > maxAttributeKey || strings.Contains(k, "=") {
+			return fmt.Errorf("invalid attribute key %q", k)
+		}
+		if len(v) > maxAttributeValue {
+			return fmt.Errorf("attribute %q: value longer than %d bytes", k, maxAttributeValue)
+		}
+	}
+	return nil
+}
+
+// chunker splits a byte stream into fixed-size content chunks as it is
+// written and builds the manifest for it.
+type chunker struct {
+	ctx      context.Context
+	node     *Node
+	buf      []byte
+	fileHash hash.Hash
+	manifest *rpcpb.Manifest
+}
+
+func (n *Node) newChunker(ctx context.Context, folder, fileName string, attrs map[string]string) *chunker {
+	return &chunker{
+		ctx:      ctx,
+		node:     n,
+		buf:      make([]byte, 0, ContentChunkSize),
+		fileHash: sha256.New(),
+		manifest: &rpcpb.Manifest{
+			Folder:     folder,
+			FileName:   fileName,
+			ChunkSize:  ContentChunkSize,
+			Attributes: attrs,
+			Erasure:    n.erasureFor(folder),
+		},
+	}
+}
+
+func (c *chunker) Write(p []byte) (int, error) {
+	written := len(p)
+	c.fileHash.Write(p)
+	for len(p) > 0 {
+		n := copy(c.buf[len(c.buf):cap(c.buf)], p)
+		c.buf = c.buf[:len(c.buf)+n]
+		p = p[n:]
+		if len(c.buf) == cap(c.buf) {
+			if err := c.flush(); err != nil {
+				return 0, err
+			}
+		}
+	}
+	return written, nil
+}
+
+// WriteRef adds a content chunk the node already holds without its bytes
+// being sent again, as if they had been written. It must start at a chunk
+// boundary, and fails if the node doesn't hold the chunk. The chunk is
+// read back locally so the file hash covers it. Any chunk on the node may
+// be referenced, whichever folder it came from: see Deduplication in the
+// README.
+func (c *chunker) WriteRef(id string) error {
+	if len(c.buf) != 0 {
+		return fmt.Errorf("chunk %s not at a chunk boundary", id)
+	}
+	if !validChunkID(id) {
+		return fmt.Errorf("invalid chunk id %q", id)
+	}
+	data, err := c.node.readChunk(c.ctx, id)
+	if err != nil {
+		return err
+	}
+	if len(data) > ContentChunkSize {
+		return fmt.Errorf("chunk %s: %d bytes, more than a chunk", id, len(data))
+	}
+	c.fileHash.Write(data)
+	if len(data) < ContentChunkSize || c.manifest.Erasure != nil {
+		// A partial chunk is buffered as its bytes would have been, and a
+		// chunk of an erasure-coded file is encoded into shards.
+		c.buf = append(c.buf, data...)
+		if len(c.buf) == cap(c.buf) {
+			return c.flush()
+		}
+		return nil
+	}
+	c.node.touchChunk(c.ctx, id)
+	c.manifest.Chunks = append(c.manifest.Chunks, &rpcpb.ChunkRef{Id: id, Size: uint64(len(data))})
+	c.manifest.Size += uint64(len(data))
+	return nil
+}
+
+func (c *chunker) flush() error {
+	if len(c.buf) == 0 {
+		return nil
+	}
+	var ref *rpcpb.ChunkRef
+	var err error
+	if c.manifest.Erasure != nil {
+		ref, err = c.node.storeErasureChunk(c.ctx, c.buf, c.manifest.Erasure)
+	} else {
+		ref, err = c.node.storeChunk(c.ctx, c.buf)
+	}
+	if err != nil {
+		return err
+	}
+	c.manifest.Chunks = append(c.manifest.Chunks, ref)
+	c.manifest.Size += ref.Size
+	c.buf = c.buf[:0]
+	return nil
+}
+
+// Close stores the trailing partial chunk and returns the finished
+// manifest. The caller assigns its seq.
+func (c *chunker) Close() (*rpcpb.Manifest, error) {
+	if err := c.flush(); err != nil {
+		return nil, err
+	}
+	c.manifest.Checksum = hex.EncodeToString(c.fileHash.Sum(nil))
+	state, err := c.fileHash.(encoding.BinaryMarshaler).MarshalBinary()
+	if err != nil {
+		return nil, fmt.Errorf("save hash state: %w", err)
+	}
+	c.manifest.HashState = state
+	return c.manifest, nil
+}
+
+// newAppendChunker returns a chunker that continues the file described by
+// base. Full chunks are kept as they are; a trailing partial chunk is
+// loaded back into the buffer so appended bytes fill it up, and the file
+// hash resumes from the state saved in base.
+func (n *Node) newAppendChunker(ctx context.Context, base *rpcpb.Manifest) (*chunker, error) {
+	c := n.newChunker(ctx, base.Folder, base.FileName, base.Attributes)
+	c.manifest.Chunks = append(c.manifest.Chunks, base.Chunks...)
+	c.manifest.Size = base.Size
+	c.manifest.ExpiresAt = base.ExpiresAt
+	if base.Seq > 0 {
+		// Appends keep the layout the file was written with.
+		c.manifest.Erasure = base.Erasure
+	}
+
+	if len(base.HashState) > 0 {
+		if err := c.fileHash.(encoding.BinaryUnmarshaler).UnmarshalBinary(base.HashState); err != nil {
+			return nil, fmt.Errorf("restore hash state: %w", err)
+		}
+	} else {
+		// Manifests written before hash states were saved: rehash.
+		for _, ref := range base.Chunks {
+			data, err := n.readChunkRef(ctx, ref)
+			if err != nil {
+				return nil, err
+			}
+			c.fileHash.Write(data)
+		}
+	}
+
+	if k := len(c.manifest.Chunks); k > 0 && c.manifest.Chunks[k-1].Size < ContentChunkSize {
+		last := c.manifest.Chunks[k-1]
+		data, err := n.readChunkRef(ctx, last)
+		if err != nil {
+			return nil, err
+		}
+		c.buf = append(c.buf, data...)
+		c.manifest.Chunks = c.manifest.Chunks[:k-1]
+		c.manifest.Size -= last.Size
+	}
+	return c, nil
+}
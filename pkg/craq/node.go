@@ -3,59 +3,119 @@ package craq
 import (
 	"context"
 	"craq-cluster/gen/rpcpb"
+	"craq-cluster/pkg/compression"
 	"craq-cluster/pkg/storage"
+	"errors"
 	"fmt"
 	"io"
 	"log"
-	"os"
+	"path"
 	"sync"
+	"time"
 )
 
 type Node struct {
 	ID      string
 	IsHead  bool
 	IsTail  bool
-	Mutex   sync.Mutex
 	Storage storage.StorageClient
+	Blobs   storage.BlobStore
 	Prev    rpcpb.NodeClient
 	Next    rpcpb.NodeClient
+
+	// ChainPos is this node's position in its chain, the head being 0,
+	// and Chain the chain's members by position, nil for this node. They
+	// place and gather the shards of erasure-coded files.
+	ChainPos int
+	Chain    []rpcpb.NodeClient
+	// Erasure maps folders to the erasure code new files under them are
+	// written with. Set before serving.
+	Erasure map[string]ErasurePolicy
+	// Compression maps folders to the wire compression versions under them
+	// are forwarded and read with, "gzip", "zstd" or "none". Set before
+	// serving.
+	Compression map[string]string
+	// Owns reports whether this node's chain is responsible for a file.
+	// The head rejects a batch naming any file it doesn't own; nil owns
+	// every file. Set before serving.
+	Owns func(folder, fileName string) bool
+
+	events  notifier
+	keys    keyLocks
+	wal     *walFile  // nil until RecoverWAL
+	access  accessLog // last read of each file, for tiering
+	refs    chunkRefs // manifests referencing each owned chunk
+	started time.Time
+
+	// quotaMu guards quotaHeld, the growth the head has booked against
+	// each quota folder for writes checked but not yet stored.
+	quotaMu   sync.Mutex
+	quotaHeld map[string]*quotaHold
 }
 
-func NewNode(id string, isHead, isTail bool, store storage.StorageClient, prev, next rpcpb.NodeClient) *Node {
+func NewNode(id string, isHead, isTail bool, store storage.StorageClient, blobs storage.BlobStore, prev, next rpcpb.NodeClient) *Node {
 	return &Node{
 		ID:      id,
 		IsHead:  isHead,
 		IsTail:  isTail,
 		Storage: store,
+		Blobs:   blobs,
 		Prev:    prev,
 		Next:    next,
+		started: time.Now(),
 	}
 }
 
-func (n *Node) HandleWrite(req *rpcpb.StreamWriteReq, ack *rpcpb.WriteAck) error {
+func (n *Node) HandleWrite(ctx context.Context, req *rpcpb.StreamWriteReq, ack *rpcpb.WriteAck) error {
 	if n.IsHead {
-		latest, found := n.Storage.GetLatest(req.Folder, req.FileName)
-		if found {
-			req.Seq = latest.Seq + 1
-		} else {
-			req.Seq = 1
+		// Hold the key until the tail acks so a later append to this file
+		// can't reach a replica before the version it extends.
+		unlock := n.keys.lock(req.Folder, req.FileName)
+		defer unlock()
+		if err := n.assignSeq(ctx, req); err != nil {
+			return err
+		}
+		if err := n.assignExpiry(ctx, req); err != nil {
+			return err
 		}
 	}
-	n.Mutex.Lock()
 
-	// Store as dirty version locally
-	if err := n.Storage.Put(req.Seq, req.FileName, req.Folder, req.Path); err != nil {
-		n.Mutex.Unlock()
-		return fmt.Errorf("Storage Put failed: %w", err)
+	releaseQuota := func() {}
+	if n.IsHead && req.Manifest != nil {
+		var err error
+		releaseQuota, err = n.reserveQuota(ctx, []quotaWrite{{req.Folder, req.FileName, req.Manifest.Size}})
+		if err != nil {
+			return err
+		}
 	}
 
-	n.Mutex.Unlock()
+	chunk, err := n.prepareVersion(ctx, req)
+	if err != nil {
+		releaseQuota()
+		return err
+	}
+
+	if err := n.logVersion(walReceived, req.Folder, req.FileName, req.Seq); err != nil {
+		releaseQuota()
+		return err
+	}
+
+	// Store as dirty version locally. The key lock orders writes to the
+	// file, so Puts to different files run concurrently and can share a
+	// group commit.
+	err = n.Storage.Put(ctx, chunk)
+	releaseQuota()
+	if err != nil {
+		return fmt.Errorf("Storage Put failed: %w", err)
+	}
 
 	if n.IsTail {
 		// Tail node: mark clean and generate ack
-		if err := n.Storage.MarkClean(req.Folder, req.FileName, req.Seq); err != nil {
+		if err := n.Storage.MarkClean(ctx, req.Folder, req.FileName, req.Seq); err != nil {
 			return fmt.Errorf("MarkClean failed at tail: %w", err)
 		}
+		n.recordCommit(ctx, req.Folder, req.FileName, req.Seq)
+		n.noteVersion(walCommitted, req.Folder, req.FileName, req.Seq)
 
 		ack.FileName = req.FileName
 		ack.Folder = req.Folder
@@ -64,17 +124,19 @@ func (n *Node) HandleWrite(req *rpcpb.StreamWriteReq, ack *rpcpb.WriteAck) error
 	}
 
 	// Not tail: forward to successor
-	nextAck, err := n.streamFileToNext(req)
+	nextAck, err := n.streamFileToNext(ctx, req)
 	if err != nil {
 		return fmt.Errorf("forward Write to successor failed: %w", err)
 	}
+	n.noteVersion(walForwarded, req.Folder, req.FileName, req.Seq)
 
-	n.Mutex.Lock()
-	if err := n.Storage.MarkClean(nextAck.Folder, nextAck.FileName, nextAck.Seq); err != nil {
-		n.Mutex.Unlock()
+	// The tail has committed, so finish here even if the client gave up.
+	ctx = context.WithoutCancel(ctx)
+	if err := n.Storage.MarkClean(ctx, nextAck.Folder, nextAck.FileName, nextAck.Seq); err != nil {
 		return fmt.Errorf("MarkClean after successor ack failed: %w", err)
 	}
-	n.Mutex.Unlock()
+	n.recordCommit(ctx, nextAck.Folder, nextAck.FileName, nextAck.Seq)
+	n.noteVersion(walCommitted, nextAck.Folder, nextAck.FileName, nextAck.Seq)
 
 	// Propagate ack upward
 	ack.FileName = nextAck.FileName
@@ -83,18 +145,75 @@ func (n *Node) HandleWrite(req *rpcpb.StreamWriteReq, ack *rpcpb.WriteAck) error
 	return nil
 }
 
-func (n *Node) HandleVersionQuery(req *rpcpb.VersionQuery, resp *rpcpb.VersionResponse) error {
+// assignSeq gives req the next seq for its key. Only the head calls it,
+// holding the key's lock.
+func (n *Node) assignSeq(ctx context.Context, req *rpcpb.StreamWriteReq) error {
+	if _, err := n.Storage.GetDir(ctx, path.Join(req.Folder, req.FileName)); err == nil {
+		return fmt.Errorf("write %s/%s: %w", req.Folder, req.FileName, ErrIsDirectory)
+	}
+
+	latest, found, err := n.latestVersion(ctx, req.Folder, req.FileName)
+	if err != nil {
+		return err
+	}
+	if found {
+		req.Seq = latest.Seq + 1
+	} else {
+		req.Seq = 1
+	}
+	return nil
+}
+
+// latestVersion returns the newest stored version of a file, with found
+// false rather than an error if there is none.
+func (n *Node) latestVersion(ctx context.Context, folder, fileName string) (storage.Chunk, bool, error) {
+	c, err := n.Storage.GetLatest(ctx, folder, fileName)
+	if errors.Is(err, storage.ErrNotFound) {
+		return storage.Chunk{}, false, nil
+	}
+	if err != nil {
+		return storage.Chunk{}, false, fmt.Errorf("look up %s/%s: %w", folder, fileName, err)
+	}
+	return c, true, nil
+}
+
+// prepareVersion saves the manifest of a seq-stamped write and returns the
+// metadata to store for it.
+func (n *Node) prepareVersion(ctx context.Context, req *rpcpb.StreamWriteReq) (storage.Chunk, error) {
+	if req.Manifest == nil {
+		return storage.Chunk{}, fmt.Errorf("write for Folder %s File %s carries no manifest", req.Folder, req.FileName)
+	}
+	req.Manifest.Seq = req.Seq
+	req.Manifest.ArchivedAt = 0 // every node starts a version hot
+	var err error
+	if req.Path, err = n.saveManifest(ctx, req.Manifest); err != nil {
+		return storage.Chunk{}, err
+	}
+
+	c := storage.Chunk{
+		Folder:   req.Folder,
+		FileName: req.FileName,
+		Seq:      req.Seq,
+		Path:     req.Path,
+		Size:     req.Manifest.Size,
+		Checksum: req.Manifest.Checksum,
+	}
+	if req.Manifest.ExpiresAt > 0 {
+		c.ExpiresAt = time.Unix(req.Manifest.ExpiresAt, 0)
+	}
+	return c, nil
+}
+
+func (n *Node) HandleVersionQuery(ctx context.Context, req *rpcpb.VersionQuery, resp *rpcpb.VersionResponse) error {
 	if !n.IsTail {
 		return fmt.Errorf("version query must be handled by tail")
 	}
 
-	n.Mutex.Lock()
-	chunk, found := n.Storage.GetLatest(req.Folder, req.FileName)
+	chunk, err := n.Storage.GetLatest(ctx, req.Folder, req.FileName)
 	log.Printf("🔍 Tail %s responding to version query for Folder %s File %s", n.ID, req.Folder, req.FileName)
-	n.Mutex.Unlock()
 
-	if !found {
-		return fmt.Errorf("Folder %s File %s not found at tail", req.Folder, req.FileName)
+	if err != nil {
+		return fmt.Errorf("version query at tail: %w", err)
 	}
 	if chunk.State != storage.Clean {
 		return fmt.Errorf("Folder %s File %s at tail is not clean yet", req.Folder, req.FileName)
@@ -107,15 +226,66 @@ func (n *Node) HandleVersionQuery(req *rpcpb.VersionQuery, resp *rpcpb.VersionRe
 	return nil
 }
 
-func (n *Node) streamFileToNext(req *rpcpb.StreamWriteReq) (*rpcpb.WriteAck, error) {
-	stream, err := n.Next.StreamWrite(context.Background())
+// streamFileToNext replicates a version to the successor. The manifest goes
+// first; after it only the content chunks the successor doesn't already
+// hold are sent, so rewriting part of a file ships just the changed chunks.
+// Of an erasure-coded version only the shards kept further down the chain
+// are sent.
+func (n *Node) streamFileToNext(ctx context.Context, req *rpcpb.StreamWriteReq) (*rpcpb.WriteAck, error) {
+	ids := n.heldChunkIDs(req.Manifest, n.ChainPos+1)
+	missing, err := n.Next.MissingChunks(ctx, &rpcpb.ChunkSet{Ids: ids})
+	if err != nil {
+		return nil, fmt.Errorf("query missing chunks failed: %w", err)
+	}
+
+	stream, err := n.Next.StreamWrite(ctx, compression.CallOptions(n.compressionFor(req.Folder, req.Compression))...)
 	if err != nil {
 		return nil, fmt.Errorf("start stream to next node failed: %w", err)
 	}
 
-	file, err := os.Open(req.Path)
+	if err := n.sendVersion(ctx, stream, req, missing.Ids); err != nil {
+		return nil, err
+	}
+
+	ack, err := stream.CloseAndRecv()
+	if err != nil {
+		return nil, fmt.Errorf("stream close failed: %w", err)
+	}
+
+	return ack, nil
+}
+
+// writeSender is the sending half of a StreamWrite or BatchWrite stream.
+type writeSender interface {
+	Send(*rpcpb.StreamWriteReq) error
+}
+
+// sendVersion sends the manifest of req followed by the listed chunks.
+func (n *Node) sendVersion(ctx context.Context, stream writeSender, req *rpcpb.StreamWriteReq, chunkIDs []string) error {
+	err := stream.Send(&rpcpb.StreamWriteReq{
+		Folder:      req.Folder,
+		Seq:         req.Seq,
+		FileName:    req.FileName,
+		Path:        req.Path,
+		Manifest:    req.Manifest,
+		Compression: req.Compression,
+	})
 	if err != nil {
-		return nil, fmt.Errorf("open file failed: %w", err)
+		return fmt.Errorf("send manifest failed: %w", err)
+	}
+
+	for _, id := range chunkIDs {
+		if err := n.sendChunk(ctx, stream, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (n *Node) sendChunk(ctx context.Context, stream writeSender, id string) error {
+	file, err := n.Blobs.Open(ctx, chunkKey(id))
+	if err != nil {
+		return fmt.Errorf("open chunk failed: %w", err)
 	}
 	defer file.Close()
 
@@ -128,25 +298,16 @@ func (n *Node) streamFileToNext(req *rpcpb.StreamWriteReq) (*rpcpb.WriteAck, err
 			break
 		}
 		if readErr != nil {
-			return nil, fmt.Errorf("read file failed: %w", readErr)
+			return fmt.Errorf("read chunk failed: %w", readErr)
 		}
 
 		err = stream.Send(&rpcpb.StreamWriteReq{
-			Folder:   req.Folder,
-			Seq:      req.Seq,
-			FileName: req.FileName,
-			Path:     req.Path,
-			Data:     buf[:nBytes],
+			ChunkId: id,
+			Data:    buf[:nBytes],
 		})
 		if err != nil {
-			return nil, fmt.Errorf("send chunk failed: %w", err)
+			return fmt.Errorf("send chunk failed: %w", err)
 		}
 	}
-
-	ack, err := stream.CloseAndRecv()
-	if err != nil {
-		return nil, fmt.Errorf("stream close failed: %w", err)
-	}
-
-	return ack, nil
+	return nil
 }
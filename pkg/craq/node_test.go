@@ -0,0 +1,348 @@
+package craq
+
+import (
+	"bytes"
+	"context"
+	"craq-cluster/gen/rpcpb"
+	"craq-cluster/pkg/storage"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// newSoloNode returns a one-node chain, head and tail at once.
+func newSoloNode(store storage.StorageClient) *Node {
+	return NewNode("solo", true, true, store, storage.NewMemBlobStore(), nil, nil)
+}
+
+// writeFile writes data to folder/fileName through n as a client stream
+// would, returning the ack.
+func writeFile(ctx context.Context, n *Node, folder, fileName string, data []byte) (*rpcpb.WriteAck, error) {
+	c := n.newChunker(ctx, folder, fileName, nil)
+	if _, err := c.Write(data); err != nil {
+		return nil, err
+	}
+	m, err := c.Close()
+	if err != nil {
+		return nil, err
+	}
+	ack := &rpcpb.WriteAck{}
+	err = n.HandleWrite(ctx, &rpcpb.StreamWriteReq{Folder: folder, FileName: fileName, Manifest: m}, ack)
+	return ack, err
+}
+
+// gatedStore holds every Put until want of them are in flight at once,
+// failing them if that doesn't happen within a second.
+type gatedStore struct {
+	storage.StorageClient
+	want     int32
+	inFlight atomic.Int32
+	all      chan struct{}
+	once     sync.Once
+}
+
+func (s *gatedStore) Put(ctx context.Context, c storage.Chunk) error {
+	if s.inFlight.Add(1) == s.want {
+		s.once.Do(func() { close(s.all) })
+	}
+	select {
+	case <-s.all:
+	case <-time.After(time.Second):
+		return errors.New("Puts ran one at a time")
+	}
+	return s.StorageClient.Put(ctx, c)
+}
+
+func TestHeadPutsDifferentFilesConcurrently(t *testing.T) {
+	ctx := context.Background()
+	const writers = 4
+	store := &gatedStore{StorageClient: storage.NewMemStore(), want: writers, all: make(chan struct{})}
+	n := newSoloNode(store)
+
+	errs := make([]error, writers)
+	var wg sync.WaitGroup
+	for i := range writers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, errs[i] = writeFile(ctx, n, "docs", fmt.Sprintf("f%d", i), []byte("hello"))
+		}()
+	}
+	wg.Wait()
+	for i, err := range errs {
+		if err != nil {
+			t.Fatalf("write f%d: %v", i, err)
+		}
+	}
+}
+
+// slowStore delays every Put, widening the window between a write's
+// quota check and its version showing in the usage.
+type slowStore struct {
+	storage.StorageClient
+}
+
+func (s slowStore) Put(ctx context.Context, c storage.Chunk) error {
+	time.Sleep(50 * time.Millisecond)
+	return s.StorageClient.Put(ctx, c)
+}
+
+func TestQuotaCountsWritesInFlight(t *testing.T) {
+	ctx := context.Background()
+	mem := storage.NewMemStore()
+	if err := mem.SetQuota(ctx, storage.Quota{Folder: "docs", MaxBytes: 150}); err != nil {
+		t.Fatal(err)
+	}
+	n := newSoloNode(slowStore{mem})
+
+	errs := make([]error, 2)
+	var wg sync.WaitGroup
+	for i := range errs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, errs[i] = writeFile(ctx, n, "docs", fmt.Sprintf("f%d", i), bytes.Repeat([]byte("x"), 100))
+		}()
+	}
+	wg.Wait()
+
+	var exceeded int
+	for _, err := range errs {
+		switch {
+		case errors.Is(err, ErrQuotaExceeded):
+			exceeded++
+		case err != nil:
+			t.Fatalf("write: %v", err)
+		}
+	}
+	if exceeded != 1 {
+		t.Fatalf("%d writes over quota, want 1: %v", exceeded, errs)
+	}
+
+	// Once the winner is stored its booking is released, so a write that
+	// fits what's left still goes through.
+	if _, err := writeFile(ctx, n, "docs", "small", bytes.Repeat([]byte("x"), 50)); err != nil {
+		t.Fatalf("write within quota: %v", err)
+	}
+}
+
+func TestBatchRejectsFilesOfAnotherChain(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemStore()
+	n := newSoloNode(store)
+	n.Owns = func(folder, fileName string) bool { return fileName != "theirs" }
+
+	var reqs []*rpcpb.StreamWriteReq
+	for _, name := range []string{"mine", "theirs"} {
+		c := n.newChunker(ctx, "docs", name, nil)
+		c.Write([]byte(name))
+		m, err := c.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		reqs = append(reqs, &rpcpb.StreamWriteReq{Folder: "docs", FileName: name, Manifest: m})
+	}
+
+	err := n.HandleBatch(ctx, reqs, &rpcpb.BatchAck{})
+	if !errors.Is(err, ErrWrongChain) {
+		t.Fatalf("HandleBatch = %v, want ErrWrongChain", err)
+	}
+	if _, err := store.GetLatest(ctx, "docs", "mine"); !errors.Is(err, storage.ErrNotFound) {
+		t.Fatalf("owned file of rejected batch stored: %v", err)
+	}
+	if status.Code(errStatus(err)) != codes.FailedPrecondition {
+		t.Fatalf("status %v, want FailedPrecondition", errStatus(err))
+	}
+}
+
+// newTestChain wires n nodes, each with its own MemStore and
+// MemBlobStore, into a chain over in-memory gRPC connections, and returns
+// them with a client for each. The head is nodes[0].
+func newTestChain(t *testing.T, n int) ([]*Node, []rpcpb.NodeClient) {
+	t.Helper()
+	nodes := make([]*Node, n)
+	clients := make([]rpcpb.NodeClient, n)
+	for i := n - 1; i >= 0; i-- {
+		var next rpcpb.NodeClient
+		if i < n-1 {
+			next = clients[i+1]
+		}
+		nodes[i] = NewNode(fmt.Sprintf("node%d", i), i == 0, i == n-1,
+			storage.NewMemStore(), storage.NewMemBlobStore(), nil, next)
+		nodes[i].ChainPos = i
+		clients[i] = serveNode(t, nodes[i])
+	}
+	for i, node := range nodes {
+		node.Chain = make([]rpcpb.NodeClient, n)
+		for j := range clients {
+			if j != i {
+				node.Chain[j] = clients[j]
+			}
+		}
+	}
+	return nodes, clients
+}
+
+// serveNode serves n over an in-memory listener and returns a client for
+// it. Both are torn down with the test.
+func serveNode(t *testing.T, n *Node, opts ...grpc.DialOption) rpcpb.NodeClient {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer()
+	rpcpb.RegisterNodeServer(srv, NewNodeServer(n))
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	opts = append(opts,
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	conn, err := grpc.NewClient("passthrough:///"+n.ID, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return rpcpb.NewNodeClient(conn)
+}
+
+func TestChainReplicatesSnapshotQuotaAndTTL(t *testing.T) {
+	ctx := context.Background()
+	nodes, _ := newTestChain(t, 3)
+	head, tail := nodes[0], nodes[2]
+	for _, n := range nodes {
+		if err := n.Storage.Mkdir(ctx, "/docs", true); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := writeFile(ctx, head, "/docs", "a", []byte("v1")); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := head.HandleCreateSnapshot(ctx, &rpcpb.SnapshotReq{Name: "s1", Folder: "/docs"}); err != nil {
+		t.Fatalf("create snapshot: %v", err)
+	}
+	if _, err := writeFile(ctx, head, "/docs", "a", []byte("v2")); err != nil {
+		t.Fatal(err)
+	}
+	if err := head.HandleSetQuota(ctx, storage.Quota{Folder: "/docs", MaxFiles: 10}); err != nil {
+		t.Fatalf("set quota: %v", err)
+	}
+	if err := head.HandleSetFolderTTL(ctx, "/docs", time.Hour); err != nil {
+		t.Fatalf("set ttl: %v", err)
+	}
+
+	for _, n := range nodes[1:] {
+		seq, err := n.Storage.SnapshotSeq(ctx, "s1", "/docs", "a")
+		if err != nil || seq != 1 {
+			t.Fatalf("%s: snapshot pins seq %d, %v; want 1", n.ID, seq, err)
+		}
+		quotas, err := n.Storage.QuotasFor(ctx, "/docs")
+		if err != nil || len(quotas) != 1 || quotas[0].MaxFiles != 10 {
+			t.Fatalf("%s: quotas %v, %v", n.ID, quotas, err)
+		}
+	}
+	if ttl, err := tail.Storage.FolderTTL(ctx, "/docs"); err != nil || ttl != time.Hour {
+		t.Fatalf("tail ttl %v, %v; want 1h", ttl, err)
+	}
+
+	if _, err := head.HandleDeleteSnapshot(ctx, &rpcpb.SnapshotReq{Name: "s1"}); err != nil {
+		t.Fatalf("delete snapshot: %v", err)
+	}
+	if _, err := tail.Storage.GetSnapshot(ctx, "s1"); !errors.Is(err, storage.ErrSnapshotNotFound) {
+		t.Fatalf("tail still has the snapshot: %v", err)
+	}
+}
+
+// testData returns size bytes that differ from chunk to chunk, seeded so
+// different files get different content.
+func testData(seed byte, size int) []byte {
+	data := make([]byte, size)
+	for i := range data {
+		data[i] = seed + byte(i%251) + byte(i/ContentChunkSize)
+	}
+	return data
+}
+
+// readFile reads the committed version of a file through client.
+func readFile(t *testing.T, client rpcpb.NodeClient, folder, fileName string) []byte {
+	t.Helper()
+	data, err := tryReadFile(client, folder, fileName)
+	if err != nil {
+		t.Fatalf("read %s/%s: %v", folder, fileName, err)
+	}
+	return data
+}
+
+func tryReadFile(client rpcpb.NodeClient, folder, fileName string) ([]byte, error) {
+	stream, err := client.StreamRead(context.Background(), &rpcpb.StreamReadReq{Folder: folder, FileName: fileName})
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	for {
+		msg, err := stream.Recv()
+		if err == io.EOF {
+			return buf.Bytes(), nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(msg.Data)
+	}
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func TestChainWriteCommitsOnEveryNode(t *testing.T) {
+	ctx := context.Background()
+	nodes, clients := newTestChain(t, 3)
+	data := testData(1, 2*ContentChunkSize+ContentChunkSize/2)
+
+	ack, err := writeFile(ctx, nodes[0], "/docs", "big", data)
+	if err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if ack.Seq != 1 {
+		t.Fatalf("acked seq %d, want 1", ack.Seq)
+	}
+
+	for i, n := range nodes {
+		c, err := n.Storage.GetLatest(ctx, "/docs", "big")
+		if err != nil {
+			t.Fatalf("%s: %v", n.ID, err)
+		}
+		if c.Seq != 1 || c.CleanSeq != 1 || c.Size != uint64(len(data)) {
+			t.Fatalf("%s: seq %d clean %d size %d; want 1, 1, %d", n.ID, c.Seq, c.CleanSeq, c.Size, len(data))
+		}
+		m, err := n.loadManifest(ctx, "/docs", "big", 1)
+		if err != nil {
+			t.Fatalf("%s: %v", n.ID, err)
+		}
+		if len(m.Chunks) != 3 || m.Checksum != sha256Hex(data) {
+			t.Fatalf("%s: %d chunks checksum %s; want 3, %s", n.ID, len(m.Chunks), m.Checksum, sha256Hex(data))
+		}
+		for _, ref := range m.Chunks {
+			if !n.hasChunk(ctx, ref.Id) {
+				t.Fatalf("%s: missing chunk %s", n.ID, ref.Id)
+			}
+		}
+		if got := readFile(t, clients[i], "/docs", "big"); !bytes.Equal(got, data) {
+			t.Fatalf("%s: read %d bytes that differ from the %d written", n.ID, len(got), len(data))
+		}
+	}
+}
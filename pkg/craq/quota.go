@@ -0,0 +1,166 @@
+package craq
+
+import (
+	"context"
+	"craq-cluster/gen/rpcpb"
+	"craq-cluster/pkg/storage"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// ErrQuotaExceeded is returned by the head for a write that would take a
+// folder tree over its quota.
+var ErrQuotaExceeded = errors.New("folder quota exceeded")
+
+// quotaWrite is one file a write is about to store, with its new size.
+type quotaWrite struct {
+	folder, fileName string
+	size             uint64
+}
+
+// HandleSetQuota sets a quota locally and forwards it down the chain, so
+// every replica enforces and reports the same limits. Setting a quota is
+// idempotent, so replicas sharing the head's metadata just set it again.
+func (n *Node) HandleSetQuota(ctx context.Context, q storage.Quota) error {
+	if err := n.Storage.SetQuota(ctx, q); err != nil {
+		return fmt.Errorf("quota %s: %w", q.Folder, err)
+	}
+	if !n.IsTail {
+		req := &rpcpb.Quota{Folder: q.Folder, MaxBytes: q.MaxBytes, MaxFiles: q.MaxFiles}
+		if _, err := n.Next.SetQuota(ctx, req); err != nil {
+			return fmt.Errorf("forward SetQuota to successor failed: %w", err)
+		}
+	}
+	return nil
+}
+
+// quotaHold is the growth of one quota folder that checked writes are
+// about to store.
+type quotaHold struct {
+	bytes, files int64
+}
+
+// reserveQuota checks writes against every quota covering their folders.
+// On success their growth is booked against those quotas until the
+// caller releases it, once the versions are stored and show in the usage,
+// so concurrent writes can't together overrun a quota they each fit.
+// The quota lock is only held to check and book, never across a Put.
+// Only the head calls it.
+func (n *Node) reserveQuota(ctx context.Context, writes []quotaWrite) (func(), error) {
+	deltas, err := n.quotaDeltas(ctx, writes)
+	if err != nil {
+		return nil, err
+	}
+	if len(deltas) == 0 {
+		return func() {}, nil
+	}
+
+	n.quotaMu.Lock()
+	defer n.quotaMu.Unlock()
+	if err := n.checkQuota(ctx, deltas); err != nil {
+		return nil, err
+	}
+	if n.quotaHeld == nil {
+		n.quotaHeld = make(map[string]*quotaHold)
+	}
+	for _, d := range deltas {
+		h, ok := n.quotaHeld[d.quota.Folder]
+		if !ok {
+			h = &quotaHold{}
+			n.quotaHeld[d.quota.Folder] = h
+		}
+		h.bytes += d.bytes
+		h.files += d.files
+	}
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			n.quotaMu.Lock()
+			defer n.quotaMu.Unlock()
+			for _, d := range deltas {
+				h := n.quotaHeld[d.quota.Folder]
+				h.bytes -= d.bytes
+				h.files -= d.files
+				if h.bytes == 0 && h.files == 0 {
+					delete(n.quotaHeld, d.quota.Folder)
+				}
+			}
+		})
+	}, nil
+}
+
+// quotaDelta is how much a set of writes grows one quota's folder tree.
+type quotaDelta struct {
+	quota        storage.Quota
+	bytes, files int64
+}
+
+// quotaDeltas sums the growth of writes under every quota covering them,
+// in the order the quotas are first met.
+func (n *Node) quotaDeltas(ctx context.Context, writes []quotaWrite) ([]*quotaDelta, error) {
+	byFolder := make(map[string]*quotaDelta)
+	var deltas []*quotaDelta
+
+	for _, w := range writes {
+		quotas, err := n.Storage.QuotasFor(ctx, w.folder)
+		if err != nil {
+			return nil, fmt.Errorf("look up quotas for %s: %w", w.folder, err)
+		}
+		if len(quotas) == 0 {
+			continue
+		}
+
+		growth, added := int64(w.size), int64(1)
+		old, found, err := n.latestVersion(ctx, w.folder, w.fileName)
+		if err != nil {
+			return nil, err
+		}
+		if found && !old.Deleted {
+			growth, added = int64(w.size)-int64(old.Size), 0
+		}
+		for _, q := range quotas {
+			d, ok := byFolder[q.Folder]
+			if !ok {
+				d = &quotaDelta{quota: q}
+				byFolder[q.Folder] = d
+				deltas = append(deltas, d)
+			}
+			d.bytes += growth
+			d.files += added
+		}
+	}
+	return deltas, nil
+}
+
+// checkQuota rejects deltas that would take stored usage, plus what other
+// writes have booked, over a quota. n.quotaMu must be held. A write whose
+// Put has landed but which hasn't released yet is counted twice, which
+// errs on the side of the quota.
+func (n *Node) checkQuota(ctx context.Context, deltas []*quotaDelta) error {
+	for _, d := range deltas {
+		folder := d.quota.Folder
+		usage, err := n.Storage.Usage(ctx, folder)
+		if err != nil {
+			return fmt.Errorf("usage of %s: %w", folder, err)
+		}
+		used, files := int64(usage.Bytes), int64(usage.Files)
+		if h := n.quotaHeld[folder]; h != nil {
+			used += h.bytes
+			files += h.files
+		}
+		var over []string
+		if q := d.quota.MaxBytes; q > 0 && d.bytes > 0 && used+d.bytes > int64(q) {
+			over = append(over, fmt.Sprintf("%d + %d bytes > %d", used, d.bytes, q))
+		}
+		if q := d.quota.MaxFiles; q > 0 && d.files > 0 && files+d.files > int64(q) {
+			over = append(over, fmt.Sprintf("%d + %d files > %d", files, d.files, q))
+		}
+		if len(over) > 0 {
+			return fmt.Errorf("%s: %s: %w", folder, strings.Join(over, ", "), ErrQuotaExceeded)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package craq
+
+import (
+	"context"
+	"craq-cluster/gen/rpcpb"
+	"craq-cluster/pkg/storage"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+)
+
+// chunkRefs counts, for every content chunk a node owns, how many of the
+// manifests it stores reference it. A chunk shared by several files or
+// versions is stored once and counted once per manifest; the collector
+// only sweeps chunks whose count has dropped to zero.
+//
+// The counts live in memory. They are rebuilt from the stored manifests
+// at startup and kept current as manifests are saved and removed. Adding
+// a manifest replaces what was counted for its key, so rewriting one or
+// indexing it twice never skews the counts.
+type chunkRefs struct {
+	mu       sync.Mutex
+	loaded   bool
+	manifest map[string][]string // manifest key -> chunk ids it references
+	count    map[string]int      // chunk id -> manifests referencing it
+}
+
+func (r *chunkRefs) add(key string, ids []string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.dropLocked(key)
+	if r.manifest == nil {
+		r.manifest = make(map[string][]string)
+		r.count = make(map[string]int)
+	}
+	// A manifest naming a chunk twice still holds one reference to it.
+	seen := make(map[string]bool, len(ids))
+	uniq := ids[:0:0]
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			uniq = append(uniq, id)
+			r.count[id]++
+		}
+	}
+	r.manifest[key] = uniq
+}
+
+func (r *chunkRefs) remove(key string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.dropLocked(key)
+}
+
+func (r *chunkRefs) dropLocked(key string) {
+	for _, id := range r.manifest[key] {
+		if r.count[id]--; r.count[id] <= 0 {
+			delete(r.count, id)
+		}
+	}
+	delete(r.manifest, key)
+}
+
+// refs returns how many stored manifests reference chunk id.
+func (r *chunkRefs) refs(id string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.count[id]
+}
+
+// stats returns the number of distinct chunks referenced and the number
+// of references to them; their difference is what deduplication saved.
+func (r *chunkRefs) stats() (chunks, refs int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, c := range r.count {
+		refs += c
+	}
+	return len(r.count), refs
+}
+
+// LoadRefs builds the chunk reference counts from the stored manifests.
+// It runs once; the collector calls it too, so it never sweeps by counts
+// that haven't been loaded.
+func (n *Node) LoadRefs(ctx context.Context) error {
+	n.refs.mu.Lock()
+	loaded := n.refs.loaded
+	n.refs.mu.Unlock()
+	if loaded {
+		return nil
+	}
+
+	files, err := n.listManifests(ctx)
+	if err != nil {
+		return fmt.Errorf("list manifests: %w", err)
+	}
+	for f, seqs := range files {
+		for _, seq := range seqs {
+			m, err := n.loadManifest(ctx, f.folder, f.fileName, seq)
+			if errors.Is(err, storage.ErrNotFound) {
+				continue // collected since the listing
+			}
+			if err != nil {
+				return fmt.Errorf("index %s/%s@%d: %w", f.folder, f.fileName, seq, err)
+			}
+			n.refs.add(manifestKey(f.folder, f.fileName, seq), n.ownedChunkIDs(m))
+		}
+	}
+
+	n.refs.mu.Lock()
+	n.refs.loaded = true
+	n.refs.mu.Unlock()
+	chunks, refs := n.refs.stats()
+	log.Printf("🔗 Node %s indexed %d chunks referenced %d times", n.ID, chunks, refs)
+	return nil
+}
+
+// indexManifest counts the references of a manifest just saved.
+func (n *Node) indexManifest(key string, m *rpcpb.Manifest) {
+	n.refs.add(key, n.ownedChunkIDs(m))
+}
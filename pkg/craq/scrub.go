@@ -0,0 +1,253 @@
+package craq
+
+import (
+	"context"
+	"craq-cluster/gen/rpcpb"
+	"craq-cluster/pkg/storage"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"time"
+)
+
+// ScrubReport counts what one scrub pass checked, found and fixed.
+type ScrubReport struct {
+	Manifests int
+	Chunks    int
+	Problems  int
+	Repaired  int
+}
+
+// scrubTimeout bounds each call to a peer while repairing.
+const scrubTimeout = 30 * time.Second
+
+// RunScrub verifies this node's blobs every interval until ctx is done,
+// repairing what it can from peers, the other nodes of the chain.
+func (n *Node) RunScrub(ctx context.Context, interval time.Duration, peers []rpcpb.NodeClient) {
+	if interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		report, err := n.Scrub(ctx, peers)
+		if err != nil {
+			log.Printf("⚠️ Node %s scrub pass failed: %v", n.ID, err)
+			continue
+		}
+		log.Printf("🩺 Node %s scrubbed %d manifests, %d chunks: %d problems, %d repaired",
+			n.ID, report.Manifests, report.Chunks, report.Problems, report.Repaired)
+	}
+}
+
+// Scrub runs one pass. For every file it holds it checks that the
+// committed version's manifest exists and agrees with the metadata, then
+// that every chunk of every stored version exists and hashes to its id
+// with the recorded size; of an erasure-coded version, only the shards
+// this node owns. Missing or corrupt blobs are fetched again from a peer,
+// or rebuilt from the other shards; problems that can't be repaired are
+// logged.
+func (n *Node) Scrub(ctx context.Context, peers []rpcpb.NodeClient) (ScrubReport, error) {
+	var report ScrubReport
+
+	files, err := n.listManifests(ctx)
+	if err != nil {
+		return report, err
+	}
+
+	checked := make(map[string]bool)
+	for f, seqs := range files {
+		folder, fileName := f.folder, f.fileName
+
+		latest, found, err := n.latestVersion(ctx, folder, fileName)
+		if err != nil {
+			return report, err
+		}
+		if found && !latest.Deleted && latest.CleanSeq > 0 {
+			if !containsSeq(seqs, latest.CleanSeq) {
+				seqs = append(seqs, latest.CleanSeq)
+			}
+			if err := n.checkCommitted(ctx, latest, peers, &report); err != nil {
+				return report, err
+			}
+		}
+
+		for _, seq := range seqs {
+			m, err := n.loadManifest(ctx, folder, fileName, seq)
+			if err != nil {
+				if !errors.Is(err, storage.ErrNotFound) {
+					n.scrubProblem(&report, "manifest %s/%s@%d unreadable: %v", folder, fileName, seq, err)
+				}
+				continue
+			}
+			report.Manifests++
+
+			for _, ref := range m.Chunks {
+				if checked[ref.Id] {
+					continue
+				}
+				checked[ref.Id] = true
+				if len(ref.Shards) > 0 {
+					n.scrubShards(ctx, ref, fmt.Sprintf("%s/%s@%d", folder, fileName, seq), &report)
+					continue
+				}
+				report.Chunks++
+
+				if err := n.verifyChunk(ctx, ref.Id, ref.Size); err != nil {
+					n.scrubProblem(&report, "chunk %s of %s/%s@%d: %v", ref.Id, folder, fileName, seq, err)
+					if n.repairChunk(ctx, ref, peers) {
+						report.Repaired++
+					}
+				}
+			}
+		}
+	}
+	return report, nil
+}
+
+// checkCommitted verifies the manifest of the committed version of a file
+// against its metadata, re-fetching it from a peer if it is missing or
+// disagrees. Size and checksum are only compared while the metadata
+// still describes that version.
+func (n *Node) checkCommitted(ctx context.Context, latest storage.Chunk, peers []rpcpb.NodeClient, report *ScrubReport) error {
+	folder, fileName, cleanSeq := latest.Folder, latest.FileName, latest.CleanSeq
+	m, err := n.loadManifest(ctx, folder, fileName, cleanSeq)
+	switch {
+	case err != nil:
+		n.scrubProblem(report, "manifest %s/%s@%d: %v", folder, fileName, cleanSeq, err)
+	case m.Seq != cleanSeq || m.Folder != folder || m.FileName != fileName:
+		n.scrubProblem(report, "manifest %s/%s@%d names %s/%s@%d", folder, fileName, cleanSeq, m.Folder, m.FileName, m.Seq)
+	case latest.Seq == cleanSeq && (m.Size != latest.Size || m.Checksum != latest.Checksum):
+		n.scrubProblem(report, "manifest %s/%s@%d: size %d checksum %s, metadata has %d %s",
+			folder, fileName, cleanSeq, m.Size, m.Checksum, latest.Size, latest.Checksum)
+	default:
+		return nil
+	}
+
+	for _, peer := range peers {
+		pctx, cancel := context.WithTimeout(ctx, scrubTimeout)
+		pm, err := peer.GetManifest(pctx, &rpcpb.StreamReadReq{Folder: folder, FileName: fileName})
+		cancel()
+		if err != nil || pm.Seq != cleanSeq {
+			continue
+		}
+		pm.ArchivedAt = 0 // the peer's tiering says nothing of this node's
+		if _, err := n.saveManifest(ctx, pm); err != nil {
+			return fmt.Errorf("save repaired manifest: %w", err)
+		}
+		log.Printf("🩹 Node %s restored manifest %s/%s@%d from a peer", n.ID, folder, fileName, cleanSeq)
+		report.Repaired++
+		return nil
+	}
+	log.Printf("⚠️ Node %s could not repair manifest %s/%s@%d", n.ID, folder, fileName, cleanSeq)
+	return nil
+}
+
+// scrubShards verifies the shards of an erasure-coded chunk this node
+// owns, rebuilding them from the other shards if any is missing or
+// corrupt.
+func (n *Node) scrubShards(ctx context.Context, ref *rpcpb.ChunkRef, version string, report *ScrubReport) {
+	bad := false
+	for i, id := range ref.Shards {
+		if n.shardOwner(i) != n.ChainPos {
+			continue
+		}
+		report.Chunks++
+		if err := n.verifyChunk(ctx, id, shardSize(ref)); err != nil {
+			n.scrubProblem(report, "shard %d of chunk %s of %s: %v", i, ref.Id, version, err)
+			bad = true
+		}
+	}
+	if bad && n.repairShards(ctx, ref) {
+		report.Repaired++
+	}
+}
+
+// verifyChunk checks that a chunk or shard exists with the recorded size
+// and that its bytes hash to its id.
+func (n *Node) verifyChunk(ctx context.Context, id string, wantSize uint64) error {
+	f, err := n.Blobs.Open(ctx, chunkKey(id))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	size, err := io.Copy(h, f)
+	if err != nil {
+		return err
+	}
+	if uint64(size) != wantSize {
+		return fmt.Errorf("size %d, want %d", size, wantSize)
+	}
+	if sum := hex.EncodeToString(h.Sum(nil)); sum != id {
+		return fmt.Errorf("content hashes to %s", sum)
+	}
+	return nil
+}
+
+// repairChunk replaces a missing or corrupt chunk with a copy from the
+// first peer whose bytes hash to the right id.
+func (n *Node) repairChunk(ctx context.Context, ref *rpcpb.ChunkRef, peers []rpcpb.NodeClient) bool {
+	for _, peer := range peers {
+		data, err := fetchPeerChunk(ctx, peer, ref)
+		if err != nil {
+			log.Printf("⚠️ Node %s fetch of chunk %s from peer failed: %v", n.ID, ref.Id, err)
+			continue
+		}
+		n.Blobs.Delete(ctx, chunkKey(ref.Id))
+		if err := n.storeChunkAs(ctx, ref.Id, data); err != nil {
+			log.Printf("⚠️ Node %s peer copy of chunk %s rejected: %v", n.ID, ref.Id, err)
+			continue
+		}
+		log.Printf("🩹 Node %s restored chunk %s from a peer", n.ID, ref.Id)
+		return true
+	}
+	log.Printf("⚠️ Node %s could not repair chunk %s", n.ID, ref.Id)
+	return false
+}
+
+func fetchPeerChunk(ctx context.Context, peer rpcpb.NodeClient, ref *rpcpb.ChunkRef) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, scrubTimeout)
+	defer cancel()
+
+	stream, err := peer.FetchChunk(ctx, &rpcpb.ChunkRef{Id: ref.Id, Size: ref.Size})
+	if err != nil {
+		return nil, err
+	}
+	data := make([]byte, 0, ref.Size)
+	for {
+		msg, err := stream.Recv()
+		if err == io.EOF {
+			return data, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, msg.Data...)
+	}
+}
+
+func (n *Node) scrubProblem(report *ScrubReport, format string, args ...any) {
+	report.Problems++
+	log.Printf("🩺 Node %s scrub: "+format, append([]any{n.ID}, args...)...)
+}
+
+func containsSeq(seqs []uint64, seq uint64) bool {
+	for _, s := range seqs {
+		if s == seq {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,64 @@
+package craq
+
+import (
+	"bytes"
+	"context"
+	"craq-cluster/gen/rpcpb"
+	"craq-cluster/pkg/storage"
+	"testing"
+)
+
+func TestScrubRepairsChunksAndManifestsFromPeers(t *testing.T) {
+	ctx := context.Background()
+	nodes, clients := newTestChain(t, 3)
+	data := testData(1, 2*ContentChunkSize)
+	for _, d := range [][]byte{[]byte("old"), data} {
+		if _, err := writeFile(ctx, nodes[0], "/docs", "big", d); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tail, peers := nodes[2], []rpcpb.NodeClient{clients[0], clients[1]}
+	m, err := tail.loadManifest(ctx, "/docs", "big", 2)
+	if err != nil || len(m.Chunks) != 2 {
+		t.Fatalf("manifest: %v, %v", m, err)
+	}
+
+	// One chunk rots; the other and the committed version's manifest go
+	// missing.
+	rotten, lost := m.Chunks[0], m.Chunks[1]
+	if err := storage.WriteBlob(ctx, tail.Blobs, chunkKey(rotten.Id), testData(9, int(rotten.Size))); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{chunkKey(lost.Id), manifestKey("/docs", "big", 2)} {
+		if err := tail.Blobs.Delete(ctx, key); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	report, err := tail.Scrub(ctx, peers)
+	if err != nil {
+		t.Fatalf("scrub: %v", err)
+	}
+	if report.Problems != 3 || report.Repaired != 3 {
+		t.Fatalf("scrub report %+v; want 3 problems, all repaired", report)
+	}
+	for _, ref := range m.Chunks {
+		if err := tail.verifyChunk(ctx, ref.Id, ref.Size); err != nil {
+			t.Fatalf("chunk %s after repair: %v", ref.Id, err)
+		}
+	}
+	if got := readFile(t, clients[2], "/docs", "big"); !bytes.Equal(got, data) {
+		t.Fatalf("repaired file reads %d bytes that differ from the %d written", len(got), len(data))
+	}
+	if report, err := tail.Scrub(ctx, peers); err != nil || report.Problems != 0 || report.Chunks != 3 {
+		t.Fatalf("second scrub: %+v, %v; want 3 clean chunks", report, err)
+	}
+
+	// Without peers that hold it, a bad chunk is reported, not repaired.
+	if err := storage.WriteBlob(ctx, tail.Blobs, chunkKey(rotten.Id), []byte("short")); err != nil {
+		t.Fatal(err)
+	}
+	if report, err := tail.Scrub(ctx, nil); err != nil || report.Problems != 1 || report.Repaired != 0 {
+		t.Fatalf("scrub without peers: %+v, %v; want 1 unrepaired problem", report, err)
+	}
+}
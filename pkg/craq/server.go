@@ -1,15 +1,26 @@
 package craq
 
 import (
+	"bytes"
 	"context"
 	"craq-cluster/gen/rpcpb"
+	"craq-cluster/pkg/compression"
+	"craq-cluster/pkg/storage"
+	"encoding/base64"
+	"errors"
+	"fmt"
 	"io"
 	"log"
-	"os"
-	"path/filepath"
+	"path"
+	"strconv"
+	"strings"
+	"time"
 
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 type NodeServer struct {
@@ -25,8 +36,8 @@ func (s *NodeServer) QueryVersion(ctx context.Context, req *rpcpb.VersionQuery)
 	internalReq := &rpcpb.VersionQuery{Folder: req.Folder, FileName: req.FileName}
 	internalResp := &rpcpb.VersionResponse{}
 
-	if err := s.node.HandleVersionQuery(internalReq, internalResp); err != nil {
-		return nil, err
+	if err := s.node.HandleVersionQuery(ctx, internalReq, internalResp); err != nil {
+		return nil, errStatus(err)
 	}
 
 	return &rpcpb.VersionResponse{
@@ -42,10 +53,12 @@ func (s *NodeServer) StreamWrite(stream rpcpb.Node_StreamWriteServer) error {
 
 	var (
 		firstReq *rpcpb.StreamWriteReq
-		tempFile *os.File
+		content  *chunker
+		replica  *chunkReceiver
 	)
 
-	// Step 1: Read stream and write to /tmp/{chunkId}
+	// Step 1: Read stream into content chunks. A client stream is split
+	// into chunks here; a stream from the predecessor already names them.
 	for {
 		req, err := stream.Recv()
 		if err == io.EOF {
@@ -58,34 +71,29 @@ func (s *NodeServer) StreamWrite(stream rpcpb.Node_StreamWriteServer) error {
 
 		if firstReq == nil {
 			firstReq = req
-
-			// Construct full file path: /tmp/{folder}/{file_name}
-			tempPath := filepath.Join("/tmp", req.Folder, req.FileName)
-			firstReq.Path = tempPath
-
-			// Ensure folder exists
-			if err := os.MkdirAll(filepath.Dir(tempPath), 0755); err != nil {
-				log.Printf("[StreamWrite] ❌ Failed to create folder: %v", err)
-				return status.Errorf(codes.Internal, "mkdir failed: %v", err)
-			}
-
-			// Create the file
-			tempFile, err = os.Create(tempPath)
-			if err != nil {
-				log.Printf("[StreamWrite] ❌ Failed to create file: %v\n", err)
-				return status.Errorf(codes.Internal, "file create failed: %v", err)
+			if req.Manifest != nil {
+				if err := s.checkReplicaStream(); err != nil {
+					return err
+				}
+				replica = s.node.newChunkReceiver(stream.Context())
+			} else {
+				if err := validateClientWrite(req); err != nil {
+					return err
+				}
+				content = s.node.newChunker(stream.Context(), req.Folder, req.FileName, req.Attributes)
 			}
 		}
 
-		_, err = tempFile.Write(req.Data)
+		if replica != nil {
+			err = replica.Write(req.ChunkId, req.Data)
+		} else {
+			err = writeClientData(content, req)
+		}
 		if err != nil {
-			log.Printf("[StreamWrite] ❌ Failed to write chunk to file: %v\n", err)
-			return status.Errorf(codes.Internal, "file write failed: %v", err)
+			log.Printf("[StreamWrite] ❌ Failed to store chunk: %v\n", err)
+			return chunkStoreStatus(err)
 		}
-		log.Printf("[StreamWrite] 📦 Wrote chunk %d bytes", len(req.Data))
-	}
-	if tempFile != nil {
-		tempFile.Close()
+		log.Printf("[StreamWrite] 📦 Received %d bytes", len(req.Data))
 	}
 
 	if firstReq == nil {
@@ -93,62 +101,255 @@ func (s *NodeServer) StreamWrite(stream rpcpb.Node_StreamWriteServer) error {
 		return status.Error(codes.InvalidArgument, "no data received")
 	}
 
+	manifest := firstReq.Manifest
+	if replica != nil {
+		if err := replica.Close(manifest); err != nil {
+			log.Printf("[StreamWrite] ❌ %v", err)
+			return status.Errorf(codes.FailedPrecondition, "incomplete chunk set: %v", err)
+		}
+	} else {
+		var err error
+		if manifest, err = content.Close(); err != nil {
+			log.Printf("[StreamWrite] ❌ Failed to store chunk: %v\n", err)
+			return status.Errorf(codes.Internal, "chunk store failed: %v", err)
+		}
+	}
+
 	// Step 2: Build internalReq and internalAck (just like Write)
 	internalReq := &rpcpb.StreamWriteReq{
-		Folder:   firstReq.Folder,
-		Seq:      firstReq.Seq,
-		FileName: firstReq.FileName,
-		Path:     firstReq.Path, // path of the reconstructed file
+		Folder:      firstReq.Folder,
+		Seq:         firstReq.Seq,
+		FileName:    firstReq.FileName,
+		Manifest:    manifest,
+		TtlSeconds:  firstReq.TtlSeconds,
+		Compression: firstReq.Compression,
 	}
 
 	internalAck := &rpcpb.WriteAck{}
 
-	if err := s.node.HandleWrite(internalReq, internalAck); err != nil {
+	if err := s.node.HandleWrite(stream.Context(), internalReq, internalAck); err != nil {
 		log.Printf("[StreamWrite] ❌ HandleWrite failed: %v\n", err)
-		return status.Errorf(codes.Internal, "HandleWrite failed: %v", err)
+		return errStatus(fmt.Errorf("HandleWrite failed: %w", err))
 	}
 
 	log.Printf("[StreamWrite] ✅ Sending final ack: Folder=%s File=%s Seq=%d", internalAck.Folder, internalAck.FileName, internalAck.Seq)
 	return stream.SendAndClose(internalAck)
 }
 
-func (s *NodeServer) StreamRead(req *rpcpb.StreamReadReq, stream rpcpb.Node_StreamReadServer) error {
-	log.Printf("[StreamRead] 📥 Received request for Folder=%s Filename=%s", req.Folder, req.FileName)
+// checkReplicaStream refuses a stream that carries manifests at the head.
+// Only a predecessor sends them, and the head has none: a client must send
+// its bytes, so they are chunked, checked and counted against quotas here.
+func (s *NodeServer) checkReplicaStream() error {
+	if s.node.IsHead {
+		return status.Error(codes.InvalidArgument, "the head takes file data, not manifests")
+	}
+	return nil
+}
 
-	// Fetch the latest metadata for the requested chunk
-	meta, found := s.node.Storage.GetLatest(req.Folder, req.FileName)
-	if !found {
-		log.Printf("[StreamRead] ❌ Folder %s File %s not found", req.Folder, req.FileName)
-		return status.Errorf(codes.NotFound, "Folder %s File % not found", req.Folder, req.FileName)
+// validateClientWrite checks the first message of a file sent by a client
+// and normalizes its folder.
+func validateClientWrite(req *rpcpb.StreamWriteReq) error {
+	if req.FileName == "" || strings.Contains(req.FileName, "/") {
+		return status.Errorf(codes.InvalidArgument, "invalid file name %q", req.FileName)
+	}
+	if err := validateAttributes(req.Attributes); err != nil {
+		return status.Errorf(codes.InvalidArgument, "%v", err)
+	}
+	if req.TtlSeconds < 0 {
+		return status.Errorf(codes.InvalidArgument, "negative ttl %d", req.TtlSeconds)
+	}
+	if err := compression.Validate(req.Compression); err != nil {
+		return status.Errorf(codes.InvalidArgument, "%v", err)
 	}
+	req.Folder = storage.CleanDir(req.Folder)
+	return nil
+}
 
-	file, err := os.Open(meta.Path)
-	if err != nil {
-		log.Printf("[StreamRead] ❌ Failed to open file %s: %v", meta.Path, err)
-		return status.Errorf(codes.Internal, "failed to open chunk file: %v", err)
+// errChunkRef marks a client message that names a chunk the node can't
+// use in place of its bytes.
+var errChunkRef = errors.New("bad chunk reference")
+
+// writeClientData adds one message of a client stream to content: its
+// bytes, or the content chunk it names by id, which the client learned
+// the node holds from MissingChunks.
+func writeClientData(content *chunker, req *rpcpb.StreamWriteReq) error {
+	if req.ChunkId == "" {
+		_, err := content.Write(req.Data)
+		return err
+	}
+	if len(req.Data) > 0 {
+		return fmt.Errorf("chunk %s sent with data: %w", req.ChunkId, errChunkRef)
+	}
+	if err := content.WriteRef(req.ChunkId); err != nil {
+		return fmt.Errorf("%w: %w", errChunkRef, err)
 	}
-	defer file.Close()
+	return nil
+}
 
-	const chunkSize = 64 * 1024 // 64 KB chunks
-	buf := make([]byte, chunkSize)
+// chunkStoreStatus maps an error storing client data to a status. A
+// referenced chunk the node doesn't hold is FailedPrecondition, so the
+// client can send its bytes instead.
+func chunkStoreStatus(err error) error {
+	switch {
+	case errors.Is(err, errChunkRef) && errors.Is(err, storage.ErrNotFound):
+		return status.Errorf(codes.FailedPrecondition, "%v", err)
+	case errors.Is(err, errChunkRef):
+		return status.Errorf(codes.InvalidArgument, "%v", err)
+	default:
+		return status.Errorf(codes.Internal, "chunk store failed: %v", err)
+	}
+}
+
+func (s *NodeServer) Append(ctx context.Context, req *rpcpb.AppendReq) (*rpcpb.AppendAck, error) {
+	log.Printf("[Append] ➕ Folder=%s File=%s %d bytes", req.Folder, req.FileName, len(req.Data))
+
+	if req.FileName == "" || strings.Contains(req.FileName, "/") {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid file name %q", req.FileName)
+	}
+	if len(req.Data) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "no data to append")
+	}
+	req.Folder = storage.CleanDir(req.Folder)
+
+	ack := &rpcpb.AppendAck{}
+	if err := s.node.HandleAppend(ctx, req, ack); err != nil {
+		log.Printf("[Append] ❌ HandleAppend failed: %v", err)
+		return nil, errStatus(fmt.Errorf("HandleAppend failed: %w", err))
+	}
+
+	log.Printf("[Append] ✅ Folder=%s File=%s Seq=%d Offset=%d", ack.Folder, ack.FileName, ack.Seq, ack.Offset)
+	return ack, nil
+}
+
+func (s *NodeServer) BatchWrite(stream rpcpb.Node_BatchWriteServer) error {
+	log.Println("[BatchWrite] ➡️ Starting to receive batch...")
+
+	var (
+		reqs    []*rpcpb.StreamWriteReq
+		content *chunker
+		replica *chunkReceiver
+		seen    = make(map[string]bool)
+	)
+
+	// finish closes the chunker of the current client file.
+	finish := func() error {
+		if content == nil {
+			return nil
+		}
+		manifest, err := content.Close()
+		if err != nil {
+			return status.Errorf(codes.Internal, "chunk store failed: %v", err)
+		}
+		reqs[len(reqs)-1].Manifest = manifest
+		return nil
+	}
 
 	for {
-		n, err := file.Read(buf)
+		req, err := stream.Recv()
 		if err == io.EOF {
 			break
 		}
 		if err != nil {
-			log.Printf("[StreamRead] ❌ Read error: %v", err)
-			return status.Errorf(codes.Internal, "read error: %v", err)
+			log.Printf("[BatchWrite] ❌ Receive error: %v\n", err)
+			return status.Errorf(codes.Internal, "failed to receive chunk: %v", err)
 		}
 
-		chunk := &rpcpb.ReadChunk{
-			Data: buf[:n],
+		if req.Manifest != nil {
+			// From the predecessor: a manifest starts the next file.
+			if err := s.checkReplicaStream(); err != nil {
+				return err
+			}
+			if replica == nil {
+				replica = s.node.newChunkReceiver(stream.Context())
+			}
+			reqs = append(reqs, &rpcpb.StreamWriteReq{
+				Folder:      req.Folder,
+				Seq:         req.Seq,
+				FileName:    req.FileName,
+				Manifest:    req.Manifest,
+				Compression: req.Compression,
+			})
+		} else if replica == nil && req.FileName != "" &&
+			(len(reqs) == 0 || req.FileName != reqs[len(reqs)-1].FileName || storage.CleanDir(req.Folder) != reqs[len(reqs)-1].Folder) {
+			// From a client: naming another file starts it.
+			if err := finish(); err != nil {
+				return err
+			}
+			if err := validateClientWrite(req); err != nil {
+				return err
+			}
+			key := req.Folder + "/" + req.FileName
+			if seen[key] {
+				return status.Errorf(codes.InvalidArgument, "file %s appears twice in the batch", key)
+			}
+			seen[key] = true
+			content = s.node.newChunker(stream.Context(), req.Folder, req.FileName, req.Attributes)
+			reqs = append(reqs, &rpcpb.StreamWriteReq{Folder: req.Folder, FileName: req.FileName, TtlSeconds: req.TtlSeconds, Compression: req.Compression})
 		}
 
-		if sendErr := stream.Send(chunk); sendErr != nil {
-			log.Printf("[StreamRead] ❌ Send error: %v", sendErr)
-			return status.Errorf(codes.Internal, "send error: %v", sendErr)
+		if len(reqs) == 0 {
+			return status.Error(codes.InvalidArgument, "first batch message must name a file")
+		}
+		if replica != nil {
+			err = replica.Write(req.ChunkId, req.Data)
+		} else {
+			err = writeClientData(content, req)
+		}
+		if err != nil {
+			log.Printf("[BatchWrite] ❌ Failed to store chunk: %v\n", err)
+			return chunkStoreStatus(err)
+		}
+	}
+
+	if len(reqs) == 0 {
+		return status.Error(codes.InvalidArgument, "empty batch")
+	}
+	if replica != nil {
+		manifests := make([]*rpcpb.Manifest, len(reqs))
+		for i, req := range reqs {
+			manifests[i] = req.Manifest
+		}
+		if err := replica.Close(manifests...); err != nil {
+			log.Printf("[BatchWrite] ❌ %v", err)
+			return status.Errorf(codes.FailedPrecondition, "incomplete chunk set: %v", err)
+		}
+	} else if err := finish(); err != nil {
+		return err
+	}
+
+	ack := &rpcpb.BatchAck{}
+	if err := s.node.HandleBatch(stream.Context(), reqs, ack); err != nil {
+		log.Printf("[BatchWrite] ❌ HandleBatch failed: %v\n", err)
+		return errStatus(fmt.Errorf("HandleBatch failed: %w", err))
+	}
+
+	log.Printf("[BatchWrite] ✅ Committed %d files", len(ack.Acks))
+	return stream.SendAndClose(ack)
+}
+
+func (s *NodeServer) StreamRead(req *rpcpb.StreamReadReq, stream rpcpb.Node_StreamReadServer) error {
+	log.Printf("[StreamRead] 📥 Received request for Folder=%s Filename=%s", req.Folder, req.FileName)
+	if err := compression.Validate(req.Compression); err != nil {
+		return status.Errorf(codes.InvalidArgument, "%v", err)
+	}
+
+	manifest, err := s.readManifest(stream.Context(), req.Folder, req.FileName, req.Snapshot)
+	if err != nil {
+		log.Printf("[StreamRead] ❌ %v", err)
+		return err
+	}
+
+	setSendCompression(stream.Context(), s.node.compressionFor(manifest.Folder, req.Compression))
+
+	if err := stream.SendHeader(manifestHeader(manifest)); err != nil {
+		log.Printf("[StreamRead] ❌ Send header error: %v", err)
+		return status.Errorf(codes.Internal, "send header error: %v", err)
+	}
+
+	for _, c := range manifest.Chunks {
+		if err := s.streamChunk(stream.Context(), c, stream); err != nil {
+			log.Printf("[StreamRead] ❌ %v", err)
+			return err
 		}
 	}
 
@@ -156,17 +357,646 @@ func (s *NodeServer) StreamRead(req *rpcpb.StreamReadReq, stream rpcpb.Node_Stre
 	return nil
 }
 
+// Page sizes for ListFiles when the client asks for none or too many.
+const (
+	defaultPageSize = 1000
+	maxPageSize     = 10000
+)
+
 func (s *NodeServer) ListFiles(ctx context.Context, req *rpcpb.FolderQuery) (*rpcpb.FileList, error) {
-	log.Printf("[ListFiles] 📁 Listing files for folder: %s", req.Folder)
+	log.Printf("[ListFiles] 📁 Listing files for folder: %s (recursive=%v pattern=%q)", req.Folder, req.Recursive, req.Pattern)
 
-	fileNames, err := s.node.Storage.ListFilesInFolder(req.Folder)
+	page, err := s.listPage(ctx, req)
 	if err != nil {
 		log.Printf("[ListFiles] ❌ Failed to list files: %v", err)
-		return nil, status.Errorf(codes.Internal, "failed to list files: %v", err)
+		return nil, err
+	}
+
+	list := &rpcpb.FileList{}
+	for _, e := range page.Entries {
+		list.FileNames = append(list.FileNames, e.Name)
+		if !e.IsDir {
+			list.Entries = append(list.Entries, s.fileStat(e.Chunk))
+		}
+	}
+	if page.Next != "" {
+		list.NextPageToken = encodePageToken(page.Next, req.Recursive)
+	}
+
+	return list, nil
+}
+
+// ReadDir lists one directory level. Unlike ListFiles it fails with
+// NotFound for a directory that doesn't exist, so an empty directory and
+// a missing one can be told apart.
+func (s *NodeServer) ReadDir(ctx context.Context, req *rpcpb.FolderQuery) (*rpcpb.DirListing, error) {
+	log.Printf("[ReadDir] 📁 Folder=%s", req.Folder)
+
+	if _, err := s.node.Storage.GetDir(ctx, req.Folder); err != nil {
+		return nil, errStatus(err)
+	}
+
+	query := &rpcpb.FolderQuery{
+		Folder:    req.Folder,
+		PageSize:  req.PageSize,
+		PageToken: req.PageToken,
+		Pattern:   req.Pattern,
+		Order:     req.Order,
+	}
+	page, err := s.listPage(ctx, query)
+	if err != nil {
+		log.Printf("[ReadDir] ❌ Failed to read dir: %v", err)
+		return nil, err
+	}
+
+	listing := &rpcpb.DirListing{}
+	for _, e := range page.Entries {
+		entry := &rpcpb.DirEntry{Name: strings.TrimSuffix(e.Name, "/"), IsDir: e.IsDir}
+		if !e.IsDir {
+			entry.Stat = s.fileStat(e.Chunk)
+		}
+		listing.Entries = append(listing.Entries, entry)
+	}
+	if page.Next != "" {
+		listing.NextPageToken = encodePageToken(page.Next, false)
+	}
+	return listing, nil
+}
+
+func (s *NodeServer) Mkdir(ctx context.Context, req *rpcpb.DirReq) (*rpcpb.DirInfo, error) {
+	log.Printf("[Mkdir] 📁 Path=%s parents=%v", req.Path, req.Recursive)
+
+	if err := s.node.HandleMkdir(ctx, req); err != nil {
+		log.Printf("[Mkdir] ❌ %v", err)
+		return nil, errStatus(err)
+	}
+
+	dir, err := s.node.Storage.GetDir(ctx, req.Path)
+	if err != nil {
+		return nil, errStatus(err)
+	}
+	return &rpcpb.DirInfo{Path: dir.Path, CreatedAt: timestamppb.New(dir.CreatedAt)}, nil
+}
+
+func (s *NodeServer) Rmdir(ctx context.Context, req *rpcpb.DirReq) (*rpcpb.DirInfo, error) {
+	log.Printf("[Rmdir] 🗑️ Path=%s recursive=%v", req.Path, req.Recursive)
+
+	dir := storage.CleanDir(req.Path)
+	if dir == "/" {
+		return nil, status.Error(codes.InvalidArgument, "cannot remove the root directory")
+	}
+
+	if err := s.node.HandleRmdir(ctx, req); err != nil {
+		log.Printf("[Rmdir] ❌ %v", err)
+		return nil, errStatus(err)
+	}
+	return &rpcpb.DirInfo{Path: dir}, nil
+}
+
+func (s *NodeServer) Delete(ctx context.Context, req *rpcpb.DeleteReq) (*rpcpb.WriteAck, error) {
+	log.Printf("[Delete] 🗑️ Folder=%s File=%s", req.Folder, req.FileName)
+	req.Folder = storage.CleanDir(req.Folder)
+
+	ack := &rpcpb.WriteAck{}
+	if err := s.node.HandleDelete(ctx, req, ack); err != nil {
+		log.Printf("[Delete] ❌ %v", err)
+		return nil, errStatus(err)
+	}
+	return ack, nil
+}
+
+func (s *NodeServer) SetFolderTTL(ctx context.Context, req *rpcpb.FolderTTL) (*rpcpb.FolderTTL, error) {
+	log.Printf("[SetFolderTTL] ⏳ Folder=%s TTL=%ds", req.Folder, req.TtlSeconds)
+
+	if req.TtlSeconds < 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "negative ttl %d", req.TtlSeconds)
+	}
+	folder := storage.CleanDir(req.Folder)
+	if err := s.node.HandleSetFolderTTL(ctx, folder, time.Duration(req.TtlSeconds)*time.Second); err != nil {
+		log.Printf("[SetFolderTTL] ❌ %v", err)
+		return nil, errStatus(err)
+	}
+	return &rpcpb.FolderTTL{Folder: folder, TtlSeconds: req.TtlSeconds}, nil
+}
+
+// maxSnapshotName bounds snapshot names, which are used as keys.
+const maxSnapshotName = 128
+
+func (s *NodeServer) CreateSnapshot(ctx context.Context, req *rpcpb.SnapshotReq) (*rpcpb.SnapshotInfo, error) {
+	log.Printf("[CreateSnapshot] 📸 Name=%s Folder=%s", req.Name, req.Folder)
+
+	if req.Name == "" || len(req.Name) > maxSnapshotName || strings.ContainsAny(req.Name, "/\x00") {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid snapshot name %q", req.Name)
+	}
+	snap, err := s.node.HandleCreateSnapshot(ctx, req)
+	if err != nil {
+		log.Printf("[CreateSnapshot] ❌ %v", err)
+		return nil, errStatus(err)
+	}
+	log.Printf("[CreateSnapshot] ✅ Name=%s pinned %d files", snap.Name, snap.FileCount)
+	return snapshotInfo(snap), nil
+}
+
+func (s *NodeServer) DeleteSnapshot(ctx context.Context, req *rpcpb.SnapshotReq) (*rpcpb.SnapshotInfo, error) {
+	log.Printf("[DeleteSnapshot] 🗑️ Name=%s", req.Name)
+
+	snap, err := s.node.HandleDeleteSnapshot(ctx, req)
+	if err != nil {
+		log.Printf("[DeleteSnapshot] ❌ %v", err)
+		return nil, errStatus(err)
+	}
+	return snapshotInfo(snap), nil
+}
+
+func (s *NodeServer) ListSnapshots(ctx context.Context, req *rpcpb.ListSnapshotsReq) (*rpcpb.SnapshotList, error) {
+	snaps, err := s.node.Storage.ListSnapshots(ctx)
+	if err != nil {
+		return nil, errStatus(err)
+	}
+	list := &rpcpb.SnapshotList{}
+	for _, snap := range snaps {
+		list.Snapshots = append(list.Snapshots, snapshotInfo(snap))
+	}
+	return list, nil
+}
+
+func snapshotInfo(snap storage.Snapshot) *rpcpb.SnapshotInfo {
+	return &rpcpb.SnapshotInfo{
+		Name:      snap.Name,
+		Folder:    snap.Folder,
+		FileCount: snap.FileCount,
+		CreatedAt: timestamppb.New(snap.CreatedAt),
+	}
+}
+
+func (s *NodeServer) SetQuota(ctx context.Context, req *rpcpb.Quota) (*rpcpb.QuotaUsage, error) {
+	log.Printf("[SetQuota] 📏 Folder=%s MaxBytes=%d MaxFiles=%d", req.Folder, req.MaxBytes, req.MaxFiles)
+
+	q := storage.Quota{Folder: storage.CleanDir(req.Folder), MaxBytes: req.MaxBytes, MaxFiles: req.MaxFiles}
+	if err := s.node.HandleSetQuota(ctx, q); err != nil {
+		log.Printf("[SetQuota] ❌ %v", err)
+		return nil, errStatus(err)
+	}
+	return s.quotaUsage(ctx, q)
+}
+
+func (s *NodeServer) GetUsage(ctx context.Context, req *rpcpb.UsageReq) (*rpcpb.QuotaUsage, error) {
+	folder := storage.CleanDir(req.Folder)
+	q := storage.Quota{Folder: folder}
+
+	quotas, err := s.node.Storage.QuotasFor(ctx, folder)
+	if err != nil {
+		return nil, errStatus(err)
+	}
+	for _, fq := range quotas {
+		if fq.Folder == folder {
+			q = fq
+		}
 	}
+	return s.quotaUsage(ctx, q)
+}
+
+func (s *NodeServer) ListQuotas(ctx context.Context, req *rpcpb.ListQuotasReq) (*rpcpb.QuotaList, error) {
+	quotas, err := s.node.Storage.ListQuotas(ctx)
+	if err != nil {
+		return nil, errStatus(err)
+	}
+	list := &rpcpb.QuotaList{}
+	for _, q := range quotas {
+		u, err := s.quotaUsage(ctx, q)
+		if err != nil {
+			return nil, err
+		}
+		list.Quotas = append(list.Quotas, u)
+	}
+	return list, nil
+}
 
-	return &rpcpb.FileList{
-		FileNames: fileNames,
+// quotaUsage reports the usage of q.Folder against q. A folder without a
+// quota has zero limits.
+func (s *NodeServer) quotaUsage(ctx context.Context, q storage.Quota) (*rpcpb.QuotaUsage, error) {
+	usage, err := s.node.Storage.Usage(ctx, q.Folder)
+	if err != nil {
+		return nil, errStatus(err)
+	}
+	return &rpcpb.QuotaUsage{
+		Quota:     &rpcpb.Quota{Folder: q.Folder, MaxBytes: q.MaxBytes, MaxFiles: q.MaxFiles},
+		UsedBytes: usage.Bytes,
+		UsedFiles: usage.Files,
 	}, nil
+}
+
+// Watch tuning: how many events to read per query, and how often to poll
+// for changes committed through other nodes sharing the change log.
+const (
+	watchBatch        = 256
+	watchPollInterval = time.Second
+)
+
+// Watch streams change events under a folder, starting after req.SinceSeq
+// (or from now when it is 0), until the client goes away.
+func (s *NodeServer) Watch(req *rpcpb.WatchReq, stream rpcpb.Node_WatchServer) error {
+	folder := storage.CleanDir(req.Folder)
+	log.Printf("[Watch] 👀 Folder=%s recursive=%v since=%d", folder, req.Recursive, req.SinceSeq)
+	ctx := stream.Context()
+
+	cursor := req.SinceSeq
+	if cursor == 0 {
+		var err error
+		if cursor, err = s.node.Storage.LastEventID(ctx); err != nil {
+			return status.Errorf(codes.Internal, "failed to read change log: %v", err)
+		}
+	}
+
+	ticker := time.NewTicker(watchPollInterval)
+	defer ticker.Stop()
+
+	for {
+		// Take the wait channel before reading so a change recorded in
+		// between still wakes us.
+		changed := s.node.events.wait()
+
+		events, err := s.node.Storage.EventsSince(ctx, cursor, folder, req.Recursive, watchBatch)
+		if errors.Is(err, storage.ErrEventsPruned) {
+			return status.Errorf(codes.OutOfRange, "events after cursor %d were pruned from the change log; watch again from now", cursor)
+		}
+		if err != nil {
+			log.Printf("[Watch] ❌ %v", err)
+			return status.Errorf(codes.Internal, "failed to read change log: %v", err)
+		}
+		for _, e := range events {
+			err := stream.Send(&rpcpb.ChangeEvent{
+				Cursor:      e.ID,
+				Kind:        rpcpb.ChangeKind(e.Kind),
+				Folder:      e.Folder,
+				FileName:    e.FileName,
+				Seq:         e.Seq,
+				IsDir:       e.IsDir,
+				CommittedAt: timestamppb.New(e.CommittedAt),
+			})
+			if err != nil {
+				return err
+			}
+			cursor = e.ID
+		}
+		if len(events) == watchBatch {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-changed:
+		case <-ticker.C:
+		}
+	}
+}
+
+// listPage validates a listing request and fetches one page of it.
+func (s *NodeServer) listPage(ctx context.Context, req *rpcpb.FolderQuery) (storage.ListPage, error) {
+	if _, err := path.Match(req.Pattern, ""); err != nil {
+		return storage.ListPage{}, status.Errorf(codes.InvalidArgument, "bad pattern %q: %v", req.Pattern, err)
+	}
+	after, err := decodePageToken(req.PageToken, req.Recursive)
+	if err != nil {
+		return storage.ListPage{}, status.Errorf(codes.InvalidArgument, "bad page token: %v", err)
+	}
+
+	pageSize := int(req.PageSize)
+	if pageSize == 0 {
+		pageSize = defaultPageSize
+	}
+	pageSize = min(pageSize, maxPageSize)
+
+	page, err := s.node.Storage.ListFilesInFolder(ctx, storage.ListQuery{
+		Folder:    storage.CleanDir(req.Folder),
+		Recursive: req.Recursive,
+		Pattern:   req.Pattern,
+		Desc:      req.Order == rpcpb.SortOrder_NAME_DESC,
+		After:     after,
+		Limit:     pageSize,
+	})
+	if err != nil {
+		return storage.ListPage{}, errStatus(fmt.Errorf("failed to list files: %w", err))
+	}
+	return page, nil
+}
+
+// Page tokens wrap the storage cursor with the listing mode, so a token
+// from a recursive listing can't be replayed against a flat one.
+func encodePageToken(cursor string, recursive bool) string {
+	mode := "c:"
+	if recursive {
+		mode = "r:"
+	}
+	return base64.RawURLEncoding.EncodeToString([]byte(mode + cursor))
+}
+
+func decodePageToken(token string, recursive bool) (string, error) {
+	if token == "" {
+		return "", nil
+	}
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return "", err
+	}
+	mode := "c:"
+	if recursive {
+		mode = "r:"
+	}
+	cursor, ok := strings.CutPrefix(string(b), mode)
+	if !ok {
+		return "", fmt.Errorf("token is for a different listing mode")
+	}
+	return cursor, nil
+}
+
+func (s *NodeServer) Stat(ctx context.Context, req *rpcpb.StatReq) (*rpcpb.FileStat, error) {
+	log.Printf("[Stat] 🔎 Folder=%s File=%s", req.Folder, req.FileName)
+
+	if req.Snapshot != "" {
+		manifest, err := s.snapshotManifest(ctx, req.Folder, req.FileName, req.Snapshot)
+		if err != nil {
+			return nil, err
+		}
+		return &rpcpb.FileStat{
+			Folder:     manifest.Folder,
+			FileName:   manifest.FileName,
+			Size:       manifest.Size,
+			Seq:        manifest.Seq,
+			State:      storage.Clean.String(),
+			Checksum:   manifest.Checksum,
+			NodeId:     s.node.ID,
+			Attributes: manifest.Attributes,
+		}, nil
+	}
+
+	chunk, err := s.lookup(ctx, req.Folder, req.FileName)
+	if err != nil {
+		return nil, err
+	}
+
+	st := s.fileStat(chunk)
+	manifest, err := s.node.loadManifest(ctx, chunk.Folder, chunk.FileName, chunk.Seq)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to load manifest: %v", err)
+	}
+	st.Attributes = manifest.Attributes
+	return st, nil
+}
+
+// manifestHeader describes the version being streamed: its seq, size,
+// checksum and one "craq-attr: key=value" entry per user attribute.
+func manifestHeader(m *rpcpb.Manifest) metadata.MD {
+	md := metadata.Pairs(
+		"craq-seq", strconv.FormatUint(m.Seq, 10),
+		"craq-size", strconv.FormatUint(m.Size, 10),
+		"craq-checksum", m.Checksum,
+	)
+	for k, v := range m.Attributes {
+		md.Append("craq-attr", k+"="+v)
+	}
+	return md
+}
+
+func (s *NodeServer) fileStat(c storage.Chunk) *rpcpb.FileStat {
+	return &rpcpb.FileStat{
+		Folder:     c.Folder,
+		FileName:   c.FileName,
+		Size:       c.Size,
+		Seq:        c.Seq,
+		State:      c.State.String(),
+		Checksum:   c.Checksum,
+		CreatedAt:  timestamppb.New(c.CreatedAt),
+		ModifiedAt: timestamppb.New(c.ModifiedAt),
+		NodeId:     s.node.ID,
+	}
+}
+
+func (s *NodeServer) GetManifest(ctx context.Context, req *rpcpb.StreamReadReq) (*rpcpb.Manifest, error) {
+	return s.readManifest(ctx, req.Folder, req.FileName, req.Snapshot)
+}
+
+// MissingChunks reports which of the given content chunks this node
+// doesn't hold yet.
+func (s *NodeServer) MissingChunks(ctx context.Context, req *rpcpb.ChunkSet) (*rpcpb.ChunkSet, error) {
+	missing := &rpcpb.ChunkSet{}
+	for _, id := range req.Ids {
+		if !validChunkID(id) {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid chunk id %q", id)
+		}
+		// Touch chunks we hold so the collector leaves them alone until
+		// the manifest referencing them arrives.
+		if !s.node.touchChunk(ctx, id) {
+			missing.Ids = append(missing.Ids, id)
+		}
+	}
+	return missing, nil
+}
+
+// FetchChunk streams one content chunk. A chunk named with its shards is
+// rebuilt from them, so clients read erasure-coded files as they read any
+// other. The reply is compressed as a read of the chunk's folder is.
+func (s *NodeServer) FetchChunk(req *rpcpb.ChunkRef, stream rpcpb.Node_FetchChunkServer) error {
+	if err := compression.Validate(req.Compression); err != nil {
+		return status.Errorf(codes.InvalidArgument, "%v", err)
+	}
+	for _, id := range append([]string{req.Id}, req.Shards...) {
+		if !validChunkID(id) {
+			return status.Errorf(codes.InvalidArgument, "invalid chunk id %q", id)
+		}
+	}
+	if len(req.Shards) > 0 && (req.DataShards == 0 || int(req.DataShards) >= len(req.Shards)) {
+		return status.Errorf(codes.InvalidArgument, "invalid erasure layout for chunk %s", req.Id)
+	}
+	setSendCompression(stream.Context(), s.node.compressionFor(req.Folder, req.Compression))
+	if err := s.streamChunk(stream.Context(), req, stream); err != nil {
+		log.Printf("[FetchChunk] ❌ %v", err)
+		return err
+	}
+	return nil
+}
+
+// lookup returns the latest version of a file, treating tombstones and
+// expired files as missing.
+func (s *NodeServer) lookup(ctx context.Context, folder, fileName string) (storage.Chunk, error) {
+	folder = storage.CleanDir(folder)
+	meta, found, err := s.node.latestVersion(ctx, folder, fileName)
+	if err != nil {
+		return storage.Chunk{}, errStatus(err)
+	}
+	if !found || meta.Deleted || meta.Expired(time.Now()) {
+		return storage.Chunk{}, status.Errorf(codes.NotFound, "Folder %s File %s not found", folder, fileName)
+	}
+	return meta, nil
+}
+
+// committedManifest returns the manifest of the latest clean version of a
+// file. While a newer version is still dirty the previous clean one is
+// served, so a reader never sees a write (or part of a batch) before the
+// tail has committed it.
+func (s *NodeServer) committedManifest(ctx context.Context, folder, fileName string) (*rpcpb.Manifest, error) {
+	meta, err := s.lookup(ctx, folder, fileName)
+	if err != nil {
+		return nil, err
+	}
+
+	if meta.CleanSeq == 0 {
+		return nil, status.Errorf(codes.NotFound, "Folder %s File %s has no committed version yet", folder, fileName)
+	}
+
+	manifest, err := s.node.loadManifest(ctx, meta.Folder, meta.FileName, meta.CleanSeq)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to load manifest: %v", err)
+	}
+	return manifest, nil
+}
+
+// readManifest returns the manifest a read should serve: the version
+// recorded in snapshot if one is named, otherwise the latest committed one.
+// The read counts as access for tiering, and an archived version is
+// rehydrated before it is served.
+func (s *NodeServer) readManifest(ctx context.Context, folder, fileName, snapshot string) (*rpcpb.Manifest, error) {
+	var manifest *rpcpb.Manifest
+	var err error
+	if snapshot != "" {
+		manifest, err = s.snapshotManifest(ctx, folder, fileName, snapshot)
+	} else {
+		manifest, err = s.committedManifest(ctx, folder, fileName)
+	}
+	if err != nil {
+		return nil, err
+	}
+	s.node.noteRead(ctx, manifest)
+	return manifest, nil
+}
+
+// snapshotManifest returns the manifest of the version of a file recorded
+// in a snapshot. It is served even if the file was changed or deleted since.
+func (s *NodeServer) snapshotManifest(ctx context.Context, folder, fileName, snapshot string) (*rpcpb.Manifest, error) {
+	folder = storage.CleanDir(folder)
+	seq, err := s.node.Storage.SnapshotSeq(ctx, snapshot, folder, fileName)
+	if err != nil {
+		return nil, errStatus(fmt.Errorf("snapshot %s: Folder %s File %s: %w", snapshot, folder, fileName, err))
+	}
+
+	manifest, err := s.node.loadManifest(ctx, folder, fileName, seq)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to load manifest: %v", err)
+	}
+	return manifest, nil
+}
+
+// streamChunk sends the bytes of a content chunk, rebuilding an
+// erasure-coded one from its shards.
+func (s *NodeServer) streamChunk(ctx context.Context, ref *rpcpb.ChunkRef, stream grpc.ServerStreamingServer[rpcpb.ReadChunk]) error {
+	var file io.Reader
+	if len(ref.Shards) > 0 {
+		data, err := s.node.readChunkRef(ctx, ref)
+		if err != nil {
+			return status.Errorf(codes.Unavailable, "failed to rebuild chunk %s: %v", ref.Id, err)
+		}
+		file = bytes.NewReader(data)
+	} else {
+		f, err := s.node.Blobs.Open(ctx, chunkKey(ref.Id))
+		if err != nil {
+			return status.Errorf(codes.Internal, "failed to open chunk %s: %v", ref.Id, err)
+		}
+		defer f.Close()
+		file = f
+	}
+
+	const chunkSize = 64 * 1024 // 64 KB messages
+	buf := make([]byte, chunkSize)
+
+	for {
+		n, err := file.Read(buf)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return status.Errorf(codes.Internal, "read error: %v", err)
+		}
+
+		if sendErr := stream.Send(&rpcpb.ReadChunk{Data: buf[:n]}); sendErr != nil {
+			return status.Errorf(codes.Internal, "send error: %v", sendErr)
+		}
+	}
+}
+
+// chunkReceiver reassembles content chunks sent by the predecessor. Each
+// chunk arrives as consecutive messages carrying the same chunk id.
+type chunkReceiver struct {
+	ctx  context.Context
+	node *Node
+	id   string
+	buf  []byte
+}
 
+func (n *Node) newChunkReceiver(ctx context.Context) *chunkReceiver {
+	return &chunkReceiver{ctx: ctx, node: n}
+}
+
+func (r *chunkReceiver) Write(id string, data []byte) error {
+	if id != r.id {
+		if err := r.flush(); err != nil {
+			return err
+		}
+		r.id = id
+	}
+	r.buf = append(r.buf, data...)
+	return nil
+}
+
+func (r *chunkReceiver) flush() error {
+	if r.id == "" {
+		return nil
+	}
+	err := r.node.storeChunkAs(r.ctx, r.id, r.buf)
+	r.id, r.buf = "", r.buf[:0]
+	return err
+}
+
+// Close stores the last chunk and checks that every chunk the manifests
+// reference is now present locally.
+func (r *chunkReceiver) Close(manifests ...*rpcpb.Manifest) error {
+	if err := r.flush(); err != nil {
+		return err
+	}
+	for _, m := range manifests {
+		for _, id := range r.node.heldChunkIDs(m, r.node.ChainPos) {
+			if !validChunkID(id) || !r.node.hasChunk(r.ctx, id) {
+				return fmt.Errorf("chunk %s of Folder %s File %s missing", id, m.Folder, m.FileName)
+			}
+		}
+	}
+	return nil
+}
+
+// errStatus maps errors from the node and storage layers to gRPC status
+// codes. Errors that already carry a status, such as one returned by the
+// successor, keep their code. Storage errors are mapped by kind, after
+// the specific ones that wrap them.
+func errStatus(err error) error {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	case errors.Is(err, storage.ErrDirNotEmpty), errors.Is(err, ErrBaseMissing), errors.Is(err, ErrNotExpired),
+		errors.Is(err, ErrWrongChain):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, ErrIsDirectory), errors.Is(err, ErrIsFile), errors.Is(err, storage.ErrConflict):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, ErrQuotaExceeded):
+		return status.Error(codes.ResourceExhausted, err.Error())
+	case errors.Is(err, storage.ErrNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, storage.ErrStale):
+		return status.Error(codes.Aborted, err.Error())
+	}
+	if st, ok := status.FromError(err); ok {
+		return status.Error(st.Code(), err.Error())
+	}
+	return status.Error(codes.Internal, err.Error())
 }
@@ -0,0 +1,217 @@
+package craq
+
+import (
+	"bytes"
+	"context"
+	"craq-cluster/gen/rpcpb"
+	"craq-cluster/pkg/compression"
+	"craq-cluster/pkg/storage"
+	"fmt"
+	"io"
+	"slices"
+	"sync"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/encoding"
+	"google.golang.org/grpc/stats"
+	"google.golang.org/grpc/status"
+)
+
+// encodingRecorder notes the compression of every response a client
+// receives.
+type encodingRecorder struct {
+	mu        sync.Mutex
+	encodings []string
+}
+
+func (r *encodingRecorder) TagRPC(ctx context.Context, _ *stats.RPCTagInfo) context.Context {
+	return ctx
+}
+
+func (r *encodingRecorder) HandleRPC(_ context.Context, s stats.RPCStats) {
+	if h, ok := s.(*stats.InHeader); ok {
+		r.mu.Lock()
+		r.encodings = append(r.encodings, h.Compression)
+		r.mu.Unlock()
+	}
+}
+
+func (r *encodingRecorder) TagConn(ctx context.Context, _ *stats.ConnTagInfo) context.Context {
+	return ctx
+}
+
+func (r *encodingRecorder) HandleConn(context.Context, stats.ConnStats) {}
+
+func (r *encodingRecorder) last() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.encodings) == 0 {
+		return ""
+	}
+	return r.encodings[len(r.encodings)-1]
+}
+
+func TestFetchChunkFollowsFolderCompression(t *testing.T) {
+	ctx := context.Background()
+	n := newSoloNode(storage.NewMemStore())
+	n.Compression = map[string]string{"/docs": compression.Zstd}
+	rec := &encodingRecorder{}
+	client := serveNode(t, n, grpc.WithStatsHandler(rec))
+	data := testData(1, 1000)
+	if _, err := writeFile(ctx, n, "/docs", "a", data); err != nil {
+		t.Fatal(err)
+	}
+	manifest, err := client.GetManifest(ctx, &rpcpb.StreamReadReq{Folder: "/docs", FileName: "a"})
+	if err != nil || len(manifest.Chunks) != 1 {
+		t.Fatalf("manifest: %v, %v", manifest, err)
+	}
+	ref := manifest.Chunks[0]
+
+	for _, tc := range []struct {
+		folder, requested, want string
+	}{
+		{"/docs", "", compression.Zstd},
+		{"/docs", compression.Gzip, compression.Gzip},
+		{"/docs", compression.None, encoding.Identity},
+		{"", "", ""},
+	} {
+		stream, err := client.FetchChunk(ctx, &rpcpb.ChunkRef{Id: ref.Id, Size: ref.Size, Folder: tc.folder, Compression: tc.requested})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []byte
+		for {
+			msg, err := stream.Recv()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatalf("fetch for %q, %q: %v", tc.folder, tc.requested, err)
+			}
+			got = append(got, msg.Data...)
+		}
+		if !bytes.Equal(got, data) {
+			t.Fatalf("fetch for %q, %q returned %d bytes, want the chunk's %d", tc.folder, tc.requested, len(got), len(data))
+		}
+		if enc := rec.last(); enc != tc.want {
+			t.Fatalf("fetch for %q, %q replied with %q compression, want %q", tc.folder, tc.requested, enc, tc.want)
+		}
+	}
+
+	stream, err := client.FetchChunk(ctx, &rpcpb.ChunkRef{Id: ref.Id, Size: ref.Size, Compression: "lz4"})
+	if err == nil {
+		_, err = stream.Recv()
+	}
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("fetch with unknown compression: %v, want InvalidArgument", err)
+	}
+}
+
+func TestHeadRejectsClientManifests(t *testing.T) {
+	ctx := context.Background()
+	n := newSoloNode(storage.NewMemStore())
+	if err := n.Storage.SetQuota(ctx, storage.Quota{Folder: "/docs", MaxBytes: 10}); err != nil {
+		t.Fatal(err)
+	}
+	client := serveNode(t, n)
+	data := testData(1, 100)
+	forged := &rpcpb.Manifest{
+		Folder: "/docs", FileName: "a", Size: 1, ChunkSize: ContentChunkSize,
+		Chunks: []*rpcpb.ChunkRef{{Id: sha256Hex(data), Size: 1}},
+	}
+	first := &rpcpb.StreamWriteReq{Folder: "/docs", FileName: "a", Manifest: forged, ChunkId: sha256Hex(data), Data: data}
+
+	write, err := client.StreamWrite(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	write.Send(first)
+	if _, err := write.CloseAndRecv(); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("write carrying a manifest: %v, want InvalidArgument", err)
+	}
+
+	batch, err := client.BatchWrite(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	batch.Send(first)
+	if _, err := batch.CloseAndRecv(); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("batch carrying a manifest: %v, want InvalidArgument", err)
+	}
+	if _, err := n.Storage.GetLatest(ctx, "/docs", "a"); err == nil {
+		t.Fatalf("forged manifest was stored")
+	}
+}
+
+// listAll pages through a listing, returning the names and the number of
+// pages it took.
+func listAll(t *testing.T, s *NodeServer, q *rpcpb.FolderQuery) ([]string, int) {
+	t.Helper()
+	var names []string
+	pages := 0
+	for {
+		list, err := s.ListFiles(context.Background(), q)
+		if err != nil {
+			t.Fatalf("list page %d: %v", pages, err)
+		}
+		pages++
+		if len(list.FileNames) > int(q.PageSize) {
+			t.Fatalf("page %d has %d names, more than %d", pages, len(list.FileNames), q.PageSize)
+		}
+		names = append(names, list.FileNames...)
+		if list.NextPageToken == "" {
+			return names, pages
+		}
+		q.PageToken = list.NextPageToken
+	}
+}
+
+func TestListFilesPagesWithCursors(t *testing.T) {
+	ctx := context.Background()
+	n := newSoloNode(storage.NewMemStore())
+	s := NewNodeServer(n)
+	var want []string
+	for i := range 7 {
+		name := fmt.Sprintf("f%d", i)
+		if _, err := writeFile(ctx, n, "/docs", name, []byte(name)); err != nil {
+			t.Fatal(err)
+		}
+		want = append(want, name)
+	}
+	if _, err := writeFile(ctx, n, "/docs/sub", "x", []byte("x")); err != nil {
+		t.Fatal(err)
+	}
+
+	names, pages := listAll(t, s, &rpcpb.FolderQuery{Folder: "/docs", PageSize: 3})
+	if !slices.Contains(names, "sub/") {
+		t.Fatalf("flat listing %v lacks the subfolder", names)
+	}
+	files := slices.DeleteFunc(slices.Clone(names), func(name string) bool { return name == "sub/" })
+	if !slices.Equal(files, want) || len(names) != len(want)+1 || pages != 3 {
+		t.Fatalf("flat listing %v in %d pages; want %v and sub/ in 3", names, pages, want)
+	}
+
+	desc, _ := listAll(t, s, &rpcpb.FolderQuery{Folder: "/docs", PageSize: 3, Order: rpcpb.SortOrder_NAME_DESC})
+	reversed := slices.Clone(names)
+	slices.Reverse(reversed)
+	if !slices.Equal(desc, reversed) {
+		t.Fatalf("descending listing %v is not %v reversed", desc, names)
+	}
+
+	recursive, _ := listAll(t, s, &rpcpb.FolderQuery{Folder: "/docs", PageSize: 2, Recursive: true})
+	if !slices.Contains(recursive, "sub/x") || len(slices.Compact(slices.Sorted(slices.Values(recursive)))) != len(recursive) {
+		t.Fatalf("recursive listing %v: want sub/x and no repeats", recursive)
+	}
+
+	// A cursor from a flat listing can't resume a recursive one.
+	first, err := s.ListFiles(ctx, &rpcpb.FolderQuery{Folder: "/docs", PageSize: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = s.ListFiles(ctx, &rpcpb.FolderQuery{Folder: "/docs", PageSize: 3, Recursive: true, PageToken: first.NextPageToken})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("flat token on recursive listing: %v, want InvalidArgument", err)
+	}
+}
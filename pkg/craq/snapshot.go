@@ -0,0 +1,78 @@
+package craq
+
+import (
+	"context"
+	"craq-cluster/gen/rpcpb"
+	"craq-cluster/pkg/storage"
+	"errors"
+	"fmt"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// HandleCreateSnapshot takes a snapshot and forwards it down the chain.
+// The head pins the committed version of every file under the folder and
+// sends the versions it pinned along, so replicas with their own metadata
+// pin the same ones and their collectors keep them. A replica sharing the
+// head's metadata finds the snapshot already there and treats it as
+// applied.
+func (n *Node) HandleCreateSnapshot(ctx context.Context, req *rpcpb.SnapshotReq) (storage.Snapshot, error) {
+	var snap storage.Snapshot
+	if n.IsHead {
+		if _, err := n.Storage.GetDir(ctx, req.Folder); err != nil {
+			return snap, fmt.Errorf("snapshot folder %s: %w", req.Folder, err)
+		}
+		var err error
+		if snap, err = n.Storage.CreateSnapshot(ctx, req.Name, req.Folder); err != nil {
+			return snap, fmt.Errorf("snapshot %s: %w", req.Name, err)
+		}
+		files, err := n.Storage.SnapshotFiles(ctx, snap.Name)
+		if err != nil {
+			return snap, fmt.Errorf("snapshot %s: %w", req.Name, err)
+		}
+		req.Folder = snap.Folder
+		req.CreatedAt = timestamppb.New(snap.CreatedAt)
+		req.Files = make([]*rpcpb.SnapshotFile, len(files))
+		for i, f := range files {
+			req.Files[i] = &rpcpb.SnapshotFile{Folder: f.Folder, FileName: f.FileName, Seq: f.Seq}
+		}
+	} else {
+		snap = storage.Snapshot{Name: req.Name, Folder: storage.CleanDir(req.Folder), CreatedAt: req.CreatedAt.AsTime()}
+		files := make([]storage.SnapshotFile, len(req.Files))
+		for i, f := range req.Files {
+			files[i] = storage.SnapshotFile{Folder: f.Folder, FileName: f.FileName, Seq: f.Seq}
+		}
+		err := n.Storage.PutSnapshot(ctx, snap, files)
+		if err != nil && !errors.Is(err, storage.ErrSnapshotExists) {
+			return snap, fmt.Errorf("snapshot %s: %w", req.Name, err)
+		}
+		snap.FileCount = uint64(len(files))
+	}
+
+	if !n.IsTail {
+		if _, err := n.Next.CreateSnapshot(ctx, req); err != nil {
+			return snap, fmt.Errorf("forward CreateSnapshot to successor failed: %w", err)
+		}
+	}
+	return snap, nil
+}
+
+// HandleDeleteSnapshot deletes a snapshot locally and forwards the request
+// down the chain. A replica that finds it already gone treats the delete
+// as applied, since replicas may share metadata with the head.
+func (n *Node) HandleDeleteSnapshot(ctx context.Context, req *rpcpb.SnapshotReq) (storage.Snapshot, error) {
+	snap, err := n.Storage.GetSnapshot(ctx, req.Name)
+	if err == nil {
+		err = n.Storage.DeleteSnapshot(ctx, req.Name)
+	}
+	if err != nil && (n.IsHead || !errors.Is(err, storage.ErrSnapshotNotFound)) {
+		return snap, fmt.Errorf("snapshot %s: %w", req.Name, err)
+	}
+
+	if !n.IsTail {
+		if _, err := n.Next.DeleteSnapshot(ctx, req); err != nil {
+			return snap, fmt.Errorf("forward DeleteSnapshot to successor failed: %w", err)
+		}
+	}
+	return snap, nil
+}
@@ -0,0 +1,225 @@
+package craq
+
+import (
+	"context"
+	"craq-cluster/gen/rpcpb"
+	"craq-cluster/pkg/storage"
+	"fmt"
+	"log"
+	"path"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// TierPolicy controls the background pass that moves the chunks of cold
+// versions from the node's hot blob store to its compressed archive tier.
+// It only runs when the node's Blobs is a *storage.TieredBlobStore.
+type TierPolicy struct {
+	Interval time.Duration // time between passes; 0 disables tiering
+	// IdleAfter is how long the committed version of a file must go
+	// unread and unwritten before it is archived.
+	IdleAfter time.Duration
+	// SupersededAfter is how long after it was written an older version,
+	// kept for retention or a snapshot, is archived.
+	SupersededAfter time.Duration
+}
+
+// TierStats counts what one tiering pass archived.
+type TierStats struct {
+	Versions int
+	Chunks   int
+	Bytes    int64 // uncompressed bytes moved off the hot tier
+}
+
+// accessLog records when each file was last read. It is kept in memory
+// only; until a file is read, the node's start counts as its last read.
+type accessLog struct {
+	mu   sync.Mutex
+	last map[fileKey]time.Time
+}
+
+func (a *accessLog) note(folder, fileName string, t time.Time) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.last == nil {
+		a.last = make(map[fileKey]time.Time)
+	}
+	a.last[fileKey{folder, fileName}] = t
+}
+
+func (a *accessLog) get(folder, fileName string) time.Time {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.last[fileKey{folder, fileName}]
+}
+
+func (a *accessLog) forget(f fileKey) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	delete(a.last, f)
+}
+
+// archivedKey returns the key of the blob marking a version archived on
+// this node; it holds the unix time it was archived. The mark is kept out
+// of the manifest so tiering never rewrites it: the manifest's mtime is
+// when the version was written, which the collector's grace period and
+// the tiering pass both go by.
+func archivedKey(folder, fileName string, seq uint64) string {
+	return path.Join("archived", folder, fileName, strconv.FormatUint(seq, 10))
+}
+
+// archived reports whether this node has archived the chunks of m.
+func (n *Node) archived(ctx context.Context, m *rpcpb.Manifest) bool {
+	_, err := n.Blobs.Stat(ctx, archivedKey(m.Folder, m.FileName, m.Seq))
+	return err == nil
+}
+
+// tiers returns the node's tiered blob store, or nil if it has none.
+func (n *Node) tiers() *storage.TieredBlobStore {
+	t, _ := n.Blobs.(*storage.TieredBlobStore)
+	return t
+}
+
+// RunTiering archives cold versions every p.Interval until ctx is done.
+func (n *Node) RunTiering(ctx context.Context, p TierPolicy) {
+	if p.Interval <= 0 || n.tiers() == nil {
+		return
+	}
+	ticker := time.NewTicker(p.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		stats, err := n.ArchiveCold(ctx, p)
+		if err != nil {
+			log.Printf("⚠️ Node %s tiering pass failed: %v", n.ID, err)
+			continue
+		}
+		if stats.Versions > 0 {
+			log.Printf("🧊 Node %s archived %d versions, %d chunks (%d bytes)", n.ID, stats.Versions, stats.Chunks, stats.Bytes)
+		}
+	}
+}
+
+// ArchiveCold runs one tiering pass. Every stored version is classed hot
+// or cold by the policy; the chunks this node owns of cold versions move
+// to the archive tier, unless a hot version shares them or they were
+// written or reused within the idle window, and the cold version is
+// marked archived.
+func (n *Node) ArchiveCold(ctx context.Context, p TierPolicy) (TierStats, error) {
+	var stats TierStats
+	tiers := n.tiers()
+	if tiers == nil {
+		return stats, nil
+	}
+
+	files, err := n.listManifests(ctx)
+	if err != nil {
+		return stats, err
+	}
+
+	now := time.Now()
+	hotChunks := make(map[string]bool)
+	var cold []*rpcpb.Manifest
+	for f, seqs := range files {
+		latest, found, err := n.latestVersion(ctx, f.folder, f.fileName)
+		if err != nil {
+			return stats, fmt.Errorf("%s/%s: %w", f.folder, f.fileName, err)
+		}
+		if found && latest.Deleted {
+			n.access.forget(f)
+		}
+		lastRead := n.access.get(f.folder, f.fileName)
+		if lastRead.Before(n.started) {
+			lastRead = n.started
+		}
+
+		sort.Slice(seqs, func(i, j int) bool { return seqs[i] < seqs[j] })
+		for _, seq := range seqs {
+			key := manifestKey(f.folder, f.fileName, seq)
+			info, err := n.Blobs.Stat(ctx, key)
+			if err != nil {
+				continue // removed by the collector since the listing
+			}
+
+			var isCold bool
+			switch {
+			case !found || seq > latest.CleanSeq:
+				// No metadata yet, or still in flight.
+			case seq == latest.CleanSeq:
+				isCold = now.Sub(info.ModTime) > p.IdleAfter && now.Sub(lastRead) > p.IdleAfter
+			default:
+				isCold = now.Sub(info.ModTime) > p.SupersededAfter
+			}
+
+			m, err := n.loadManifest(ctx, f.folder, f.fileName, seq)
+			if err != nil {
+				continue
+			}
+			if !isCold {
+				for _, id := range n.ownedChunkIDs(m) {
+					hotChunks[id] = true
+				}
+			} else if !n.archived(ctx, m) {
+				cold = append(cold, m)
+			}
+		}
+	}
+
+	idleSince := now.Add(-p.IdleAfter)
+	for _, m := range cold {
+		for _, id := range n.ownedChunkIDs(m) {
+			if hotChunks[id] {
+				continue
+			}
+			hot, err := tiers.IsHot(ctx, chunkKey(id))
+			if err != nil || !hot || n.writtenAfter(ctx, chunkKey(id), idleSince) {
+				continue
+			}
+			freed, err := tiers.Archive(ctx, chunkKey(id))
+			if err != nil {
+				log.Printf("⚠️ Node %s failed to archive chunk %s: %v", n.ID, id, err)
+				continue
+			}
+			stats.Chunks++
+			stats.Bytes += freed
+		}
+
+		stamp := []byte(strconv.FormatInt(now.Unix(), 10))
+		if err := storage.WriteBlob(ctx, n.Blobs, archivedKey(m.Folder, m.FileName, m.Seq), stamp); err != nil {
+			return stats, fmt.Errorf("mark %s/%s@%d archived: %w", m.Folder, m.FileName, m.Seq, err)
+		}
+		stats.Versions++
+	}
+	return stats, nil
+}
+
+// noteRead records a read of a version. Reading an archived version moves
+// its chunks back to the hot tier before they are streamed; chunks that
+// fail to move are still served from the archive.
+func (n *Node) noteRead(ctx context.Context, m *rpcpb.Manifest) {
+	n.access.note(m.Folder, m.FileName, time.Now())
+	tiers := n.tiers()
+	if tiers == nil || !n.archived(ctx, m) {
+		return
+	}
+
+	for _, id := range n.ownedChunkIDs(m) {
+		if err := tiers.Rehydrate(ctx, chunkKey(id)); err != nil {
+			log.Printf("⚠️ Node %s failed to rehydrate chunk %s: %v", n.ID, id, err)
+			return
+		}
+	}
+	if err := n.Blobs.Delete(ctx, archivedKey(m.Folder, m.FileName, m.Seq)); err != nil {
+		log.Printf("⚠️ Node %s failed to mark %s/%s@%d hot: %v", n.ID, m.Folder, m.FileName, m.Seq, err)
+		return
+	}
+	log.Printf("🔥 Node %s rehydrated %s/%s@%d", n.ID, m.Folder, m.FileName, m.Seq)
+}
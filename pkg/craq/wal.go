@@ -0,0 +1,461 @@
+package craq
+
+import (
+	"bufio"
+	"context"
+	"craq-cluster/gen/rpcpb"
+	"craq-cluster/pkg/storage"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// walOp is what a WAL record says happened to a version on this node.
+type walOp string
+
+const (
+	// walReceived: the version's manifest and chunks are on disk and its
+	// metadata is about to be stored as dirty.
+	walReceived walOp = "received"
+	// walForwarded: the successor acked the version, so the tail has
+	// committed it.
+	walForwarded walOp = "forwarded"
+	// walCommitted: the version is marked clean here.
+	walCommitted walOp = "committed"
+)
+
+type walRecord struct {
+	Op       walOp  `json:"op"`
+	Folder   string `json:"folder"`
+	FileName string `json:"file"`
+	Seq      uint64 `json:"seq"`
+	// Batch holds the versions of a batch write instead, which are stored,
+	// forwarded and committed together.
+	Batch []walEntry `json:"batch,omitempty"`
+}
+
+type walEntry struct {
+	Folder   string `json:"folder"`
+	FileName string `json:"file"`
+	Seq      uint64 `json:"seq"`
+}
+
+type walVersion struct {
+	folder, fileName string
+	seq              uint64
+}
+
+// versions returns the versions rec is about.
+func (rec walRecord) versions() []walVersion {
+	if len(rec.Batch) == 0 {
+		return []walVersion{{rec.Folder, rec.FileName, rec.Seq}}
+	}
+	vs := make([]walVersion, len(rec.Batch))
+	for i, e := range rec.Batch {
+		vs[i] = walVersion{e.Folder, e.FileName, e.Seq}
+	}
+	return vs
+}
+
+func (rec walRecord) String() string {
+	if len(rec.Batch) == 0 {
+		return fmt.Sprintf("%s/%s@%d", rec.Folder, rec.FileName, rec.Seq)
+	}
+	first := rec.Batch[0]
+	return fmt.Sprintf("batch of %d from %s/%s@%d", len(rec.Batch), first.Folder, first.FileName, first.Seq)
+}
+
+// walCompactSize is the log size past which it is rewritten to hold only
+// the versions still in flight.
+const walCompactSize = 16 << 20
+
+// walFile is a node's append-only log of the versions it has received,
+// forwarded and committed. Each record is framed with its length and a
+// CRC and fsynced before write returns; a torn record at the end, left
+// by a crash mid-write, is dropped when the log is opened.
+type walFile struct {
+	mu      sync.Mutex
+	path    string
+	f       *os.File
+	size    int64
+	pending map[walVersion]walOp        // versions not yet committed, by last op
+	batches map[walVersion][]walVersion // every version of the batch a pending version came in
+}
+
+// openWAL opens the log at path, creating it if needed, and loads the
+// versions it still has in flight.
+func openWAL(path string) (*walFile, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("open WAL: %w", err)
+	}
+
+	w := &walFile{path: path, f: f, pending: make(map[walVersion]walOp), batches: make(map[walVersion][]walVersion)}
+	valid, err := w.load()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	if err := f.Truncate(valid); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("truncate torn WAL tail: %w", err)
+	}
+	if _, err := f.Seek(valid, io.SeekStart); err != nil {
+		f.Close()
+		return nil, err
+	}
+	w.size = valid
+	return w, nil
+}
+
+// load replays the records into pending and returns the length of the
+// intact prefix of the log.
+func (w *walFile) load() (int64, error) {
+	r := bufio.NewReader(w.f)
+	var valid int64
+	for {
+		var header [8]byte
+		if _, err := io.ReadFull(r, header[:]); err != nil {
+			return valid, nil
+		}
+		size := binary.BigEndian.Uint32(header[:4])
+		payload := make([]byte, size)
+		if _, err := io.ReadFull(r, payload); err != nil {
+			return valid, nil
+		}
+		if crc32.ChecksumIEEE(payload) != binary.BigEndian.Uint32(header[4:]) {
+			return valid, nil
+		}
+		var rec walRecord
+		if err := json.Unmarshal(payload, &rec); err != nil {
+			return valid, fmt.Errorf("decode WAL record at %d: %w", valid, err)
+		}
+		w.apply(rec)
+		valid += int64(len(header)) + int64(size)
+	}
+}
+
+func (w *walFile) apply(rec walRecord) {
+	vs := rec.versions()
+	for _, v := range vs {
+		switch {
+		case rec.Op == walCommitted:
+			delete(w.pending, v)
+			delete(w.batches, v)
+		case len(vs) > 1:
+			w.pending[v] = rec.Op
+			w.batches[v] = vs
+		default:
+			w.pending[v] = rec.Op
+		}
+	}
+}
+
+func encodeWALRecord(rec walRecord) ([]byte, error) {
+	payload, err := json.Marshal(rec)
+	if err != nil {
+		return nil, err
+	}
+	buf := make([]byte, 8, 8+len(payload))
+	binary.BigEndian.PutUint32(buf[:4], uint32(len(payload)))
+	binary.BigEndian.PutUint32(buf[4:], crc32.ChecksumIEEE(payload))
+	return append(buf, payload...), nil
+}
+
+// write appends rec and fsyncs the log.
+func (w *walFile) write(rec walRecord) error {
+	b, err := encodeWALRecord(rec)
+	if err != nil {
+		return err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if _, err := w.f.Write(b); err != nil {
+		return fmt.Errorf("append to WAL: %w", err)
+	}
+	if err := w.f.Sync(); err != nil {
+		return fmt.Errorf("sync WAL: %w", err)
+	}
+	w.size += int64(len(b))
+	w.apply(rec)
+
+	if w.size > walCompactSize {
+		if err := w.compact(); err != nil {
+			log.Printf("⚠️ WAL compaction failed: %v", err)
+		}
+	}
+	return nil
+}
+
+// inFlight returns the versions not yet committed, with the last thing
+// logged for each, in seq order per file. The versions of a batch come
+// back together as one record.
+func (w *walFile) inFlight() []walRecord {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.records()
+}
+
+func (w *walFile) records() []walRecord {
+	recs := make([]walRecord, 0, len(w.pending))
+	seen := make(map[walVersion]bool, len(w.pending))
+	for v, op := range w.pending {
+		if seen[v] {
+			continue
+		}
+		batch := w.batches[v]
+		if batch == nil {
+			recs = append(recs, walRecord{Op: op, Folder: v.folder, FileName: v.fileName, Seq: v.seq})
+			continue
+		}
+		rec := walRecord{Op: op}
+		for _, b := range batch {
+			if _, ok := w.pending[b]; ok {
+				rec.Batch = append(rec.Batch, walEntry{b.folder, b.fileName, b.seq})
+				seen[b] = true
+			}
+		}
+		recs = append(recs, rec)
+	}
+	sort.Slice(recs, func(i, j int) bool {
+		a, b := recs[i].versions()[0], recs[j].versions()[0]
+		if a.folder != b.folder {
+			return a.folder < b.folder
+		}
+		if a.fileName != b.fileName {
+			return a.fileName < b.fileName
+		}
+		return a.seq < b.seq
+	})
+	return recs
+}
+
+// compact replaces the log with one holding only the pending versions.
+// The new log is fsynced before it is renamed over the old one.
+func (w *walFile) compact() error {
+	tmpPath := w.path + ".compact"
+	tmp, err := os.OpenFile(tmpPath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	var size int64
+	for _, rec := range w.records() {
+		b, err := encodeWALRecord(rec)
+		if err == nil {
+			_, err = tmp.Write(b)
+		}
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmpPath)
+			return err
+		}
+		size += int64(len(b))
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Rename(tmpPath, w.path); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	syncDir(filepath.Dir(w.path))
+
+	w.f.Close()
+	w.f, w.size = tmp, size
+	return nil
+}
+
+func syncDir(dir string) {
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+}
+
+// batchRecord is the one record logged for every version of a batch.
+func batchRecord(op walOp, chunks []storage.Chunk) walRecord {
+	rec := walRecord{Op: op, Batch: make([]walEntry, len(chunks))}
+	for i, c := range chunks {
+		rec.Batch[i] = walEntry{c.Folder, c.FileName, c.Seq}
+	}
+	return rec
+}
+
+// logRecord appends rec if the node has a WAL.
+func (n *Node) logRecord(rec walRecord) error {
+	if n.wal == nil {
+		return nil
+	}
+	return n.wal.write(rec)
+}
+
+// logVersion appends a record for a version if the node has a WAL.
+func (n *Node) logVersion(op walOp, folder, fileName string, seq uint64) error {
+	return n.logRecord(walRecord{Op: op, Folder: folder, FileName: fileName, Seq: seq})
+}
+
+// noteRecord logs a forward or commit that already happened, so a
+// failure is only reported: replay redoes either step harmlessly.
+func (n *Node) noteRecord(rec walRecord) {
+	if err := n.logRecord(rec); err != nil {
+		log.Printf("⚠️ Node %s failed to log %s %v: %v", n.ID, rec.Op, rec, err)
+	}
+}
+
+func (n *Node) noteVersion(op walOp, folder, fileName string, seq uint64) {
+	n.noteRecord(walRecord{Op: op, Folder: folder, FileName: fileName, Seq: seq})
+}
+
+// ErrVersionLost is returned by recovery for a logged version whose
+// manifest is gone, so it can't be stored or forwarded again.
+var ErrVersionLost = errors.New("logged version has no manifest")
+
+// RecoverWAL opens the log at path and finishes every version it shows in
+// flight before the node serves: the version's metadata is stored again
+// from its manifest, it is forwarded to the successor unless it already
+// was (or this node is the tail), and it is marked clean. A batch is
+// finished the same way as a whole, through the batch path, so it commits
+// downstream all at once. A version that can't be finished stays in the
+// log for the next start. The node logs to the WAL from then on.
+func (n *Node) RecoverWAL(ctx context.Context, path string) error {
+	w, err := openWAL(path)
+	if err != nil {
+		return err
+	}
+	n.wal = w
+
+	pending := w.inFlight()
+	recovered := 0
+	for _, rec := range pending {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		var err error
+		if len(rec.Batch) > 0 {
+			err = n.recoverBatch(ctx, rec)
+		} else {
+			err = n.recoverVersion(ctx, rec)
+		}
+		switch {
+		case err == nil:
+			recovered++
+		case errors.Is(err, ErrVersionLost):
+			log.Printf("⚠️ Node %s dropping %v from WAL: %v", n.ID, rec, err)
+			rec.Op = walCommitted
+			n.noteRecord(rec)
+		default:
+			log.Printf("⚠️ Node %s could not recover %v: %v", n.ID, rec, err)
+		}
+	}
+	if len(pending) > 0 {
+		log.Printf("📜 Node %s recovered %d of %d in-flight writes from WAL", n.ID, recovered, len(pending))
+	}
+	return nil
+}
+
+func (n *Node) recoverVersion(ctx context.Context, rec walRecord) error {
+	latest, found, err := n.latestVersion(ctx, rec.Folder, rec.FileName)
+	if err != nil {
+		return err
+	}
+	if found && (latest.CleanSeq >= rec.Seq || latest.Seq > rec.Seq) {
+		// Committed, or superseded by a newer version.
+		n.noteVersion(walCommitted, rec.Folder, rec.FileName, rec.Seq)
+		return nil
+	}
+
+	manifest, err := n.loadManifest(ctx, rec.Folder, rec.FileName, rec.Seq)
+	if errors.Is(err, storage.ErrNotFound) {
+		return ErrVersionLost
+	}
+	if err != nil {
+		return err
+	}
+	req := &rpcpb.StreamWriteReq{
+		Folder:   rec.Folder,
+		FileName: rec.FileName,
+		Seq:      rec.Seq,
+		Manifest: manifest,
+	}
+	chunk, err := n.prepareVersion(ctx, req)
+	if err != nil {
+		return err
+	}
+	if err := n.Storage.Put(ctx, chunk); err != nil {
+		return fmt.Errorf("Storage Put failed: %w", err)
+	}
+
+	// Replicas that already hold the version treat it as applied.
+	if !n.IsTail && rec.Op != walForwarded {
+		if _, err := n.streamFileToNext(ctx, req); err != nil {
+			return fmt.Errorf("forward to successor failed: %w", err)
+		}
+	}
+
+	if err := n.Storage.MarkClean(ctx, rec.Folder, rec.FileName, rec.Seq); err != nil {
+		return fmt.Errorf("MarkClean failed: %w", err)
+	}
+	n.recordCommit(ctx, rec.Folder, rec.FileName, rec.Seq)
+	n.noteVersion(walCommitted, rec.Folder, rec.FileName, rec.Seq)
+	return nil
+}
+
+// recoverBatch finishes a logged batch like recoverVersion does a single
+// version, leaving out versions already committed or superseded here.
+func (n *Node) recoverBatch(ctx context.Context, rec walRecord) error {
+	var reqs []*rpcpb.StreamWriteReq
+	var chunks []storage.Chunk
+	for _, e := range rec.Batch {
+		latest, found, err := n.latestVersion(ctx, e.Folder, e.FileName)
+		if err != nil {
+			return err
+		}
+		if found && (latest.CleanSeq >= e.Seq || latest.Seq > e.Seq) {
+			continue
+		}
+		manifest, err := n.loadManifest(ctx, e.Folder, e.FileName, e.Seq)
+		if errors.Is(err, storage.ErrNotFound) {
+			return fmt.Errorf("%s/%s@%d: %w", e.Folder, e.FileName, e.Seq, ErrVersionLost)
+		}
+		if err != nil {
+			return err
+		}
+		req := &rpcpb.StreamWriteReq{Folder: e.Folder, FileName: e.FileName, Seq: e.Seq, Manifest: manifest}
+		chunk, err := n.prepareVersion(ctx, req)
+		if err != nil {
+			return err
+		}
+		reqs, chunks = append(reqs, req), append(chunks, chunk)
+	}
+
+	if len(chunks) > 0 {
+		if err := n.Storage.PutBatch(ctx, chunks); err != nil {
+			return fmt.Errorf("Storage PutBatch failed: %w", err)
+		}
+		// Replicas that already hold the batch treat it as applied.
+		if err := n.finishBatch(ctx, reqs, chunks, rec.Op == walForwarded); err != nil {
+			return err
+		}
+	}
+	if len(chunks) < len(rec.Batch) {
+		rec.Op = walCommitted
+		n.noteRecord(rec)
+	}
+	return nil
+}
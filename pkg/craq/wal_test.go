@@ -0,0 +1,171 @@
+package craq
+
+import (
+	"context"
+	"craq-cluster/gen/rpcpb"
+	"craq-cluster/pkg/storage"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWALReplaysInFlightVersionPastTornTail(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "wal", "solo.log")
+	store, blobs := storage.NewMemStore(), storage.NewMemBlobStore()
+
+	n := NewNode("solo", true, true, store, blobs, nil, nil)
+	if err := n.RecoverWAL(ctx, path); err != nil {
+		t.Fatal(err)
+	}
+	defer n.wal.f.Close()
+	if _, err := writeFile(ctx, n, "/docs", "done", []byte("committed")); err != nil {
+		t.Fatal(err)
+	}
+
+	// Crash after logging a version as received, before its Put...
+	c := n.newChunker(ctx, "/docs", "pending", nil)
+	c.Write([]byte("in flight"))
+	m, err := c.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := &rpcpb.StreamWriteReq{Folder: "/docs", FileName: "pending", Seq: 1, Manifest: m}
+	if _, err := n.prepareVersion(ctx, req); err != nil {
+		t.Fatal(err)
+	}
+	if err := n.logVersion(walReceived, req.Folder, req.FileName, req.Seq); err != nil {
+		t.Fatal(err)
+	}
+	// ...while the next record was half written.
+	torn, err := encodeWALRecord(walRecord{Op: walReceived, Folder: "/docs", FileName: "torn", Seq: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	intact := n.wal.size
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Write(torn[:len(torn)/2])
+	f.Close()
+
+	restarted := NewNode("solo", true, true, store, blobs, nil, nil)
+	if err := restarted.RecoverWAL(ctx, path); err != nil {
+		t.Fatalf("recover: %v", err)
+	}
+	defer restarted.wal.f.Close()
+
+	latest, err := store.GetLatest(ctx, "/docs", "pending")
+	if err != nil || latest.CleanSeq != 1 {
+		t.Fatalf("in-flight version after replay: clean seq %d, %v; want 1", latest.CleanSeq, err)
+	}
+	if _, err := store.GetLatest(ctx, "/docs", "torn"); err == nil {
+		t.Fatalf("torn record was replayed")
+	}
+	if pending := restarted.wal.inFlight(); len(pending) != 0 {
+		t.Fatalf("still in flight after replay: %v", pending)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Size() < intact || restarted.wal.size != info.Size() {
+		t.Fatalf("log is %d bytes, tracked as %d; want the %d-byte intact prefix plus replay records",
+			info.Size(), restarted.wal.size, intact)
+	}
+
+	// The log takes new records after the dropped tail.
+	if _, err := writeFile(ctx, restarted, "/docs", "after", []byte("more")); err != nil {
+		t.Fatalf("write after recovery: %v", err)
+	}
+	again, err := openWAL(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer again.f.Close()
+	if again.size != restarted.wal.size {
+		t.Fatalf("reopened log keeps %d of %d bytes", again.size, restarted.wal.size)
+	}
+}
+
+// putRecorder counts how versions reach a store: one by one or as batches.
+type putRecorder struct {
+	storage.StorageClient
+	puts    int
+	batches [][]storage.Chunk
+}
+
+func (r *putRecorder) Put(ctx context.Context, c storage.Chunk) error {
+	r.puts++
+	return r.StorageClient.Put(ctx, c)
+}
+
+func (r *putRecorder) PutBatch(ctx context.Context, chunks []storage.Chunk) error {
+	r.batches = append(r.batches, chunks)
+	return r.StorageClient.PutBatch(ctx, chunks)
+}
+
+func TestWALReplaysBatchThroughBatchPath(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "wal", "head.log")
+	nodes, _ := newTestChain(t, 3)
+	head := nodes[0]
+	if err := head.RecoverWAL(ctx, path); err != nil {
+		t.Fatal(err)
+	}
+
+	// Crash after the head stored a batch, before forwarding any of it.
+	var chunks []storage.Chunk
+	for _, name := range []string{"a", "b"} {
+		c := head.newChunker(ctx, "/docs", name, nil)
+		c.Write([]byte(name))
+		m, err := c.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		chunk, err := head.prepareVersion(ctx, &rpcpb.StreamWriteReq{Folder: "/docs", FileName: name, Seq: 1, Manifest: m})
+		if err != nil {
+			t.Fatal(err)
+		}
+		chunks = append(chunks, chunk)
+	}
+	if err := head.logRecord(batchRecord(walReceived, chunks)); err != nil {
+		t.Fatal(err)
+	}
+	if err := head.Storage.PutBatch(ctx, chunks); err != nil {
+		t.Fatal(err)
+	}
+	head.wal.f.Close()
+
+	pending := head.wal.inFlight()
+	if len(pending) != 1 || len(pending[0].Batch) != 2 {
+		t.Fatalf("in flight before replay: %v; want one batch of 2", pending)
+	}
+
+	tail := &putRecorder{StorageClient: nodes[2].Storage}
+	nodes[2].Storage = tail
+	if err := head.RecoverWAL(ctx, path); err != nil {
+		t.Fatalf("recover: %v", err)
+	}
+	defer head.wal.f.Close()
+
+	if tail.puts != 0 || len(tail.batches) != 1 || len(tail.batches[0]) != 2 {
+		t.Fatalf("tail got %d single puts and batches %v; want the 2 files in one batch", tail.puts, tail.batches)
+	}
+	for _, n := range nodes {
+		for _, name := range []string{"a", "b"} {
+			if c, err := n.Storage.GetLatest(ctx, "/docs", name); err != nil || c.CleanSeq != 1 {
+				t.Fatalf("%s: /docs/%s after replay: %+v, %v; want committed", n.ID, name, c, err)
+			}
+		}
+	}
+	again, err := openWAL(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer again.f.Close()
+	if pending := again.inFlight(); len(pending) != 0 {
+		t.Fatalf("still in flight after replay: %v", pending)
+	}
+}
@@ -0,0 +1,86 @@
+package craq
+
+import (
+	"context"
+	"craq-cluster/pkg/storage"
+	"log"
+	"sync"
+	"time"
+)
+
+// eventPruneInterval is how often RunEventPruning trims the change log.
+const eventPruneInterval = time.Hour
+
+// notifier wakes Watch streams when this node records a change. Each
+// wait() channel is closed by the next notify().
+type notifier struct {
+	mu sync.Mutex
+	ch chan struct{}
+}
+
+func (nf *notifier) wait() <-chan struct{} {
+	nf.mu.Lock()
+	defer nf.mu.Unlock()
+	if nf.ch == nil {
+		nf.ch = make(chan struct{})
+	}
+	return nf.ch
+}
+
+func (nf *notifier) notify() {
+	nf.mu.Lock()
+	defer nf.mu.Unlock()
+	if nf.ch != nil {
+		close(nf.ch)
+		nf.ch = nil
+	}
+}
+
+// recordCommit logs a committed file version to the change log. The first
+// version of a file is a create; later ones are updates.
+func (n *Node) recordCommit(ctx context.Context, folder, fileName string, seq uint64) {
+	kind := storage.EventUpdate
+	if seq == 1 {
+		kind = storage.EventCreate
+	}
+	n.recordEvent(ctx, storage.Event{Kind: kind, Folder: folder, FileName: fileName, Seq: seq})
+}
+
+// recordEvent appends e to the change log and wakes local watchers. The
+// change is already committed, so a failure here is logged rather than
+// failing the request, and the client going away doesn't stop it.
+func (n *Node) recordEvent(ctx context.Context, e storage.Event) {
+	if err := n.Storage.RecordEvent(context.WithoutCancel(ctx), e); err != nil {
+		log.Printf("⚠️ Node %s failed to record %s event for %s/%s: %v", n.ID, e.Kind, e.Folder, e.FileName, err)
+		return
+	}
+	n.events.notify()
+}
+
+// RunEventPruning drops change events older than retention every
+// eventPruneInterval until ctx is done. A watcher resuming from a pruned
+// cursor gets OutOfRange rather than a stream with a gap.
+func (n *Node) RunEventPruning(ctx context.Context, retention time.Duration) {
+	if retention <= 0 {
+		return
+	}
+	ticker := time.NewTicker(eventPruneInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		pruned, err := n.Storage.PruneEvents(ctx, time.Now().Add(-retention))
+		if err != nil {
+			log.Printf("⚠️ Node %s change log pruning failed: %v", n.ID, err)
+			continue
+		}
+		if pruned > 0 {
+			log.Printf("✂️ Node %s pruned %d change events older than %v", n.ID, pruned, retention)
+		}
+	}
+}
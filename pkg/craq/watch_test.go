@@ -0,0 +1,121 @@
+package craq
+
+import (
+	"context"
+	"craq-cluster/gen/rpcpb"
+	"craq-cluster/pkg/storage"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// nextEvent receives one change from a Watch stream, failing the test if
+// none comes in time.
+func nextEvent(t *testing.T, stream rpcpb.Node_WatchClient) *rpcpb.ChangeEvent {
+	t.Helper()
+	type result struct {
+		e   *rpcpb.ChangeEvent
+		err error
+	}
+	got := make(chan result, 1)
+	go func() {
+		e, err := stream.Recv()
+		got <- result{e, err}
+	}()
+	select {
+	case r := <-got:
+		if r.err != nil {
+			t.Fatalf("watch: %v", r.err)
+		}
+		return r.e
+	case <-time.After(5 * time.Second):
+		t.Fatal("no change event")
+		return nil
+	}
+}
+
+func TestWatchResumesFromCursor(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	n := newSoloNode(storage.NewMemStore())
+	client := serveNode(t, n)
+	if _, err := writeFile(ctx, n, "/docs", "before", []byte("x")); err != nil {
+		t.Fatal(err)
+	}
+
+	// Without a cursor, only changes from now on are sent.
+	live, err := client.Watch(ctx, &rpcpb.WatchReq{Folder: "/docs"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Watch has no ready signal; give the stream time to take its cursor.
+	time.Sleep(100 * time.Millisecond)
+	for _, name := range []string{"a", "a"} {
+		if _, err := writeFile(ctx, n, "/docs", name, []byte(name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := writeFile(ctx, n, "/other", "skipped", []byte("x")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := writeFile(ctx, n, "/docs", "b", []byte("b")); err != nil {
+		t.Fatal(err)
+	}
+	created, updated := nextEvent(t, live), nextEvent(t, live)
+	if created.FileName != "a" || created.Kind != rpcpb.ChangeKind_CREATE || updated.Kind != rpcpb.ChangeKind_UPDATE || updated.Seq != 2 {
+		t.Fatalf("first events %v, %v; want a created, then updated to seq 2", created, updated)
+	}
+	if e := nextEvent(t, live); e.FileName != "b" || e.Folder != "/docs" {
+		t.Fatalf("third event %v, want b, skipping /other", e)
+	}
+
+	// Resuming after the create sends the rest again, in order.
+	resumed, err := client.Watch(ctx, &rpcpb.WatchReq{Folder: "/docs", SinceSeq: created.Cursor})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e := nextEvent(t, resumed); e.Cursor != updated.Cursor || e.Seq != 2 {
+		t.Fatalf("resumed at %v, want the update after cursor %d", e, created.Cursor)
+	}
+	if e := nextEvent(t, resumed); e.FileName != "b" {
+		t.Fatalf("resumed stream sent %v, want b", e)
+	}
+}
+
+func TestWatchRefusesPrunedCursor(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	n := newSoloNode(storage.NewMemStore())
+	client := serveNode(t, n)
+	for _, name := range []string{"a", "b"} {
+		if _, err := writeFile(ctx, n, "/docs", name, []byte(name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if pruned, err := n.Storage.PruneEvents(ctx, time.Now()); err != nil || pruned != 2 {
+		t.Fatalf("pruned %d, %v", pruned, err)
+	}
+
+	stream, err := client.Watch(ctx, &rpcpb.WatchReq{Folder: "/docs", SinceSeq: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stream.Recv(); status.Code(err) != codes.OutOfRange {
+		t.Fatalf("watch from a pruned cursor: %v, want OutOfRange", err)
+	}
+
+	// Watching from now still works and picks up new changes.
+	live, err := client.Watch(ctx, &rpcpb.WatchReq{Folder: "/docs"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if _, err := writeFile(ctx, n, "/docs", "c", []byte("c")); err != nil {
+		t.Fatal(err)
+	}
+	if e := nextEvent(t, live); e.FileName != "c" || e.Cursor != 3 {
+		t.Fatalf("event after pruning: %v, want c at cursor 3", e)
+	}
+}
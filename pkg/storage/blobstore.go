@@ -0,0 +1,82 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+)
+
+// BlobInfo describes a stored blob.
+type BlobInfo struct {
+	Key     string
+	Size    int64
+	ModTime time.Time
+}
+
+// BlobWriter writes a new blob. Nothing is visible under its key until
+// Close succeeds, so a reader never sees a partial blob; Abort discards
+// what was written instead.
+type BlobWriter interface {
+	io.Writer
+	Close() error
+	Abort()
+}
+
+// BlobStore holds a node's bytes: content chunks and manifests, under
+// slash-separated keys such as "chunks/ab/ab12..." and
+// "manifests/docs/a.txt/3.manifest". It is separate from StorageClient,
+// which records which version of a file is current; the blob store only
+// holds what those versions contain.
+//
+// Blobs that don't exist are reported with an error wrapping ErrNotFound.
+type BlobStore interface {
+	// Create starts writing the blob at key. Closing the writer replaces
+	// any blob already there.
+	Create(ctx context.Context, key string) (BlobWriter, error)
+
+	// Open returns a reader for the blob at key.
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+
+	Stat(ctx context.Context, key string) (BlobInfo, error)
+
+	// Delete removes the blob at key. Deleting a missing blob is not an
+	// error.
+	Delete(ctx context.Context, key string) error
+
+	// Touch sets a blob's ModTime to now, so the collector counts it as
+	// recently used.
+	Touch(ctx context.Context, key string) error
+
+	// List calls fn for every blob whose key starts with prefix, in key
+	// order, stopping at the first error fn returns. fn may delete the
+	// blob it is given.
+	List(ctx context.Context, prefix string, fn func(BlobInfo) error) error
+}
+
+// ReadBlob returns the contents of the blob at key.
+func ReadBlob(ctx context.Context, blobs BlobStore, key string) ([]byte, error) {
+	r, err := blobs.Open(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
+
+// WriteBlob stores data as the blob at key.
+func WriteBlob(ctx context.Context, blobs BlobStore, key string, data []byte) error {
+	w, err := blobs.Create(ctx, key)
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		w.Abort()
+		return err
+	}
+	return w.Close()
+}
+
+func blobNotFound(key string) error {
+	return fmt.Errorf("blob %s: %w", key, ErrNotFound)
+}
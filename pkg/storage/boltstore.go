@@ -0,0 +1,930 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// BoltStore is a StorageClient kept in a single bbolt file in the node's
+// data directory, so a node needs no external database. Every call is one
+// bbolt transaction, which is fsynced on commit, so a crash leaves either
+// the old or the new state. Like MemStore it is private to one node.
+//
+// Files are keyed by folder + "\x00" + file name, which sorts the same way
+// as (folder, file_name) in CraqStore, so listings are prefix scans. Only
+// the latest version of a file is kept: older versions live on as
+// manifests in the blob store until the collector drops them.
+type BoltStore struct {
+	db *bolt.DB
+}
+
+var (
+	bucketFiles         = []byte("files")
+	bucketDirs          = []byte("dirs") // parent + "\x00" + name + "/" → created at
+	bucketEvents        = []byte("events")
+	bucketEventKeys     = []byte("event_keys")
+	bucketSnapshots     = []byte("snapshots")
+	bucketSnapshotFiles = []byte("snapshot_files") // snapshot + "\x00" + file key → seq
+	bucketSnapshotPins  = []byte("snapshot_pins")  // file key + "\x00" + snapshot → seq
+	bucketQuotas        = []byte("quotas")
+	bucketTTLs          = []byte("ttls")
+	bucketMeta          = []byte("meta")
+
+	metaEventsPruned = []byte("events_pruned") // cursor of the newest pruned event
+)
+
+// NewBoltStore opens (or creates) the metadata file at path.
+func NewBoltStore(path string) (*BoltStore, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %w", path, err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{bucketFiles, bucketDirs, bucketEvents, bucketEventKeys,
+			bucketSnapshots, bucketSnapshotFiles, bucketSnapshotPins, bucketQuotas, bucketTTLs, bucketMeta} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		if err := boltSlashDirKeys(tx.Bucket(bucketDirs)); err != nil {
+			return err
+		}
+		// Files written before versions were dropped still hold a copy of
+		// every version's metadata that nothing reads.
+		if err := tx.DeleteBucket([]byte("versions")); err != nil && !errors.Is(err, bolt.ErrBucketNotFound) {
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &BoltStore{db: db}, nil
+}
+
+func (store *BoltStore) Close() error {
+	return store.db.Close()
+}
+
+// view and update run fn in a read-only or read-write transaction unless
+// ctx is already done. bbolt transactions can't be interrupted once begun.
+func (store *BoltStore) view(ctx context.Context, fn func(*bolt.Tx) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return store.db.View(fn)
+}
+
+func (store *BoltStore) update(ctx context.Context, fn func(*bolt.Tx) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return store.db.Update(fn)
+}
+
+func boltKey(parts ...string) []byte {
+	return []byte(strings.Join(parts, "\x00"))
+}
+
+func getJSON(b *bolt.Bucket, key []byte, v any) (bool, error) {
+	data := b.Get(key)
+	if data == nil {
+		return false, nil
+	}
+	return true, json.Unmarshal(data, v)
+}
+
+// boltSlashDirKeys rewrites folder keys stored without the trailing "/"
+// dirKey now adds.
+func boltSlashDirKeys(b *bolt.Bucket) error {
+	var old [][]byte
+	err := b.ForEach(func(k, v []byte) error {
+		if !bytes.HasSuffix(k, []byte("/")) {
+			old = append(old, bytes.Clone(k))
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, k := range old {
+		v := bytes.Clone(b.Get(k))
+		if err := b.Delete(k); err != nil {
+			return err
+		}
+		if err := b.Put(append(k, '/'), v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func putJSON(b *bolt.Bucket, key []byte, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return b.Put(key, data)
+}
+
+func putUint64(b *bolt.Bucket, key []byte, v uint64) error {
+	return b.Put(key, binary.BigEndian.AppendUint64(nil, v))
+}
+
+// scanPrefix calls fn for each key with prefix, in key order (reversed
+// when desc), starting after the key after if it is set, until fn returns
+// false.
+func scanPrefix(b *bolt.Bucket, prefix, after []byte, desc bool, fn func(k, v []byte) (bool, error)) error {
+	c := b.Cursor()
+	var k, v []byte
+
+	switch {
+	case !desc && after == nil:
+		k, v = c.Seek(prefix)
+	case !desc:
+		k, v = c.Seek(after)
+		if bytes.Equal(k, after) {
+			k, v = c.Next()
+		}
+	default:
+		from := after
+		if from == nil {
+			from = prefixEnd(prefix)
+		}
+		if from == nil {
+			k, v = c.Last()
+		} else if k, v = c.Seek(from); k == nil {
+			k, v = c.Last()
+		} else {
+			k, v = c.Prev()
+		}
+	}
+
+	for k != nil && bytes.HasPrefix(k, prefix) {
+		more, err := fn(k, v)
+		if err != nil || !more {
+			return err
+		}
+		if desc {
+			k, v = c.Prev()
+		} else {
+			k, v = c.Next()
+		}
+	}
+	return nil
+}
+
+// prefixEnd is the first key after every key with prefix, or nil if there
+// is none.
+func prefixEnd(prefix []byte) []byte {
+	end := bytes.Clone(prefix)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xff {
+			end[i]++
+			return end[:i+1]
+		}
+	}
+	return nil
+}
+
+// treePrefix is the key prefix shared by every file in folder and below.
+// Keys it matches outside the tree ("/ab" for "/a") are filtered by inTree.
+func treePrefix(folder string) []byte {
+	if folder == "/" {
+		return []byte("/")
+	}
+	return []byte(folder)
+}
+
+func (store *BoltStore) Put(ctx context.Context, c Chunk) error {
+	return store.update(ctx, func(tx *bolt.Tx) error {
+		return boltPut(tx, c)
+	})
+}
+
+func (store *BoltStore) PutBatch(ctx context.Context, chunks []Chunk) error {
+	return store.update(ctx, func(tx *bolt.Tx) error {
+		for _, c := range chunks {
+			if err := boltPut(tx, c); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// boltPut stores c as the dirty latest version unless a version at least
+// as new is stored. A tombstoned file starts over, as in CraqStore.
+func boltPut(tx *bolt.Tx, c Chunk) error {
+	files := tx.Bucket(bucketFiles)
+	key := boltKey(c.Folder, c.FileName)
+	now := time.Now()
+
+	var old Chunk
+	found, err := getJSON(files, key, &old)
+	if err != nil {
+		return err
+	}
+	if found && old.Seq >= c.Seq {
+		return nil
+	}
+
+	c.State = Dirty
+	c.ModifiedAt = now
+	c.Deleted = false
+	c.CreatedAt, c.CleanSeq = now, 0
+	if found {
+		c.CreatedAt = old.CreatedAt
+		if !old.Deleted {
+			c.CleanSeq = old.CleanSeq
+		}
+	}
+	if err := putJSON(files, key, c); err != nil {
+		return err
+	}
+	return boltInsertDirs(tx, ancestors(c.Folder), now)
+}
+
+func (store *BoltStore) MarkClean(ctx context.Context, folder, fileName string, seq uint64) error {
+	return store.MarkCleanBatch(ctx, []Chunk{{Folder: folder, FileName: fileName, Seq: seq}})
+}
+
+func (store *BoltStore) MarkCleanBatch(ctx context.Context, chunks []Chunk) error {
+	return store.update(ctx, func(tx *bolt.Tx) error {
+		files := tx.Bucket(bucketFiles)
+		for _, c := range chunks {
+			key := boltKey(c.Folder, c.FileName)
+			var f Chunk
+			found, err := getJSON(files, key, &f)
+			if err != nil {
+				return err
+			}
+			if !found {
+				return markCleanError(c.Folder, c.FileName, c.Seq, 0)
+			}
+			if f.Seq != c.Seq {
+				return markCleanError(c.Folder, c.FileName, c.Seq, f.Seq)
+			}
+			f.State = Clean
+			f.CleanSeq = f.Seq
+			if err := putJSON(files, key, f); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (store *BoltStore) GetLatest(ctx context.Context, folder, fileName string) (Chunk, error) {
+	var c Chunk
+	err := store.view(ctx, func(tx *bolt.Tx) error {
+		found, err := getJSON(tx.Bucket(bucketFiles), boltKey(folder, fileName), &c)
+		if err == nil && !found {
+			err = fmt.Errorf("%s/%s: %w", folder, fileName, ErrNotFound)
+		}
+		return err
+	})
+	return c, err
+}
+
+// ListFilesInFolder returns one page of the entries under q.Folder, sorted
+// by name. Non-recursive listings report each subfolder once, as "name/".
+func (store *BoltStore) ListFilesInFolder(ctx context.Context, q ListQuery) (ListPage, error) {
+	var page ListPage
+	err := store.view(ctx, func(tx *bolt.Tx) error {
+		var err error
+		if q.Recursive {
+			page, err = boltListRecursive(tx, q)
+		} else {
+			page, err = boltListChildren(tx, q)
+		}
+		return err
+	})
+	return page, err
+}
+
+func boltListRecursive(tx *bolt.Tx, q ListQuery) (ListPage, error) {
+	var after []byte
+	if q.After != "" {
+		after = []byte(q.After)
+	}
+
+	now := time.Now()
+	p := &pager{q: q}
+	err := scanPrefix(tx.Bucket(bucketFiles), treePrefix(q.Folder), after, q.Desc, func(k, v []byte) (bool, error) {
+		var c Chunk
+		if err := json.Unmarshal(v, &c); err != nil {
+			return false, err
+		}
+		if !inTree(c.Folder, q.Folder) || !live(&c, now) {
+			return true, nil
+		}
+		return !p.add(Entry{Name: relativeName(q.Folder, c), Chunk: c}, string(k)), nil
+	})
+	return p.page(), err
+}
+
+// boltListChildren merges the files and subfolders of q.Folder. Both
+// buckets are seeked to the cursor and read only until one page of
+// matching entries is found in each, so a page costs the same however
+// large the folder is.
+func boltListChildren(tx *bolt.Tx, q ListQuery) (ListPage, error) {
+	now := time.Now()
+	folder := CleanDir(q.Folder)
+
+	var after []byte
+	if q.After != "" {
+		after = boltKey(folder, q.After)
+	}
+	files, dirs := &pager{q: q}, &pager{q: q}
+	err := scanPrefix(tx.Bucket(bucketFiles), boltKey(folder, ""), after, q.Desc, func(k, v []byte) (bool, error) {
+		var c Chunk
+		if err := json.Unmarshal(v, &c); err != nil {
+			return false, err
+		}
+		if !live(&c, now) {
+			return true, nil
+		}
+		return !files.add(Entry{Name: c.FileName, Chunk: c}, c.FileName), nil
+	})
+	if err != nil {
+		return ListPage{}, err
+	}
+	err = scanPrefix(tx.Bucket(bucketDirs), boltKey(folder, ""), after, q.Desc, func(k, v []byte) (bool, error) {
+		name := strings.TrimPrefix(string(k), folder+"\x00")
+		return !dirs.add(Entry{Name: name, IsDir: true}, name), nil
+	})
+	if err != nil {
+		return ListPage{}, err
+	}
+
+	p := &pager{q: q}
+	i, j := 0, 0
+	for i < len(files.entries) || j < len(dirs.entries) {
+		var e Entry
+		if j == len(dirs.entries) || (i < len(files.entries) && q.before(files.entries[i].Name, dirs.entries[j].Name)) {
+			e, i = files.entries[i], i+1
+		} else {
+			e, j = dirs.entries[j], j+1
+		}
+		if p.add(e, e.Name) {
+			break
+		}
+	}
+	return p.page(), nil
+}
+
+// dirKey is the key of dir in the dirs bucket: its parent, then its name
+// with a trailing "/" so the keys under a parent sort as the listing
+// names "name/" do.
+func dirKey(dir string) []byte {
+	return boltKey(path.Dir(dir), path.Base(dir)+"/")
+}
+
+func (store *BoltStore) Mkdir(ctx context.Context, dir string, parents bool) error {
+	dirs := ancestors(dir)
+	if len(dirs) == 0 {
+		return nil // root
+	}
+
+	return store.update(ctx, func(tx *bolt.Tx) error {
+		if !parents && len(dirs) > 1 {
+			if _, err := boltGetDir(tx, dirs[len(dirs)-2]); err != nil {
+				return err
+			}
+			dirs = dirs[len(dirs)-1:]
+		}
+		return boltInsertDirs(tx, dirs, time.Now())
+	})
+}
+
+func boltInsertDirs(tx *bolt.Tx, dirs []string, now time.Time) error {
+	b := tx.Bucket(bucketDirs)
+	for _, d := range dirs {
+		if key := dirKey(d); b.Get(key) == nil {
+			created, err := now.MarshalBinary()
+			if err != nil {
+				return err
+			}
+			if err := b.Put(key, created); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (store *BoltStore) Rmdir(ctx context.Context, dir string, recursive bool) error {
+	dir = CleanDir(dir)
+
+	return store.update(ctx, func(tx *bolt.Tx) error {
+		if _, err := boltGetDir(tx, dir); err != nil {
+			return err
+		}
+		files, dirs := tx.Bucket(bucketFiles), tx.Bucket(bucketDirs)
+
+		if !recursive {
+			if k, _ := dirs.Cursor().Seek(boltKey(dir, "")); k != nil && bytes.HasPrefix(k, boltKey(dir, "")) {
+				return ErrDirNotEmpty
+			}
+			err := scanPrefix(files, boltKey(dir, ""), nil, false, func(k, v []byte) (bool, error) {
+				var c Chunk
+				if err := json.Unmarshal(v, &c); err != nil {
+					return false, err
+				}
+				if !c.Deleted {
+					return false, ErrDirNotEmpty
+				}
+				return true, nil
+			})
+			if err != nil {
+				return err
+			}
+			return dirs.Delete(dirKey(dir))
+		}
+
+		// Collect first: a bucket must not be changed while a cursor walks it.
+		now := time.Now()
+		var tombstones []Chunk
+		err := scanPrefix(files, treePrefix(dir), nil, false, func(k, v []byte) (bool, error) {
+			var c Chunk
+			if err := json.Unmarshal(v, &c); err != nil {
+				return false, err
+			}
+			if inTree(c.Folder, dir) && !c.Deleted {
+				c.Deleted = true
+				c.ModifiedAt = now
+				tombstones = append(tombstones, c)
+			}
+			return true, nil
+		})
+		if err != nil {
+			return err
+		}
+		for _, c := range tombstones {
+			if err := putJSON(files, boltKey(c.Folder, c.FileName), c); err != nil {
+				return err
+			}
+		}
+
+		var doomed [][]byte
+		err = scanPrefix(dirs, treePrefix(dir), nil, false, func(k, v []byte) (bool, error) {
+			if parent, _, _ := strings.Cut(string(k), "\x00"); inTree(parent, dir) {
+				doomed = append(doomed, bytes.Clone(k))
+			}
+			return true, nil
+		})
+		if err != nil {
+			return err
+		}
+		for _, k := range append(doomed, dirKey(dir)) {
+			if err := dirs.Delete(k); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (store *BoltStore) GetDir(ctx context.Context, dir string) (Dir, error) {
+	var d Dir
+	err := store.view(ctx, func(tx *bolt.Tx) error {
+		var err error
+		d, err = boltGetDir(tx, CleanDir(dir))
+		return err
+	})
+	return d, err
+}
+
+func boltGetDir(tx *bolt.Tx, dir string) (Dir, error) {
+	if dir == "/" {
+		return Dir{Path: "/"}, nil
+	}
+	data := tx.Bucket(bucketDirs).Get(dirKey(dir))
+	if data == nil {
+		return Dir{}, ErrDirNotFound
+	}
+	d := Dir{Path: dir}
+	return d, d.CreatedAt.UnmarshalBinary(data)
+}
+
+func (store *BoltStore) RecordEvent(ctx context.Context, e Event) error {
+	return store.update(ctx, func(tx *bolt.Tx) error {
+		keys := tx.Bucket(bucketEventKeys)
+		k := eventDedupKey(e)
+		if keys.Get(k) != nil {
+			return nil
+		}
+
+		events := tx.Bucket(bucketEvents)
+		id, err := events.NextSequence()
+		if err != nil {
+			return err
+		}
+		e.ID = id
+		e.CommittedAt = time.Now()
+		if err := putUint64(keys, k, id); err != nil {
+			return err
+		}
+		return putJSON(events, binary.BigEndian.AppendUint64(nil, id), e)
+	})
+}
+
+func eventDedupKey(e Event) []byte {
+	return boltKey(e.Folder, e.FileName, strconv.FormatUint(e.Seq, 10), strconv.Itoa(int(e.Kind)))
+}
+
+func (store *BoltStore) EventsSince(ctx context.Context, cursor uint64, folder string, recursive bool, limit int) ([]Event, error) {
+	var events []Event
+	err := store.view(ctx, func(tx *bolt.Tx) error {
+		if data := tx.Bucket(bucketMeta).Get(metaEventsPruned); data != nil && cursor < binary.BigEndian.Uint64(data) {
+			return ErrEventsPruned
+		}
+		after := binary.BigEndian.AppendUint64(nil, cursor)
+		return scanPrefix(tx.Bucket(bucketEvents), nil, after, false, func(k, v []byte) (bool, error) {
+			if len(events) == limit {
+				return false, nil
+			}
+			var e Event
+			if err := json.Unmarshal(v, &e); err != nil {
+				return false, err
+			}
+			if e.Folder == folder || (recursive && inTree(e.Folder, folder)) {
+				events = append(events, e)
+			}
+			return true, nil
+		})
+	})
+	return events, err
+}
+
+func (store *BoltStore) LastEventID(ctx context.Context) (uint64, error) {
+	var id uint64
+	err := store.view(ctx, func(tx *bolt.Tx) error {
+		id = tx.Bucket(bucketEvents).Sequence()
+		return nil
+	})
+	return id, err
+}
+
+// PruneEvents drops events from the oldest on, which is also commit order.
+func (store *BoltStore) PruneEvents(ctx context.Context, before time.Time) (int, error) {
+	n := 0
+	err := store.update(ctx, func(tx *bolt.Tx) error {
+		n = 0
+		events, keys := tx.Bucket(bucketEvents), tx.Bucket(bucketEventKeys)
+		var last uint64
+		c := events.Cursor()
+		for k, v := c.First(); k != nil; k, v = c.First() {
+			var e Event
+			if err := json.Unmarshal(v, &e); err != nil {
+				return err
+			}
+			if !e.CommittedAt.Before(before) {
+				break
+			}
+			if err := keys.Delete(eventDedupKey(e)); err != nil {
+				return err
+			}
+			if err := c.Delete(); err != nil {
+				return err
+			}
+			last = e.ID
+			n++
+		}
+		if n == 0 {
+			return nil
+		}
+		return putUint64(tx.Bucket(bucketMeta), metaEventsPruned, last)
+	})
+	return n, err
+}
+
+func (store *BoltStore) CreateSnapshot(ctx context.Context, name, folder string) (Snapshot, error) {
+	folder = CleanDir(folder)
+	snap := Snapshot{Name: name, Folder: folder, CreatedAt: time.Now()}
+
+	err := store.update(ctx, func(tx *bolt.Tx) error {
+		snaps := tx.Bucket(bucketSnapshots)
+		if snaps.Get([]byte(name)) != nil {
+			return ErrSnapshotExists
+		}
+
+		var pinned []SnapshotFile
+		err := scanPrefix(tx.Bucket(bucketFiles), treePrefix(folder), nil, false, func(k, v []byte) (bool, error) {
+			var c Chunk
+			if err := json.Unmarshal(v, &c); err != nil {
+				return false, err
+			}
+			if inTree(c.Folder, folder) && live(&c, snap.CreatedAt) {
+				pinned = append(pinned, SnapshotFile{Folder: c.Folder, FileName: c.FileName, Seq: c.CleanSeq})
+			}
+			return true, nil
+		})
+		if err != nil {
+			return err
+		}
+		return putSnapshotTx(tx, &snap, pinned)
+	})
+	if err != nil {
+		return Snapshot{}, err
+	}
+	return snap, nil
+}
+
+func (store *BoltStore) PutSnapshot(ctx context.Context, snap Snapshot, files []SnapshotFile) error {
+	return store.update(ctx, func(tx *bolt.Tx) error {
+		if tx.Bucket(bucketSnapshots).Get([]byte(snap.Name)) != nil {
+			return ErrSnapshotExists
+		}
+		return putSnapshotTx(tx, &snap, files)
+	})
+}
+
+// putSnapshotTx records snap pinning files, setting its file count.
+func putSnapshotTx(tx *bolt.Tx, snap *Snapshot, pinned []SnapshotFile) error {
+	files, pins := tx.Bucket(bucketSnapshotFiles), tx.Bucket(bucketSnapshotPins)
+	for _, f := range pinned {
+		if err := putUint64(files, boltKey(snap.Name, f.Folder, f.FileName), f.Seq); err != nil {
+			return err
+		}
+		if err := putUint64(pins, boltKey(f.Folder, f.FileName, snap.Name), f.Seq); err != nil {
+			return err
+		}
+	}
+	snap.FileCount = uint64(len(pinned))
+	return putJSON(tx.Bucket(bucketSnapshots), []byte(snap.Name), snap)
+}
+
+func (store *BoltStore) SnapshotFiles(ctx context.Context, name string) ([]SnapshotFile, error) {
+	var files []SnapshotFile
+	err := store.view(ctx, func(tx *bolt.Tx) error {
+		if tx.Bucket(bucketSnapshots).Get([]byte(name)) == nil {
+			return ErrSnapshotNotFound
+		}
+		return scanPrefix(tx.Bucket(bucketSnapshotFiles), boltKey(name, ""), nil, false, func(k, v []byte) (bool, error) {
+			// name\x00folder\x00file
+			parts := strings.SplitN(strings.TrimPrefix(string(k), name+"\x00"), "\x00", 2)
+			if len(parts) != 2 {
+				return false, fmt.Errorf("malformed snapshot file key %q", k)
+			}
+			files = append(files, SnapshotFile{Folder: parts[0], FileName: parts[1], Seq: binary.BigEndian.Uint64(v)})
+			return true, nil
+		})
+	})
+	return files, err
+}
+
+func (store *BoltStore) GetSnapshot(ctx context.Context, name string) (Snapshot, error) {
+	var snap Snapshot
+	err := store.view(ctx, func(tx *bolt.Tx) error {
+		found, err := getJSON(tx.Bucket(bucketSnapshots), []byte(name), &snap)
+		if err == nil && !found {
+			err = ErrSnapshotNotFound
+		}
+		return err
+	})
+	return snap, err
+}
+
+func (store *BoltStore) ListSnapshots(ctx context.Context) ([]Snapshot, error) {
+	snaps := []Snapshot{}
+	err := store.view(ctx, func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketSnapshots).ForEach(func(k, v []byte) error {
+			var snap Snapshot
+			if err := json.Unmarshal(v, &snap); err != nil {
+				return err
+			}
+			snaps = append(snaps, snap)
+			return nil
+		})
+	})
+	return snaps, err
+}
+
+func (store *BoltStore) DeleteSnapshot(ctx context.Context, name string) error {
+	return store.update(ctx, func(tx *bolt.Tx) error {
+		snaps := tx.Bucket(bucketSnapshots)
+		if snaps.Get([]byte(name)) == nil {
+			return ErrSnapshotNotFound
+		}
+
+		files, pins := tx.Bucket(bucketSnapshotFiles), tx.Bucket(bucketSnapshotPins)
+		var keys [][]byte
+		err := scanPrefix(files, boltKey(name, ""), nil, false, func(k, v []byte) (bool, error) {
+			keys = append(keys, bytes.Clone(k))
+			return true, nil
+		})
+		if err != nil {
+			return err
+		}
+		for _, k := range keys {
+			// name\x00folder\x00file → folder\x00file\x00name
+			fileKey := strings.TrimPrefix(string(k), name+"\x00")
+			if err := pins.Delete(boltKey(fileKey, name)); err != nil {
+				return err
+			}
+			if err := files.Delete(k); err != nil {
+				return err
+			}
+		}
+		return snaps.Delete([]byte(name))
+	})
+}
+
+func (store *BoltStore) SnapshotSeq(ctx context.Context, name, folder, fileName string) (uint64, error) {
+	var seq uint64
+	err := store.view(ctx, func(tx *bolt.Tx) error {
+		if tx.Bucket(bucketSnapshots).Get([]byte(name)) == nil {
+			return ErrSnapshotNotFound
+		}
+		data := tx.Bucket(bucketSnapshotFiles).Get(boltKey(name, folder, fileName))
+		if data == nil {
+			return ErrNotInSnapshot
+		}
+		seq = binary.BigEndian.Uint64(data)
+		return nil
+	})
+	return seq, err
+}
+
+func (store *BoltStore) SnapshotSeqs(ctx context.Context, folder, fileName string) ([]uint64, error) {
+	var seqs []uint64
+	err := store.view(ctx, func(tx *bolt.Tx) error {
+		return scanPrefix(tx.Bucket(bucketSnapshotPins), boltKey(folder, fileName, ""), nil, false, func(k, v []byte) (bool, error) {
+			if seq := binary.BigEndian.Uint64(v); !containsUint64(seqs, seq) {
+				seqs = append(seqs, seq)
+			}
+			return true, nil
+		})
+	})
+	return seqs, err
+}
+
+func containsUint64(s []uint64, v uint64) bool {
+	for _, x := range s {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
+
+func (store *BoltStore) SetQuota(ctx context.Context, q Quota) error {
+	q.Folder = CleanDir(q.Folder)
+
+	return store.update(ctx, func(tx *bolt.Tx) error {
+		quotas := tx.Bucket(bucketQuotas)
+		if q.MaxBytes == 0 && q.MaxFiles == 0 {
+			return quotas.Delete([]byte(q.Folder))
+		}
+		return putJSON(quotas, []byte(q.Folder), q)
+	})
+}
+
+func (store *BoltStore) ListQuotas(ctx context.Context) ([]Quota, error) {
+	quotas := []Quota{}
+	err := store.view(ctx, func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketQuotas).ForEach(func(k, v []byte) error {
+			var q Quota
+			if err := json.Unmarshal(v, &q); err != nil {
+				return err
+			}
+			quotas = append(quotas, q)
+			return nil
+		})
+	})
+	return quotas, err
+}
+
+func (store *BoltStore) QuotasFor(ctx context.Context, folder string) ([]Quota, error) {
+	var quotas []Quota
+	err := store.view(ctx, func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketQuotas)
+		for _, d := range append([]string{"/"}, ancestors(folder)...) {
+			var q Quota
+			found, err := getJSON(b, []byte(d), &q)
+			if err != nil {
+				return err
+			}
+			if found {
+				quotas = append(quotas, q)
+			}
+		}
+		return nil
+	})
+	return quotas, err
+}
+
+func (store *BoltStore) Usage(ctx context.Context, folder string) (Usage, error) {
+	folder = CleanDir(folder)
+
+	var u Usage
+	err := store.view(ctx, func(tx *bolt.Tx) error {
+		return scanPrefix(tx.Bucket(bucketFiles), treePrefix(folder), nil, false, func(k, v []byte) (bool, error) {
+			var c Chunk
+			if err := json.Unmarshal(v, &c); err != nil {
+				return false, err
+			}
+			if inTree(c.Folder, folder) && !c.Deleted {
+				u.Bytes += c.Size
+				u.Files++
+			}
+			return true, nil
+		})
+	})
+	return u, err
+}
+
+func (store *BoltStore) DeleteFile(ctx context.Context, folder, fileName string, seq uint64) error {
+	return store.update(ctx, func(tx *bolt.Tx) error {
+		files := tx.Bucket(bucketFiles)
+		key := boltKey(folder, fileName)
+
+		var c Chunk
+		found, err := getJSON(files, key, &c)
+		if err != nil || !found || c.Seq >= seq {
+			return err
+		}
+		c.Deleted = true
+		c.Seq = seq
+		c.ModifiedAt = time.Now()
+		return putJSON(files, key, c)
+	})
+}
+
+// ExpiredFiles walks every file; an edge node holds few enough that an
+// expiry index isn't worth keeping.
+func (store *BoltStore) ExpiredFiles(ctx context.Context, after string, limit int) ([]Chunk, string, error) {
+	var expired []Chunk
+	var next string
+	err := store.view(ctx, func(tx *bolt.Tx) error {
+		var from []byte
+		if after != "" {
+			from = []byte(after)
+		}
+		now := time.Now()
+		return scanPrefix(tx.Bucket(bucketFiles), nil, from, false, func(k, v []byte) (bool, error) {
+			var c Chunk
+			if err := json.Unmarshal(v, &c); err != nil {
+				return false, err
+			}
+			if c.Deleted || !c.Expired(now) {
+				return true, nil
+			}
+			if len(expired) == limit {
+				last := expired[limit-1]
+				next = last.Folder + "\x00" + last.FileName
+				return false, nil
+			}
+			expired = append(expired, c)
+			return true, nil
+		})
+	})
+	return expired, next, err
+}
+
+func (store *BoltStore) SetFolderTTL(ctx context.Context, folder string, ttl time.Duration) error {
+	folder = CleanDir(folder)
+
+	return store.update(ctx, func(tx *bolt.Tx) error {
+		ttls := tx.Bucket(bucketTTLs)
+		if ttl <= 0 {
+			return ttls.Delete([]byte(folder))
+		}
+		return putUint64(ttls, []byte(folder), uint64(ttl/time.Second))
+	})
+}
+
+func (store *BoltStore) FolderTTL(ctx context.Context, folder string) (time.Duration, error) {
+	var ttl time.Duration
+	err := store.view(ctx, func(tx *bolt.Tx) error {
+		ttls := tx.Bucket(bucketTTLs)
+		dirs := append([]string{"/"}, ancestors(folder)...)
+		for i := len(dirs) - 1; i >= 0; i-- {
+			if data := ttls.Get([]byte(dirs[i])); data != nil {
+				ttl = time.Duration(binary.BigEndian.Uint64(data)) * time.Second
+				return nil
+			}
+		}
+		return nil
+	})
+	return ttl, err
+}
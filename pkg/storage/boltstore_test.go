@@ -0,0 +1,145 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// TestBoltStoreUpgradesOldFiles opens a file written before version
+// copies were dropped and folder keys ended in "/".
+func TestBoltStoreUpgradesOldFiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "meta.db")
+	db, err := bolt.Open(path, 0600, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucket([]byte("versions"))
+		if err != nil {
+			return err
+		}
+		if err := b.Put([]byte("/docs\x00a\x00"), []byte("{}")); err != nil {
+			return err
+		}
+		dirs, err := tx.CreateBucket([]byte("dirs"))
+		if err != nil {
+			return err
+		}
+		created, _ := time.Now().MarshalBinary()
+		return dirs.Put([]byte("/\x00docs"), created)
+	})
+	db.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store, err := NewBoltStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	ctx := context.Background()
+	if _, err := store.GetDir(ctx, "/docs"); err != nil {
+		t.Fatalf("folder made before the upgrade: %v", err)
+	}
+	if err := store.Rmdir(ctx, "/docs", false); err != nil {
+		t.Fatalf("rmdir folder made before the upgrade: %v", err)
+	}
+	if _, err := store.GetDir(ctx, "/docs"); !errors.Is(err, ErrDirNotFound) {
+		t.Fatalf("removed folder: %v, want ErrDirNotFound", err)
+	}
+	for seq := uint64(1); seq <= 3; seq++ {
+		if err := store.Put(ctx, Chunk{Folder: "/docs", FileName: "a", Seq: seq}); err != nil {
+			t.Fatal(err)
+		}
+		if err := store.MarkClean(ctx, "/docs", "a", seq); err != nil {
+			t.Fatal(err)
+		}
+	}
+	store.db.View(func(tx *bolt.Tx) error {
+		tx.ForEach(func(name []byte, _ *bolt.Bucket) error {
+			if string(name) == "versions" {
+				t.Errorf("versions bucket still kept")
+			}
+			return nil
+		})
+		return nil
+	})
+}
+
+// listPages pages through a listing of store, returning every name and
+// the number of pages.
+func listPages(t *testing.T, store StorageClient, q ListQuery) ([]string, int) {
+	t.Helper()
+	var names []string
+	for pages := 1; ; pages++ {
+		page, err := store.ListFilesInFolder(context.Background(), q)
+		if err != nil {
+			t.Fatalf("list page %d: %v", pages, err)
+		}
+		for _, e := range page.Entries {
+			names = append(names, e.Name)
+		}
+		if page.Next == "" {
+			return names, pages
+		}
+		q.After = page.Next
+	}
+}
+
+func TestBoltStorePagesChildrenLikeMemStore(t *testing.T) {
+	ctx := context.Background()
+	bolt, err := NewBoltStore(filepath.Join(t.TempDir(), "meta.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer bolt.Close()
+	mem := NewMemStore()
+
+	// "x-a/" sorts before "x/", and the file "x" before both.
+	for _, store := range []StorageClient{bolt, mem} {
+		for _, dir := range []string{"/docs/x", "/docs/x-a", "/docs/x-a-b", "/docs/xb", "/docs/x/deep", "/other"} {
+			if err := store.Mkdir(ctx, dir, true); err != nil {
+				t.Fatal(err)
+			}
+		}
+		for i, name := range []string{"x", "x.txt", "w", "y", "gone"} {
+			if err := store.Put(ctx, Chunk{Folder: "/docs", FileName: name, Seq: 1}); err != nil {
+				t.Fatal(err)
+			}
+			if err := store.MarkClean(ctx, "/docs", name, 1); err != nil {
+				t.Fatal(err)
+			}
+			if i == 4 {
+				if err := store.DeleteFile(ctx, "/docs", name, 2); err != nil {
+					t.Fatal(err)
+				}
+			}
+		}
+	}
+
+	for _, q := range []ListQuery{
+		{Folder: "/docs", Limit: 2},
+		{Folder: "/docs", Limit: 3, Desc: true},
+		{Folder: "/docs", Limit: 1, Pattern: "x*"},
+		{Folder: "/", Limit: 1},
+	} {
+		want, _ := listPages(t, mem, q)
+		got, pages := listPages(t, bolt, q)
+		if strings.Join(got, ",") != strings.Join(want, ",") {
+			t.Fatalf("bolt lists %+v as %v, memory store as %v", q, got, want)
+		}
+		if wantPages := (len(want) + q.Limit - 1) / q.Limit; pages > wantPages+1 {
+			t.Fatalf("bolt took %d pages for %d entries of %d", pages, len(want), q.Limit)
+		}
+	}
+	if got, _ := listPages(t, bolt, ListQuery{Folder: "/docs", Limit: 100}); strings.Join(got, ",") != "w,x,x-a-b/,x-a/,x.txt,x/,xb/,y" {
+		t.Fatalf("listing %v out of name order", got)
+	}
+}
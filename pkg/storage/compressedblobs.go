@@ -0,0 +1,192 @@
+package storage
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// CompressedBlobStore compresses blobs at rest in another BlobStore,
+// under their key plus the codec's extension (".gz" or ".zst"). Blobs
+// stored before compression was turned on, under the bare key, are still
+// read, so a node can switch it on without rewriting what it holds.
+// Stat reports stored, compressed sizes.
+type CompressedBlobStore struct {
+	inner BlobStore
+	ext   string
+	codec blobCodec
+}
+
+// blobCodec compresses whole blobs; chunks and manifests are small
+// enough to buffer.
+type blobCodec interface {
+	encode(data []byte) ([]byte, error)
+	decode(data []byte) ([]byte, error)
+}
+
+func NewCompressedBlobStore(inner BlobStore, codec string) (*CompressedBlobStore, error) {
+	switch codec {
+	case "gzip":
+		return &CompressedBlobStore{inner: inner, ext: ".gz", codec: gzipCodec{}}, nil
+	case "zstd":
+		enc, err := zstd.NewWriter(nil)
+		if err != nil {
+			return nil, err
+		}
+		dec, err := zstd.NewReader(nil)
+		if err != nil {
+			return nil, err
+		}
+		return &CompressedBlobStore{inner: inner, ext: ".zst", codec: zstdCodec{enc, dec}}, nil
+	default:
+		return nil, fmt.Errorf("unknown blob compression %q: want gzip or zstd", codec)
+	}
+}
+
+type gzipCodec struct{}
+
+func (gzipCodec) encode(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (gzipCodec) decode(data []byte) ([]byte, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(zr)
+}
+
+// zstdCodec shares one encoder and decoder; their EncodeAll and DecodeAll
+// are safe for concurrent use.
+type zstdCodec struct {
+	enc *zstd.Encoder
+	dec *zstd.Decoder
+}
+
+func (c zstdCodec) encode(data []byte) ([]byte, error) {
+	return c.enc.EncodeAll(data, nil), nil
+}
+
+func (c zstdCodec) decode(data []byte) ([]byte, error) {
+	return c.dec.DecodeAll(data, nil)
+}
+
+func (c *CompressedBlobStore) Create(ctx context.Context, key string) (BlobWriter, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return &compressedBlobWriter{store: c, ctx: ctx, key: key}, nil
+}
+
+type compressedBlobWriter struct {
+	store *CompressedBlobStore
+	ctx   context.Context
+	key   string
+	buf   bytes.Buffer
+}
+
+func (w *compressedBlobWriter) Write(p []byte) (int, error) {
+	return w.buf.Write(p)
+}
+
+// Close compresses the blob and stores it. An uncompressed copy left from
+// before is removed.
+func (w *compressedBlobWriter) Close() error {
+	data, err := w.store.codec.encode(w.buf.Bytes())
+	if err != nil {
+		return fmt.Errorf("compress %s: %w", w.key, err)
+	}
+	if err := WriteBlob(w.ctx, w.store.inner, w.key+w.store.ext, data); err != nil {
+		return err
+	}
+	return w.store.inner.Delete(w.ctx, w.key)
+}
+
+func (w *compressedBlobWriter) Abort() {
+	w.buf.Reset()
+}
+
+func (c *CompressedBlobStore) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	data, err := ReadBlob(ctx, c.inner, key+c.ext)
+	if errors.Is(err, ErrNotFound) {
+		return c.inner.Open(ctx, key)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if data, err = c.codec.decode(data); err != nil {
+		return nil, fmt.Errorf("decompress %s: %w", key, err)
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (c *CompressedBlobStore) Stat(ctx context.Context, key string) (BlobInfo, error) {
+	info, err := c.inner.Stat(ctx, key+c.ext)
+	if errors.Is(err, ErrNotFound) {
+		return c.inner.Stat(ctx, key)
+	}
+	info.Key = key
+	return info, err
+}
+
+func (c *CompressedBlobStore) Delete(ctx context.Context, key string) error {
+	if err := c.inner.Delete(ctx, key+c.ext); err != nil {
+		return err
+	}
+	return c.inner.Delete(ctx, key)
+}
+
+func (c *CompressedBlobStore) Touch(ctx context.Context, key string) error {
+	err := c.inner.Touch(ctx, key+c.ext)
+	if errors.Is(err, ErrNotFound) {
+		return c.inner.Touch(ctx, key)
+	}
+	return err
+}
+
+// List reports each blob once under its key. A blob stored both
+// compressed and bare is reported as its compressed copy, the one Open
+// and Stat read.
+func (c *CompressedBlobStore) List(ctx context.Context, prefix string, fn func(BlobInfo) error) error {
+	blobs := make(map[string]BlobInfo)
+	err := c.inner.List(ctx, prefix, func(info BlobInfo) error {
+		key, compressed := strings.CutSuffix(info.Key, c.ext)
+		if _, seen := blobs[key]; seen && !compressed {
+			return nil
+		}
+		info.Key = key
+		blobs[key] = info
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	infos := make([]BlobInfo, 0, len(blobs))
+	for _, info := range blobs {
+		infos = append(infos, info)
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Key < infos[j].Key })
+	for _, info := range infos {
+		if err := fn(info); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package storage
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestCompressedBlobStoreListsEachBlobOnce(t *testing.T) {
+	ctx := context.Background()
+	inner := NewMemBlobStore()
+	c, err := NewCompressedBlobStore(inner, "gzip")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := WriteBlob(ctx, inner, "chunks/ab/abd", []byte("bare only")); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteBlob(ctx, c, "chunks/ab/abe", []byte("compressed only")); err != nil {
+		t.Fatal(err)
+	}
+	// chunks/ab/abc ends up stored both ways, as after a crash between
+	// writing the compressed copy and removing the bare one.
+	if err := WriteBlob(ctx, c, "chunks/ab/abc", []byte("compressed")); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteBlob(ctx, inner, "chunks/ab/abc", []byte("bare and stale")); err != nil {
+		t.Fatal(err)
+	}
+
+	var listed []string
+	sizes := make(map[string]int64)
+	err = c.List(ctx, "chunks/", func(info BlobInfo) error {
+		listed = append(listed, info.Key)
+		sizes[info.Key] = info.Size
+		return nil
+	})
+	if want := []string{"chunks/ab/abc", "chunks/ab/abd", "chunks/ab/abe"}; err != nil || strings.Join(listed, ",") != strings.Join(want, ",") {
+		t.Fatalf("list: %v, %v; want %v", listed, err, want)
+	}
+	info, err := c.Stat(ctx, "chunks/ab/abc")
+	if err != nil || sizes["chunks/ab/abc"] != info.Size {
+		t.Fatalf("listed size %d of a blob stored both ways, Stat says %d (%v)", sizes["chunks/ab/abc"], info.Size, err)
+	}
+}
@@ -3,7 +3,9 @@ package storage
 import (
 	"context"
 	"fmt"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -12,117 +14,785 @@ import (
 )
 
 type CraqStore struct {
-	pool *pgxpool.Pool
+	pool   *pgxpool.Pool
+	commit *groupCommitter // nil when group commit is off
 }
 
+// NewCraqStore connects to the database at dsn, with group commit on at
+// the default window.
 func NewCraqStore(ctx context.Context, dsn string) (*CraqStore, error) {
 	pool, err := pgxpool.New(ctx, dsn)
 	if err != nil {
 		return nil, err
 	}
 
-	return &CraqStore{pool: pool}, nil
+	store := &CraqStore{pool: pool}
+	store.SetGroupCommit(DefaultBatchWindow, DefaultMaxBatch)
+	return store, nil
 }
 
-func (store *CraqStore) Put(seq uint64, fileName, folder, path string) error {
-	return crdbpgx.ExecuteTx(context.Background(), store.pool, pgx.TxOptions{}, func(tx pgx.Tx) error {
-		_, err := tx.Exec(context.Background(), `
-			INSERT INTO chunk_metadata (folder, file_name, seq, state, path)
-			VALUES ($1, $2, $3, 'dirty', $4)
-			ON CONFLICT (folder, file_name) DO UPDATE
-			SET seq = EXCLUDED.seq,
-			    state = 'dirty',
-			    path = EXCLUDED.path
-			WHERE chunk_metadata.seq < EXCLUDED.seq
-		`, folder, fileName, seq, path)
+// SetGroupCommit tunes how Put and MarkClean batch: concurrent calls
+// within window of the first share one transaction, up to maxBatch of
+// them. A zero window gives every call its own transaction. Call it
+// before the store is in use.
+func (store *CraqStore) SetGroupCommit(window time.Duration, maxBatch int) {
+	if window <= 0 {
+		store.commit = nil
+		return
+	}
+	if maxBatch < 1 {
+		maxBatch = DefaultMaxBatch
+	}
+	store.commit = newGroupCommitter(store.pool, window, maxBatch)
+}
+
+// Put also creates the file's folder and its ancestors, so every stored
+// file sits in a real directory.
+func (store *CraqStore) Put(ctx context.Context, c Chunk) error {
+	if store.commit != nil {
+		return store.commit.submit(ctx, &groupOp{put: true, chunk: c})
+	}
+	return crdbpgx.ExecuteTx(ctx, store.pool, pgx.TxOptions{}, func(tx pgx.Tx) error {
+		return putTx(ctx, tx, c)
+	})
+}
+
+func (store *CraqStore) PutBatch(ctx context.Context, chunks []Chunk) error {
+	return crdbpgx.ExecuteTx(ctx, store.pool, pgx.TxOptions{}, func(tx pgx.Tx) error {
+		for _, c := range chunks {
+			if err := putTx(ctx, tx, c); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func putTx(ctx context.Context, tx pgx.Tx, c Chunk) error {
+	_, err := tx.Exec(ctx, `
+		INSERT INTO chunk_metadata (folder, file_name, seq, state, path, size, checksum, expires_at)
+		VALUES ($1, $2, $3, 'dirty', $4, $5, $6, $7)
+		ON CONFLICT (folder, file_name) DO UPDATE
+		SET seq = EXCLUDED.seq,
+		    state = 'dirty',
+		    path = EXCLUDED.path,
+		    size = EXCLUDED.size,
+		    checksum = EXCLUDED.checksum,
+		    modified_at = now(),
+		    expires_at = EXCLUDED.expires_at,
+		    -- a tombstoned file starts over: its old versions stay hidden
+		    clean_seq = CASE WHEN chunk_metadata.deleted THEN 0 ELSE chunk_metadata.clean_seq END,
+		    deleted = false
+		WHERE chunk_metadata.seq < EXCLUDED.seq
+	`, c.Folder, c.FileName, c.Seq, c.Path, c.Size, c.Checksum, nullTime(c.ExpiresAt))
+	if err != nil {
+		return err
+	}
+
+	return insertDirs(ctx, tx, ancestors(c.Folder))
+}
+
+func (store *CraqStore) MarkClean(ctx context.Context, folder, fileName string, seq uint64) error {
+	if store.commit != nil {
+		return store.commit.submit(ctx, &groupOp{chunk: Chunk{Folder: folder, FileName: fileName, Seq: seq}})
+	}
+	return crdbpgx.ExecuteTx(ctx, store.pool, pgx.TxOptions{}, func(tx pgx.Tx) error {
+		return markCleanTx(ctx, tx, folder, fileName, seq)
+	})
+}
+
+func (store *CraqStore) MarkCleanBatch(ctx context.Context, chunks []Chunk) error {
+	return crdbpgx.ExecuteTx(ctx, store.pool, pgx.TxOptions{}, func(tx pgx.Tx) error {
+		for _, c := range chunks {
+			if err := markCleanTx(ctx, tx, c.Folder, c.FileName, c.Seq); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func markCleanTx(ctx context.Context, tx pgx.Tx, folder, fileName string, seq uint64) error {
+	tag, err := tx.Exec(ctx, `
+		UPDATE chunk_metadata
+		SET state = 'clean', clean_seq = seq
+		WHERE folder = $1 AND file_name = $2 AND seq = $3
+	`, folder, fileName, seq)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		var stored uint64
+		err := tx.QueryRow(ctx, `SELECT seq FROM chunk_metadata WHERE folder = $1 AND file_name = $2`,
+			folder, fileName).Scan(&stored)
+		if err != nil && err != pgx.ErrNoRows {
+			return err
+		}
+		return markCleanError(folder, fileName, seq, stored)
+	}
+	return nil
+}
+
+// chunkColumns is the column list scanChunk expects, in order.
+const chunkColumns = `folder, file_name, seq, state, path, size, checksum, created_at, modified_at, deleted, clean_seq, expires_at`
+
+// liveFilter selects files readers may see: not tombstoned, committed at
+// least once and not expired.
+const liveFilter = `NOT deleted AND clean_seq > 0 AND (expires_at IS NULL OR expires_at > now())`
+
+func scanChunk(row pgx.Row) (Chunk, error) {
+	var c Chunk
+	var stateStr string
+	var expiresAt *time.Time
+
+	err := row.Scan(&c.Folder, &c.FileName, &c.Seq, &stateStr, &c.Path,
+		&c.Size, &c.Checksum, &c.CreatedAt, &c.ModifiedAt, &c.Deleted, &c.CleanSeq, &expiresAt)
+	if err != nil {
+		return Chunk{}, err
+	}
+	if expiresAt != nil {
+		c.ExpiresAt = *expiresAt
+	}
+
+	if stateStr == "clean" {
+		c.State = Clean
+	} else {
+		c.State = Dirty
+	}
+	return c, nil
+}
+
+func (store *CraqStore) GetLatest(ctx context.Context, folder, fileName string) (Chunk, error) {
+	chunk, err := scanChunk(store.pool.QueryRow(ctx,
+		`SELECT `+chunkColumns+`
+		 FROM chunk_metadata 
+		 WHERE folder = $1 AND file_name = $2`,
+		folder, fileName))
+	if err == pgx.ErrNoRows {
+		return Chunk{}, fmt.Errorf("%s/%s: %w", folder, fileName, ErrNotFound)
+	}
+	return chunk, err
+}
+
+// listBatch is how many rows a listing reads per query while filling a
+// page, so a selective pattern doesn't pull a whole folder at once.
+const listBatch = 500
+
+// ListFilesInFolder returns one page of the entries under q.Folder, sorted
+// by name. Non-recursive listings report each subfolder once, as "name/".
+func (store *CraqStore) ListFilesInFolder(ctx context.Context, q ListQuery) (ListPage, error) {
+	if q.Recursive {
+		return store.listRecursive(ctx, q)
+	}
+	return store.listChildren(ctx, q)
+}
+
+// listRecursive pages through every file under q.Folder ordered by
+// (folder, file_name); the cursor is that pair.
+func (store *CraqStore) listRecursive(ctx context.Context, q ListQuery) (ListPage, error) {
+	cmp, order := ">", "ASC"
+	if q.Desc {
+		cmp, order = "<", "DESC"
+	}
+	afterFolder, afterFile, hasCursor := strings.Cut(q.After, "\x00")
+
+	p := &pager{q: q}
+	for {
+		query := `SELECT ` + chunkColumns + ` FROM chunk_metadata WHERE (folder = $1 OR folder LIKE $2) AND ` + liveFilter
+		args := []any{q.Folder, subfolderPattern(q.Folder), listBatch}
+		if hasCursor {
+			query += fmt.Sprintf(` AND (folder, file_name) %s ($4, $5)`, cmp)
+			args = append(args, afterFolder, afterFile)
+		}
+		query += fmt.Sprintf(` ORDER BY folder %s, file_name %s LIMIT $3`, order, order)
+
+		rows, err := store.pool.Query(ctx, query, args...)
+		if err != nil {
+			return ListPage{}, err
+		}
+
+		n := 0
+		for rows.Next() {
+			c, err := scanChunk(rows)
+			if err != nil {
+				rows.Close()
+				return ListPage{}, err
+			}
+			n++
+			afterFolder, afterFile, hasCursor = c.Folder, c.FileName, true
+			if p.add(Entry{Name: relativeName(q.Folder, c), Chunk: c}, c.Folder+"\x00"+c.FileName) {
+				break
+			}
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return ListPage{}, err
+		}
+
+		if p.full() || n < listBatch {
+			return p.page(), nil
+		}
+	}
+}
+
+// listChildren pages through the files directly in q.Folder merged with
+// its immediate subfolders; the cursor is the entry name.
+func (store *CraqStore) listChildren(ctx context.Context, q ListQuery) (ListPage, error) {
+	dirs, err := store.childDirs(ctx, q)
+	if err != nil {
+		return ListPage{}, err
+	}
+
+	cmp, order := ">", "ASC"
+	if q.Desc {
+		cmp, order = "<", "DESC"
+	}
+	after := q.After
+
+	p := &pager{q: q}
+	for {
+		rows, err := store.pool.Query(ctx,
+			`SELECT `+chunkColumns+` FROM chunk_metadata
+			 WHERE folder = $1 AND `+liveFilter+` AND ($2 = '' OR file_name `+cmp+` $2)
+			 ORDER BY file_name `+order+` LIMIT $3`,
+			q.Folder, after, listBatch)
+		if err != nil {
+			return ListPage{}, err
+		}
+
+		n := 0
+		full := false
+		for rows.Next() && !full {
+			c, err := scanChunk(rows)
+			if err != nil {
+				rows.Close()
+				return ListPage{}, err
+			}
+			n++
+			after = c.FileName
+
+			for len(dirs) > 0 && q.before(dirs[0], c.FileName) && !full {
+				full = p.add(Entry{Name: dirs[0], IsDir: true}, dirs[0])
+				dirs = dirs[1:]
+			}
+			if !full {
+				full = p.add(Entry{Name: c.FileName, Chunk: c}, c.FileName)
+			}
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return ListPage{}, err
+		}
+
+		if full {
+			return p.page(), nil
+		}
+		if n < listBatch {
+			break
+		}
+	}
+
+	for _, d := range dirs {
+		if p.add(Entry{Name: d, IsDir: true}, d) {
+			break
+		}
+	}
+	return p.page(), nil
+}
+
+// childDirs returns the immediate subfolders of q.Folder that sort after
+// the cursor, in query order.
+func (store *CraqStore) childDirs(ctx context.Context, q ListQuery) ([]string, error) {
+	rows, err := store.pool.Query(ctx,
+		`SELECT name FROM directories WHERE parent = $1`, CleanDir(q.Folder))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var dirs []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+
+		name += "/"
+		if q.After != "" && !q.before(q.After, name) {
+			continue
+		}
+		dirs = append(dirs, name)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(dirs, func(i, j int) bool { return q.before(dirs[i], dirs[j]) })
+	return dirs, nil
+}
+
+func (store *CraqStore) Mkdir(ctx context.Context, dir string, parents bool) error {
+	dirs := ancestors(dir)
+	if len(dirs) == 0 {
+		return nil // root
+	}
+
+	return crdbpgx.ExecuteTx(ctx, store.pool, pgx.TxOptions{}, func(tx pgx.Tx) error {
+		if !parents && len(dirs) > 1 {
+			if _, err := getDir(ctx, tx, dirs[len(dirs)-2]); err != nil {
+				return err
+			}
+			dirs = dirs[len(dirs)-1:]
+		}
+		return insertDirs(ctx, tx, dirs)
+	})
+}
+
+func (store *CraqStore) Rmdir(ctx context.Context, dir string, recursive bool) error {
+	dir = CleanDir(dir)
+	parent, name := splitDir(dir)
+
+	return crdbpgx.ExecuteTx(ctx, store.pool, pgx.TxOptions{}, func(tx pgx.Tx) error {
+		if _, err := getDir(ctx, tx, dir); err != nil {
+			return err
+		}
+
+		if !recursive {
+			var nonEmpty bool
+			err := tx.QueryRow(ctx, `
+				SELECT EXISTS (SELECT 1 FROM directories WHERE parent = $1)
+				    OR EXISTS (SELECT 1 FROM chunk_metadata WHERE folder = $1 AND NOT deleted)
+			`, dir).Scan(&nonEmpty)
+			if err != nil {
+				return err
+			}
+			if nonEmpty {
+				return ErrDirNotEmpty
+			}
+		} else {
+			_, err := tx.Exec(ctx, `
+				UPDATE chunk_metadata SET deleted = true, modified_at = now()
+				WHERE (folder = $1 OR folder LIKE $2) AND NOT deleted
+			`, dir, subfolderPattern(dir))
+			if err != nil {
+				return err
+			}
+			_, err = tx.Exec(ctx,
+				`DELETE FROM directories WHERE parent = $1 OR parent LIKE $2`, dir, subfolderPattern(dir))
+			if err != nil {
+				return err
+			}
+		}
+
+		_, err := tx.Exec(ctx,
+			`DELETE FROM directories WHERE parent = $1 AND name = $2`, parent, name)
+		return err
+	})
+}
+
+func (store *CraqStore) GetDir(ctx context.Context, dir string) (Dir, error) {
+	return getDir(ctx, store.pool, CleanDir(dir))
+}
 
+// querier is the part of pgxpool.Pool and pgx.Tx that getDir needs.
+type querier interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+func getDir(ctx context.Context, q querier, dir string) (Dir, error) {
+	if dir == "/" {
+		return Dir{Path: "/"}, nil
+	}
+
+	parent, name := splitDir(dir)
+	d := Dir{Path: dir}
+	err := q.QueryRow(ctx,
+		`SELECT created_at FROM directories WHERE parent = $1 AND name = $2`, parent, name).Scan(&d.CreatedAt)
+	if err == pgx.ErrNoRows {
+		return Dir{}, ErrDirNotFound
+	}
+	return d, err
+}
+
+func insertDirs(ctx context.Context, tx pgx.Tx, dirs []string) error {
+	for _, d := range dirs {
+		parent, name := splitDir(d)
+		_, err := tx.Exec(ctx, `
+			INSERT INTO directories (parent, name) VALUES ($1, $2)
+			ON CONFLICT (parent, name) DO NOTHING
+		`, parent, name)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (store *CraqStore) RecordEvent(ctx context.Context, e Event) error {
+	_, err := store.pool.Exec(ctx, `
+		INSERT INTO change_events (folder, file_name, seq, kind, is_dir)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (folder, file_name, seq, kind) DO NOTHING
+	`, e.Folder, e.FileName, e.Seq, e.Kind.String(), e.IsDir)
+	return err
+}
+
+func (store *CraqStore) EventsSince(ctx context.Context, cursor uint64, folder string, recursive bool, limit int) ([]Event, error) {
+	var pruned uint64
+	err := store.pool.QueryRow(ctx, `SELECT through FROM change_events_pruned`).Scan(&pruned)
+	if err != nil && err != pgx.ErrNoRows {
+		return nil, err
+	}
+	if cursor < pruned {
+		return nil, ErrEventsPruned
+	}
+
+	rows, err := store.pool.Query(ctx, `
+		SELECT id, kind, folder, file_name, seq, is_dir, committed_at
+		FROM change_events
+		WHERE id > $1 AND (folder = $2 OR ($3 AND folder LIKE $4))
+		ORDER BY id
+		LIMIT $5
+	`, cursor, folder, recursive, subfolderPattern(folder), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []Event
+	for rows.Next() {
+		var e Event
+		var kind string
+		if err := rows.Scan(&e.ID, &kind, &e.Folder, &e.FileName, &e.Seq, &e.IsDir, &e.CommittedAt); err != nil {
+			return nil, err
+		}
+		e.Kind = parseEventKind(kind)
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
+
+func (store *CraqStore) LastEventID(ctx context.Context) (uint64, error) {
+	var id uint64
+	err := store.pool.QueryRow(ctx,
+		`SELECT greatest(
+			(SELECT COALESCE(max(id), 0) FROM change_events),
+			(SELECT COALESCE(max(through), 0) FROM change_events_pruned))`).Scan(&id)
+	return id, err
+}
+
+// PruneEvents drops every event up to the newest one committed before
+// before, so the change log has no holes. Replicas stamp their own commit
+// times, so this may take an event a little newer than before with it.
+func (store *CraqStore) PruneEvents(ctx context.Context, before time.Time) (int, error) {
+	var n int
+	err := crdbpgx.ExecuteTx(ctx, store.pool, pgx.TxOptions{}, func(tx pgx.Tx) error {
+		n = 0
+		var through *uint64
+		err := tx.QueryRow(ctx, `
+			SELECT max(id) FROM change_events WHERE committed_at < $1
+		`, before).Scan(&through)
+		if err != nil || through == nil {
+			return err
+		}
+		tag, err := tx.Exec(ctx, `DELETE FROM change_events WHERE id <= $1`, *through)
+		if err != nil {
+			return err
+		}
+		n = int(tag.RowsAffected())
+		_, err = tx.Exec(ctx, `
+			INSERT INTO change_events_pruned (singleton, through) VALUES (true, $1)
+			ON CONFLICT (singleton) DO UPDATE
+			SET through = greatest(change_events_pruned.through, EXCLUDED.through)
+		`, *through)
 		return err
 	})
+	return n, err
 }
 
-func (store *CraqStore) MarkClean(folder, fileName string, seq uint64) error {
-	return crdbpgx.ExecuteTx(context.Background(), store.pool, pgx.TxOptions{}, func(tx pgx.Tx) error {
-		tag, err := tx.Exec(context.Background(), `
-			UPDATE chunk_metadata
-			SET state = 'clean'
-			WHERE folder = $1 AND file_name = $2 AND seq = $3
-		`, folder, fileName, seq)
+func (store *CraqStore) CreateSnapshot(ctx context.Context, name, folder string) (Snapshot, error) {
+	folder = CleanDir(folder)
+	var snap Snapshot
+
+	err := crdbpgx.ExecuteTx(ctx, store.pool, pgx.TxOptions{}, func(tx pgx.Tx) error {
+		tag, err := tx.Exec(ctx, `
+			INSERT INTO snapshots (name, folder) VALUES ($1, $2)
+			ON CONFLICT (name) DO NOTHING
+		`, name, folder)
 		if err != nil {
 			return err
 		}
 		if tag.RowsAffected() == 0 {
-			return fmt.Errorf("no matching chunk to mark clean")
+			return ErrSnapshotExists
+		}
+
+		tag, err = tx.Exec(ctx, `
+			INSERT INTO snapshot_files (snapshot, folder, file_name, seq)
+			SELECT $1, folder, file_name, clean_seq FROM chunk_metadata
+			WHERE (folder = $2 OR folder LIKE $3) AND `+liveFilter+`
+		`, name, folder, subfolderPattern(folder))
+		if err != nil {
+			return err
+		}
+
+		snap = Snapshot{Name: name, Folder: folder, FileCount: uint64(tag.RowsAffected())}
+		return tx.QueryRow(ctx, `
+			UPDATE snapshots SET file_count = $2 WHERE name = $1 RETURNING created_at
+		`, name, snap.FileCount).Scan(&snap.CreatedAt)
+	})
+	return snap, err
+}
+
+func (store *CraqStore) PutSnapshot(ctx context.Context, snap Snapshot, files []SnapshotFile) error {
+	return crdbpgx.ExecuteTx(ctx, store.pool, pgx.TxOptions{}, func(tx pgx.Tx) error {
+		tag, err := tx.Exec(ctx, `
+			INSERT INTO snapshots (name, folder, file_count, created_at) VALUES ($1, $2, $3, $4)
+			ON CONFLICT (name) DO NOTHING
+		`, snap.Name, snap.Folder, len(files), snap.CreatedAt)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			return ErrSnapshotExists
+		}
+		for _, f := range files {
+			if _, err := tx.Exec(ctx, `
+				INSERT INTO snapshot_files (snapshot, folder, file_name, seq) VALUES ($1, $2, $3, $4)
+			`, snap.Name, f.Folder, f.FileName, f.Seq); err != nil {
+				return err
+			}
 		}
 		return nil
 	})
 }
 
-func (store *CraqStore) GetLatest(folder, fileName string) (Chunk, bool) {
-	var seq uint64
-	var stateStr, path string
+func (store *CraqStore) SnapshotFiles(ctx context.Context, name string) ([]SnapshotFile, error) {
+	if _, err := store.GetSnapshot(ctx, name); err != nil {
+		return nil, err
+	}
+	rows, err := store.pool.Query(ctx, `
+		SELECT folder, file_name, seq FROM snapshot_files WHERE snapshot = $1 ORDER BY folder, file_name
+	`, name)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
 
-	err := store.pool.QueryRow(context.Background(),
-		`SELECT seq, state, path 
-		 FROM chunk_metadata 
-		 WHERE folder = $1 AND file_name = $2`,
-		folder, fileName).Scan(&seq, &stateStr, &path)
+	var files []SnapshotFile
+	for rows.Next() {
+		var f SnapshotFile
+		if err := rows.Scan(&f.Folder, &f.FileName, &f.Seq); err != nil {
+			return nil, err
+		}
+		files = append(files, f)
+	}
+	return files, rows.Err()
+}
 
+func (store *CraqStore) GetSnapshot(ctx context.Context, name string) (Snapshot, error) {
+	snap := Snapshot{Name: name}
+	err := store.pool.QueryRow(ctx,
+		`SELECT folder, file_count, created_at FROM snapshots WHERE name = $1`, name,
+	).Scan(&snap.Folder, &snap.FileCount, &snap.CreatedAt)
+	if err == pgx.ErrNoRows {
+		return Snapshot{}, ErrSnapshotNotFound
+	}
+	return snap, err
+}
+
+func (store *CraqStore) ListSnapshots(ctx context.Context) ([]Snapshot, error) {
+	rows, err := store.pool.Query(ctx,
+		`SELECT name, folder, file_count, created_at FROM snapshots ORDER BY name`)
 	if err != nil {
-		if err == pgx.ErrNoRows {
-			return Chunk{}, false
-		}
-		panic(err)
+		return nil, err
 	}
+	defer rows.Close()
 
-	var stateVersion VersionState
-	if stateStr == "clean" {
-		stateVersion = Clean
-	} else {
-		stateVersion = Dirty
+	var snaps []Snapshot
+	for rows.Next() {
+		var snap Snapshot
+		if err := rows.Scan(&snap.Name, &snap.Folder, &snap.FileCount, &snap.CreatedAt); err != nil {
+			return nil, err
+		}
+		snaps = append(snaps, snap)
 	}
+	return snaps, rows.Err()
+}
+
+func (store *CraqStore) DeleteSnapshot(ctx context.Context, name string) error {
+	return crdbpgx.ExecuteTx(ctx, store.pool, pgx.TxOptions{}, func(tx pgx.Tx) error {
+		tag, err := tx.Exec(ctx, `DELETE FROM snapshots WHERE name = $1`, name)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			return ErrSnapshotNotFound
+		}
+		_, err = tx.Exec(ctx, `DELETE FROM snapshot_files WHERE snapshot = $1`, name)
+		return err
+	})
+}
 
-	return Chunk{
-		Folder:   folder,
-		FileName: fileName,
-		Seq:      seq,
-		State:    stateVersion,
-		Path:     path,
-	}, true
+func (store *CraqStore) SnapshotSeq(ctx context.Context, name, folder, fileName string) (uint64, error) {
+	var seq uint64
+	err := store.pool.QueryRow(ctx, `
+		SELECT seq FROM snapshot_files WHERE snapshot = $1 AND folder = $2 AND file_name = $3
+	`, name, folder, fileName).Scan(&seq)
+	if err == pgx.ErrNoRows {
+		if _, err := store.GetSnapshot(ctx, name); err != nil {
+			return 0, err
+		}
+		return 0, ErrNotInSnapshot
+	}
+	return seq, err
 }
 
-func (store *CraqStore) ListFilesInFolder(folder string) ([]string, error) {
-	rows, err := store.pool.Query(context.Background(),
-		`SELECT DISTINCT folder, file_name FROM chunk_metadata WHERE folder LIKE $1 || '%'`, folder)
+func (store *CraqStore) SnapshotSeqs(ctx context.Context, folder, fileName string) ([]uint64, error) {
+	rows, err := store.pool.Query(ctx, `
+		SELECT DISTINCT seq FROM snapshot_files WHERE folder = $1 AND file_name = $2
+	`, folder, fileName)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	entries := make(map[string]struct{})
+	var seqs []uint64
+	for rows.Next() {
+		var seq uint64
+		if err := rows.Scan(&seq); err != nil {
+			return nil, err
+		}
+		seqs = append(seqs, seq)
+	}
+	return seqs, rows.Err()
+}
+
+func (store *CraqStore) SetQuota(ctx context.Context, q Quota) error {
+	q.Folder = CleanDir(q.Folder)
+	if q.MaxBytes == 0 && q.MaxFiles == 0 {
+		_, err := store.pool.Exec(ctx, `DELETE FROM folder_quotas WHERE folder = $1`, q.Folder)
+		return err
+	}
+	_, err := store.pool.Exec(ctx, `
+		UPSERT INTO folder_quotas (folder, max_bytes, max_files) VALUES ($1, $2, $3)
+	`, q.Folder, q.MaxBytes, q.MaxFiles)
+	return err
+}
+
+func (store *CraqStore) ListQuotas(ctx context.Context) ([]Quota, error) {
+	return store.queryQuotas(ctx, `SELECT folder, max_bytes, max_files FROM folder_quotas ORDER BY folder`)
+}
+
+func (store *CraqStore) QuotasFor(ctx context.Context, folder string) ([]Quota, error) {
+	dirs := append([]string{"/"}, ancestors(folder)...)
+	return store.queryQuotas(ctx, `
+		SELECT folder, max_bytes, max_files FROM folder_quotas WHERE folder = ANY($1) ORDER BY folder
+	`, dirs)
+}
 
+func (store *CraqStore) queryQuotas(ctx context.Context, sql string, args ...any) ([]Quota, error) {
+	rows, err := store.pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var quotas []Quota
 	for rows.Next() {
-		var fullFolder, fileName string
-		if err := rows.Scan(&fullFolder, &fileName); err != nil {
+		var q Quota
+		if err := rows.Scan(&q.Folder, &q.MaxBytes, &q.MaxFiles); err != nil {
 			return nil, err
 		}
+		quotas = append(quotas, q)
+	}
+	return quotas, rows.Err()
+}
 
-		trimmed := strings.TrimPrefix(fullFolder, folder)
-		if trimmed == "" {
-			// Direct file in the folder
-			entries[fileName] = struct{}{}
-		} else {
-			// Handle subdir case like `/craq/docker` → returns `docker`
-			trimmed = strings.TrimPrefix(trimmed, "/")
-			parts := strings.Split(trimmed, "/")
-			if len(parts) > 0 && parts[0] != "" {
-				entries[parts[0]+"/"] = struct{}{}
-			}
+func (store *CraqStore) Usage(ctx context.Context, folder string) (Usage, error) {
+	folder = CleanDir(folder)
+	var u Usage
+	err := store.pool.QueryRow(ctx, `
+		SELECT COALESCE(sum(size), 0)::INT8, count(*) FROM chunk_metadata
+		WHERE (folder = $1 OR folder LIKE $2) AND NOT deleted
+	`, folder, subfolderPattern(folder)).Scan(&u.Bytes, &u.Files)
+	return u, err
+}
+
+func (store *CraqStore) DeleteFile(ctx context.Context, folder, fileName string, seq uint64) error {
+	_, err := store.pool.Exec(ctx, `
+		UPDATE chunk_metadata SET deleted = true, seq = $3, modified_at = now()
+		WHERE folder = $1 AND file_name = $2 AND seq < $3
+	`, folder, fileName, seq)
+	return err
+}
+
+func (store *CraqStore) ExpiredFiles(ctx context.Context, after string, limit int) ([]Chunk, string, error) {
+	afterFolder, afterFile, _ := strings.Cut(after, "\x00")
+	rows, err := store.pool.Query(ctx, `
+		SELECT `+chunkColumns+` FROM chunk_metadata
+		WHERE NOT deleted AND expires_at <= now() AND (folder, file_name) > ($1, $2)
+		ORDER BY folder, file_name
+		LIMIT $3
+	`, afterFolder, afterFile, limit)
+	if err != nil {
+		return nil, "", err
+	}
+	defer rows.Close()
+
+	var expired []Chunk
+	for rows.Next() {
+		c, err := scanChunk(rows)
+		if err != nil {
+			return nil, "", err
 		}
+		expired = append(expired, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, "", err
 	}
 
-	var result []string
-	for name := range entries {
-		result = append(result, name)
+	next := ""
+	if len(expired) == limit {
+		last := expired[len(expired)-1]
+		next = last.Folder + "\x00" + last.FileName
 	}
-	return result, nil
+	return expired, next, nil
+}
+
+func (store *CraqStore) SetFolderTTL(ctx context.Context, folder string, ttl time.Duration) error {
+	folder = CleanDir(folder)
+	if ttl <= 0 {
+		_, err := store.pool.Exec(ctx, `DELETE FROM folder_ttls WHERE folder = $1`, folder)
+		return err
+	}
+	_, err := store.pool.Exec(ctx,
+		`UPSERT INTO folder_ttls (folder, ttl_seconds) VALUES ($1, $2)`, folder, int64(ttl/time.Second))
+	return err
+}
+
+func (store *CraqStore) FolderTTL(ctx context.Context, folder string) (time.Duration, error) {
+	dirs := append([]string{"/"}, ancestors(folder)...)
+	var seconds int64
+	err := store.pool.QueryRow(ctx, `
+		SELECT ttl_seconds FROM folder_ttls WHERE folder = ANY($1)
+		ORDER BY length(folder) DESC LIMIT 1
+	`, dirs).Scan(&seconds)
+	if err == pgx.ErrNoRows {
+		return 0, nil
+	}
+	return time.Duration(seconds) * time.Second, err
+}
+
+// nullTime maps the zero time to SQL NULL.
+func nullTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+// subfolderPattern is a LIKE pattern matching every folder below folder.
+func subfolderPattern(folder string) string {
+	escaped := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(strings.TrimSuffix(folder, "/"))
+	return escaped + "/%"
 }
@@ -0,0 +1,45 @@
+package storage
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"time"
+)
+
+var (
+	ErrDirNotFound = fmt.Errorf("directory %w", ErrNotFound)
+	ErrDirNotEmpty = fmt.Errorf("directory not empty: %w", ErrConflict)
+)
+
+// Dir is a directory entry. The root "/" always exists and has no entry.
+type Dir struct {
+	Path      string
+	CreatedAt time.Time
+}
+
+// CleanDir normalizes a folder path to the form directories are stored
+// under: rooted, no trailing slash, no "." or ".." elements.
+func CleanDir(folder string) string {
+	return path.Clean("/" + folder)
+}
+
+// splitDir splits a clean, non-root directory path into its parent and
+// name, e.g. "/craq/models" → ("/craq", "models").
+func splitDir(dir string) (parent, name string) {
+	parent, name = path.Split(dir)
+	if parent != "/" {
+		parent = strings.TrimSuffix(parent, "/")
+	}
+	return parent, name
+}
+
+// ancestors returns dir and every directory above it, root excluded,
+// outermost first: "/a/b" → ["/a", "/a/b"].
+func ancestors(dir string) []string {
+	var dirs []string
+	for d := CleanDir(dir); d != "/"; d = path.Dir(d) {
+		dirs = append([]string{d}, dirs...)
+	}
+	return dirs
+}
@@ -0,0 +1,68 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestStoresMkdirAndRmdir(t *testing.T) {
+	ctx := context.Background()
+	bolt, err := NewBoltStore(filepath.Join(t.TempDir(), "meta.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer bolt.Close()
+
+	for name, store := range map[string]StorageClient{"mem": NewMemStore(), "bolt": bolt} {
+		if err := store.Mkdir(ctx, "/a/b", false); !errors.Is(err, ErrDirNotFound) {
+			t.Fatalf("%s: mkdir without its parent: %v, want ErrDirNotFound", name, err)
+		}
+		if err := store.Mkdir(ctx, "/a/b/c", true); err != nil {
+			t.Fatalf("%s: mkdir -p: %v", name, err)
+		}
+		for _, dir := range []string{"/a", "/a/b", "/a/b/c"} {
+			if d, err := store.GetDir(ctx, dir); err != nil || d.Path != dir || d.CreatedAt.IsZero() {
+				t.Fatalf("%s: get %s: %+v, %v", name, dir, d, err)
+			}
+		}
+
+		// A subfolder or a live file keeps a folder; a deleted file doesn't.
+		if err := store.Rmdir(ctx, "/a/b", false); !errors.Is(err, ErrDirNotEmpty) {
+			t.Fatalf("%s: rmdir with a subfolder: %v, want ErrDirNotEmpty", name, err)
+		}
+		if err := store.Put(ctx, Chunk{Folder: "/a/b/c", FileName: "f", Seq: 1}); err != nil {
+			t.Fatal(err)
+		}
+		if err := store.Rmdir(ctx, "/a/b/c", false); !errors.Is(err, ErrDirNotEmpty) {
+			t.Fatalf("%s: rmdir with a file: %v, want ErrDirNotEmpty", name, err)
+		}
+		if err := store.DeleteFile(ctx, "/a/b/c", "f", 2); err != nil {
+			t.Fatal(err)
+		}
+		if err := store.Rmdir(ctx, "/a/b/c", false); err != nil {
+			t.Fatalf("%s: rmdir with only a deleted file: %v", name, err)
+		}
+		if _, err := store.GetDir(ctx, "/a/b/c"); !errors.Is(err, ErrDirNotFound) {
+			t.Fatalf("%s: removed folder: %v, want ErrDirNotFound", name, err)
+		}
+
+		// Recursive removal takes the tree and tombstones its files.
+		if err := store.Put(ctx, Chunk{Folder: "/a/b", FileName: "g", Seq: 1}); err != nil {
+			t.Fatal(err)
+		}
+		if err := store.Rmdir(ctx, "/a", true); err != nil {
+			t.Fatalf("%s: rmdir -r: %v", name, err)
+		}
+		if _, err := store.GetDir(ctx, "/a/b"); !errors.Is(err, ErrDirNotFound) {
+			t.Fatalf("%s: subfolder of a removed tree: %v, want ErrDirNotFound", name, err)
+		}
+		if c, err := store.GetLatest(ctx, "/a/b", "g"); err != nil || !c.Deleted {
+			t.Fatalf("%s: file of a removed tree: %+v, %v; want a tombstone", name, c, err)
+		}
+		if err := store.Rmdir(ctx, "/a", false); !errors.Is(err, ErrDirNotFound) {
+			t.Fatalf("%s: rmdir of a missing folder: %v, want ErrDirNotFound", name, err)
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// DiskBlobStore keeps blobs as files under a root directory, one per key.
+// Writes go through a synced temp file renamed into place, so a crash
+// never leaves a truncated blob under a valid key.
+type DiskBlobStore struct {
+	root string
+}
+
+func NewDiskBlobStore(root string) (*DiskBlobStore, error) {
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return nil, fmt.Errorf("create blob dir: %w", err)
+	}
+	return &DiskBlobStore{root: root}, nil
+}
+
+// tempPrefix marks the temp files of writes in progress, which List skips.
+const tempPrefix = ".tmp-"
+
+func (d *DiskBlobStore) path(key string) (string, error) {
+	clean := path.Clean("/" + key)[1:]
+	if clean == "" || clean != key {
+		return "", fmt.Errorf("invalid blob key %q", key)
+	}
+	return filepath.Join(d.root, filepath.FromSlash(key)), nil
+}
+
+func (d *DiskBlobStore) Create(ctx context.Context, key string) (BlobWriter, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	p, err := d.path(key)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return nil, err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(p), tempPrefix+"*")
+	if err != nil {
+		return nil, err
+	}
+	return &diskBlobWriter{f: tmp, path: p}, nil
+}
+
+type diskBlobWriter struct {
+	f    *os.File
+	path string
+}
+
+func (w *diskBlobWriter) Write(p []byte) (int, error) {
+	return w.f.Write(p)
+}
+
+// Close syncs the blob, so a version logged as received survives a crash,
+// then renames it into place.
+func (w *diskBlobWriter) Close() error {
+	defer os.Remove(w.f.Name())
+	if err := w.f.Sync(); err != nil {
+		w.f.Close()
+		return err
+	}
+	if err := w.f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(w.f.Name(), w.path)
+}
+
+func (w *diskBlobWriter) Abort() {
+	w.f.Close()
+	os.Remove(w.f.Name())
+}
+
+func (d *DiskBlobStore) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	p, err := d.path(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(p)
+	if os.IsNotExist(err) {
+		return nil, blobNotFound(key)
+	}
+	return f, err
+}
+
+func (d *DiskBlobStore) Stat(ctx context.Context, key string) (BlobInfo, error) {
+	if err := ctx.Err(); err != nil {
+		return BlobInfo{}, err
+	}
+	p, err := d.path(key)
+	if err != nil {
+		return BlobInfo{}, err
+	}
+	fi, err := os.Stat(p)
+	if os.IsNotExist(err) {
+		return BlobInfo{}, blobNotFound(key)
+	}
+	if err != nil {
+		return BlobInfo{}, err
+	}
+	return BlobInfo{Key: key, Size: fi.Size(), ModTime: fi.ModTime()}, nil
+}
+
+func (d *DiskBlobStore) Delete(ctx context.Context, key string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	p, err := d.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(p); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (d *DiskBlobStore) Touch(ctx context.Context, key string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	p, err := d.path(key)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	err = os.Chtimes(p, now, now)
+	if os.IsNotExist(err) {
+		return blobNotFound(key)
+	}
+	return err
+}
+
+// List walks the directory holding prefix, so listing "chunks/" only
+// reads the chunk tree.
+func (d *DiskBlobStore) List(ctx context.Context, prefix string, fn func(BlobInfo) error) error {
+	dir := d.root
+	if i := strings.LastIndex(prefix, "/"); i >= 0 {
+		dir = filepath.Join(d.root, filepath.FromSlash(prefix[:i]))
+	}
+	err := filepath.WalkDir(dir, func(p string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil // removed while walking
+			}
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), tempPrefix) {
+			return nil
+		}
+		rel, err := filepath.Rel(d.root, p)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+		fi, err := entry.Info()
+		if err != nil {
+			return nil // removed since it was listed
+		}
+		return fn(BlobInfo{Key: key, Size: fi.Size(), ModTime: fi.ModTime()})
+	})
+	if os.IsNotExist(err) {
+		err = nil
+	}
+	return err
+}
@@ -0,0 +1,56 @@
+package storage
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrEventsPruned is returned by EventsSince for a cursor older than the
+// change log still holds: events after it were pruned, so resuming there
+// would skip them.
+var ErrEventsPruned = errors.New("change events after cursor were pruned")
+
+// EventKind says what a committed change did to an entry.
+type EventKind int
+
+const (
+	EventCreate EventKind = iota
+	EventUpdate
+	EventDelete
+)
+
+func (k EventKind) String() string {
+	switch k {
+	case EventCreate:
+		return "create"
+	case EventUpdate:
+		return "update"
+	default:
+		return "delete"
+	}
+}
+
+func parseEventKind(s string) EventKind {
+	switch s {
+	case "create":
+		return EventCreate
+	case "update":
+		return EventUpdate
+	default:
+		return EventDelete
+	}
+}
+
+// Event is one committed change. Folder is the containing folder, so a
+// directory event names the directory's parent and FileName its name.
+// Events for the same (Folder, FileName, Seq, Kind) are recorded once, so
+// every replica can record the commits it learns about.
+type Event struct {
+	ID          uint64 // cursor; increases with commit order
+	Kind        EventKind
+	Folder      string
+	FileName    string
+	Seq         uint64
+	IsDir       bool
+	CommittedAt time.Time
+}
@@ -0,0 +1,162 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	crdbpgx "github.com/cockroachdb/cockroach-go/v2/crdb/crdbpgxv5"
+)
+
+// Group commit defaults: how long the first waiting update holds its batch
+// open, and how many updates a batch takes before it is sent early.
+const (
+	DefaultBatchWindow = 2 * time.Millisecond
+	DefaultMaxBatch    = 128
+)
+
+// groupOp is one caller's Put or MarkClean waiting for its batch.
+type groupOp struct {
+	ctx   context.Context
+	put   bool
+	chunk Chunk // for MarkClean, only Folder, FileName and Seq are used
+	done  chan error
+}
+
+// applyOp is the default groupCommitter.apply: op's own statements.
+func applyOp(ctx context.Context, tx pgx.Tx, op *groupOp) error {
+	if op.put {
+		return putTx(ctx, tx, op.chunk)
+	}
+	return markCleanTx(ctx, tx, op.chunk.Folder, op.chunk.FileName, op.chunk.Seq)
+}
+
+// groupCommitter coalesces concurrent Puts and MarkCleans into one
+// transaction. The first update to arrive opens a batch and waits up to
+// window for others to join; a batch that fills to maxBatch goes at once.
+// Batches commit independently, so a slow one doesn't hold up the next.
+type groupCommitter struct {
+	window   time.Duration
+	maxBatch int
+	// execTx runs fn in one transaction, retrying it as CockroachDB asks,
+	// and apply runs one update inside it.
+	execTx func(ctx context.Context, fn func(pgx.Tx) error) error
+	apply  func(ctx context.Context, tx pgx.Tx, op *groupOp) error
+
+	mu      sync.Mutex
+	pending []*groupOp
+	timer   *time.Timer
+}
+
+func newGroupCommitter(pool *pgxpool.Pool, window time.Duration, maxBatch int) *groupCommitter {
+	return &groupCommitter{
+		window:   window,
+		maxBatch: maxBatch,
+		execTx: func(ctx context.Context, fn func(pgx.Tx) error) error {
+			return crdbpgx.ExecuteTx(ctx, pool, pgx.TxOptions{}, fn)
+		},
+		apply: applyOp,
+	}
+}
+
+// submit queues op and waits for its own result. A caller that gives up
+// gets its context's error, though an update already sent may still land,
+// as with any transaction cancelled mid-commit.
+func (g *groupCommitter) submit(ctx context.Context, op *groupOp) error {
+	op.ctx, op.done = ctx, make(chan error, 1)
+
+	g.mu.Lock()
+	g.pending = append(g.pending, op)
+	switch {
+	case len(g.pending) >= g.maxBatch:
+		batch := g.take()
+		g.mu.Unlock()
+		go g.commit(batch)
+	case len(g.pending) == 1:
+		g.timer = time.AfterFunc(g.window, g.flush)
+		g.mu.Unlock()
+	default:
+		g.mu.Unlock()
+	}
+
+	select {
+	case err := <-op.done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// take detaches the pending batch. g.mu must be held.
+func (g *groupCommitter) take() []*groupOp {
+	batch := g.pending
+	g.pending = nil
+	if g.timer != nil {
+		g.timer.Stop()
+		g.timer = nil
+	}
+	return batch
+}
+
+func (g *groupCommitter) flush() {
+	g.mu.Lock()
+	batch := g.take()
+	g.mu.Unlock()
+	g.commit(batch)
+}
+
+// commit applies a batch in one transaction and hands each caller its own
+// result. An update that is stale or missing fails alone; if the
+// transaction itself fails, every update is retried in its own so one bad
+// update can't fail its neighbours.
+func (g *groupCommitter) commit(batch []*groupOp) {
+	live := batch[:0]
+	for _, op := range batch {
+		if err := op.ctx.Err(); err != nil {
+			op.done <- err
+			continue
+		}
+		live = append(live, op)
+	}
+	if len(live) == 0 {
+		return
+	}
+
+	// Callers wait on their own contexts; the batch finishes regardless so
+	// that one caller giving up doesn't abort the others' updates.
+	ctx := context.Background()
+	if len(live) == 1 {
+		live[0].done <- g.commitOne(ctx, live[0])
+		return
+	}
+
+	results := make([]error, len(live))
+	err := g.execTx(ctx, func(tx pgx.Tx) error {
+		for i, op := range live {
+			results[i] = g.apply(ctx, tx, op)
+			if results[i] != nil && !errors.Is(results[i], ErrStale) && !errors.Is(results[i], ErrNotFound) {
+				return results[i]
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		for _, op := range live {
+			op.done <- g.commitOne(ctx, op)
+		}
+		return
+	}
+	for i, op := range live {
+		op.done <- results[i]
+	}
+}
+
+func (g *groupCommitter) commitOne(ctx context.Context, op *groupOp) error {
+	return g.execTx(ctx, func(tx pgx.Tx) error {
+		return g.apply(ctx, tx, op)
+	})
+}
@@ -0,0 +1,132 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// fakeTx records the ops applied in it.
+type fakeTx struct {
+	pgx.Tx
+	ops []string
+}
+
+// fakeCommitter returns a groupCommitter whose transactions only record
+// which ops they applied, and a func listing the committed ones.
+func fakeCommitter(window time.Duration, maxBatch int, fail func(*groupOp) error) (*groupCommitter, func() [][]string) {
+	var mu sync.Mutex
+	var txs [][]string
+	g := &groupCommitter{
+		window:   window,
+		maxBatch: maxBatch,
+		execTx: func(ctx context.Context, fn func(pgx.Tx) error) error {
+			tx := &fakeTx{}
+			if err := fn(tx); err != nil {
+				return err
+			}
+			mu.Lock()
+			txs = append(txs, tx.ops)
+			mu.Unlock()
+			return nil
+		},
+		apply: func(ctx context.Context, tx pgx.Tx, op *groupOp) error {
+			tx.(*fakeTx).ops = append(tx.(*fakeTx).ops, op.chunk.FileName)
+			if fail != nil {
+				return fail(op)
+			}
+			return nil
+		},
+	}
+	return g, func() [][]string {
+		mu.Lock()
+		defer mu.Unlock()
+		return txs
+	}
+}
+
+func submitAll(g *groupCommitter, n int) []error {
+	errs := make([]error, n)
+	var wg sync.WaitGroup
+	for i := range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = g.submit(context.Background(), &groupOp{put: true, chunk: Chunk{FileName: fmt.Sprint(i)}})
+		}()
+	}
+	wg.Wait()
+	return errs
+}
+
+func TestGroupCommitBatchesConcurrentPuts(t *testing.T) {
+	g, txs := fakeCommitter(time.Minute, 8, nil)
+
+	for i, err := range submitAll(g, 8) {
+		if err != nil {
+			t.Fatalf("put %d: %v", i, err)
+		}
+	}
+	if got := txs(); len(got) != 1 || len(got[0]) != 8 {
+		t.Fatalf("transactions = %v, want one of 8 puts", got)
+	}
+}
+
+func TestGroupCommitWindowFlushesPartialBatch(t *testing.T) {
+	g, txs := fakeCommitter(20*time.Millisecond, 128, nil)
+
+	for i, err := range submitAll(g, 3) {
+		if err != nil {
+			t.Fatalf("put %d: %v", i, err)
+		}
+	}
+	var total int
+	for _, tx := range txs() {
+		total += len(tx)
+	}
+	if total != 3 || len(txs()) > 3 {
+		t.Fatalf("transactions = %v, want the 3 puts", txs())
+	}
+}
+
+func TestGroupCommitFailsStaleOpAlone(t *testing.T) {
+	g, txs := fakeCommitter(time.Minute, 4, func(op *groupOp) error {
+		if op.chunk.FileName == "2" {
+			return ErrStale
+		}
+		return nil
+	})
+
+	for i, err := range submitAll(g, 4) {
+		if want := i == 2; errors.Is(err, ErrStale) != want || (!want && err != nil) {
+			t.Fatalf("put %d: %v", i, err)
+		}
+	}
+	if got := txs(); len(got) != 1 || len(got[0]) != 4 {
+		t.Fatalf("transactions = %v, want one of 4 puts", got)
+	}
+}
+
+func TestGroupCommitRetriesFailedBatchSingly(t *testing.T) {
+	boom := errors.New("boom")
+	g, txs := fakeCommitter(time.Minute, 3, func(op *groupOp) error {
+		if op.chunk.FileName == "1" {
+			return boom
+		}
+		return nil
+	})
+
+	for i, err := range submitAll(g, 3) {
+		if want := i == 1; errors.Is(err, boom) != want || (!want && err != nil) {
+			t.Fatalf("put %d: %v", i, err)
+		}
+	}
+	if got := txs(); len(got) != 2 {
+		t.Fatalf("transactions = %v, want the two good puts committed singly", got)
+	}
+}
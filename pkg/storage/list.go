@@ -0,0 +1,81 @@
+package storage
+
+import (
+	"path"
+	"strings"
+)
+
+// ListQuery selects one page of the entries under a folder.
+type ListQuery struct {
+	Folder    string
+	Recursive bool   // walk subfolders; entry names become relative paths
+	Pattern   string // glob matched against the base name; "" matches all
+	Desc      bool   // sort by name, descending
+	After     string // cursor from the previous page; "" starts at the top
+	Limit     int
+}
+
+// Entry is one listing result: a file, or a subfolder when the listing is
+// not recursive.
+type Entry struct {
+	Name  string // relative to the queried folder; subfolders end in "/"
+	IsDir bool
+	Chunk Chunk // metadata of the file; zero for subfolders
+}
+
+// ListPage is one page of listing results. Next is the cursor to pass as
+// ListQuery.After for the following page, or "" on the last page.
+type ListPage struct {
+	Entries []Entry
+	Next    string
+}
+
+// pager collects one page of entries. It keeps one entry past the limit so
+// it can tell whether another page follows.
+type pager struct {
+	q       ListQuery
+	entries []Entry
+	cursors []string
+}
+
+// add keeps e if it matches the query pattern and reports whether the page
+// is full.
+func (p *pager) add(e Entry, cursor string) bool {
+	if p.q.Pattern != "" {
+		if ok, _ := path.Match(p.q.Pattern, path.Base(strings.TrimSuffix(e.Name, "/"))); !ok {
+			return p.full()
+		}
+	}
+	p.entries = append(p.entries, e)
+	p.cursors = append(p.cursors, cursor)
+	return p.full()
+}
+
+func (p *pager) full() bool {
+	return len(p.entries) > p.q.Limit
+}
+
+func (p *pager) page() ListPage {
+	if !p.full() {
+		return ListPage{Entries: p.entries}
+	}
+	return ListPage{
+		Entries: p.entries[:p.q.Limit],
+		Next:    p.cursors[p.q.Limit-1],
+	}
+}
+
+// before reports whether name a sorts ahead of b in the query's order.
+func (q ListQuery) before(a, b string) bool {
+	if q.Desc {
+		return a > b
+	}
+	return a < b
+}
+
+// relativeName is the path of c relative to folder, used as the entry name
+// in recursive listings.
+func relativeName(folder string, c Chunk) string {
+	rest := strings.TrimPrefix(c.Folder, strings.TrimSuffix(folder, "/"))
+	return path.Join(strings.TrimPrefix(rest, "/"), c.FileName)
+}
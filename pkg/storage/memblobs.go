@@ -0,0 +1,131 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MemBlobStore keeps blobs in process memory. Like MemStore it is meant
+// for tests and throwaway nodes: everything is lost on restart.
+type MemBlobStore struct {
+	mu    sync.RWMutex
+	blobs map[string]memBlob
+}
+
+type memBlob struct {
+	data    []byte
+	modTime time.Time
+}
+
+func NewMemBlobStore() *MemBlobStore {
+	return &MemBlobStore{blobs: make(map[string]memBlob)}
+}
+
+func (m *MemBlobStore) Create(ctx context.Context, key string) (BlobWriter, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return &memBlobWriter{store: m, key: key}, nil
+}
+
+type memBlobWriter struct {
+	store *MemBlobStore
+	key   string
+	buf   bytes.Buffer
+}
+
+func (w *memBlobWriter) Write(p []byte) (int, error) {
+	return w.buf.Write(p)
+}
+
+func (w *memBlobWriter) Close() error {
+	w.store.mu.Lock()
+	defer w.store.mu.Unlock()
+	w.store.blobs[w.key] = memBlob{data: w.buf.Bytes(), modTime: time.Now()}
+	return nil
+}
+
+func (w *memBlobWriter) Abort() {
+	w.buf.Reset()
+}
+
+func (m *MemBlobStore) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	b, ok := m.blobs[key]
+	if !ok {
+		return nil, blobNotFound(key)
+	}
+	// Blobs are never modified in place, so readers can share the bytes.
+	return io.NopCloser(bytes.NewReader(b.data)), nil
+}
+
+func (m *MemBlobStore) Stat(ctx context.Context, key string) (BlobInfo, error) {
+	if err := ctx.Err(); err != nil {
+		return BlobInfo{}, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	b, ok := m.blobs[key]
+	if !ok {
+		return BlobInfo{}, blobNotFound(key)
+	}
+	return BlobInfo{Key: key, Size: int64(len(b.data)), ModTime: b.modTime}, nil
+}
+
+func (m *MemBlobStore) Delete(ctx context.Context, key string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.blobs, key)
+	return nil
+}
+
+func (m *MemBlobStore) Touch(ctx context.Context, key string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	b, ok := m.blobs[key]
+	if !ok {
+		return blobNotFound(key)
+	}
+	b.modTime = time.Now()
+	m.blobs[key] = b
+	return nil
+}
+
+// List works from a copy of the matching entries, so fn can modify the
+// store.
+func (m *MemBlobStore) List(ctx context.Context, prefix string, fn func(BlobInfo) error) error {
+	m.mu.RLock()
+	var infos []BlobInfo
+	for key, b := range m.blobs {
+		if strings.HasPrefix(key, prefix) {
+			infos = append(infos, BlobInfo{Key: key, Size: int64(len(b.data)), ModTime: b.modTime})
+		}
+	}
+	m.mu.RUnlock()
+
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Key < infos[j].Key })
+	for _, info := range infos {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := fn(info); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,688 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MemStore is a StorageClient that keeps everything in memory, for tests
+// and throwaway clusters. It follows CraqStore's semantics but, unlike it,
+// is private to one node: nothing is shared with other nodes or survives a
+// restart.
+type MemStore struct {
+	mu sync.RWMutex
+
+	files     map[fileKey]*Chunk
+	dirs      map[string]time.Time // directory path → created at
+	events    []Event
+	eventKeys map[eventKey]bool
+	lastEvent uint64
+	pruned    uint64 // cursor of the newest pruned event
+	snapshots map[string]*memSnapshot
+	quotas    map[string]Quota
+	ttls      map[string]time.Duration
+}
+
+type fileKey struct {
+	folder, fileName string
+}
+
+type eventKey struct {
+	folder, fileName string
+	seq              uint64
+	kind             EventKind
+}
+
+type memSnapshot struct {
+	Snapshot
+	seqs map[fileKey]uint64
+}
+
+func NewMemStore() *MemStore {
+	return &MemStore{
+		files:     make(map[fileKey]*Chunk),
+		dirs:      make(map[string]time.Time),
+		eventKeys: make(map[eventKey]bool),
+		snapshots: make(map[string]*memSnapshot),
+		quotas:    make(map[string]Quota),
+		ttls:      make(map[string]time.Duration),
+	}
+}
+
+func (store *MemStore) Put(ctx context.Context, c Chunk) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	store.put(c)
+	return nil
+}
+
+func (store *MemStore) PutBatch(ctx context.Context, chunks []Chunk) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	for _, c := range chunks {
+		store.put(c)
+	}
+	return nil
+}
+
+// put stores c as the dirty latest version unless a version at least as
+// new is stored. A tombstoned file starts over, as in CraqStore.
+func (store *MemStore) put(c Chunk) {
+	k := fileKey{c.Folder, c.FileName}
+	now := time.Now()
+
+	old, found := store.files[k]
+	if found && old.Seq >= c.Seq {
+		return
+	}
+
+	c.State = Dirty
+	c.ModifiedAt = now
+	c.Deleted = false
+	c.CreatedAt, c.CleanSeq = now, 0
+	if found {
+		c.CreatedAt = old.CreatedAt
+		if !old.Deleted {
+			c.CleanSeq = old.CleanSeq
+		}
+	}
+	store.files[k] = &c
+
+	for _, d := range ancestors(c.Folder) {
+		store.addDir(d, now)
+	}
+}
+
+func (store *MemStore) MarkClean(ctx context.Context, folder, fileName string, seq uint64) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return store.MarkCleanBatch(ctx, []Chunk{{Folder: folder, FileName: fileName, Seq: seq}})
+}
+
+// MarkCleanBatch checks every version before marking any, so a batch is
+// committed whole or not at all.
+func (store *MemStore) MarkCleanBatch(ctx context.Context, chunks []Chunk) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	for _, c := range chunks {
+		f, ok := store.files[fileKey{c.Folder, c.FileName}]
+		if !ok {
+			return markCleanError(c.Folder, c.FileName, c.Seq, 0)
+		}
+		if f.Seq != c.Seq {
+			return markCleanError(c.Folder, c.FileName, c.Seq, f.Seq)
+		}
+	}
+	for _, c := range chunks {
+		f := store.files[fileKey{c.Folder, c.FileName}]
+		f.State = Clean
+		f.CleanSeq = f.Seq
+	}
+	return nil
+}
+
+func (store *MemStore) GetLatest(ctx context.Context, folder, fileName string) (Chunk, error) {
+	if err := ctx.Err(); err != nil {
+		return Chunk{}, err
+	}
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	c, ok := store.files[fileKey{folder, fileName}]
+	if !ok {
+		return Chunk{}, fmt.Errorf("%s/%s: %w", folder, fileName, ErrNotFound)
+	}
+	return *c, nil
+}
+
+// live reports whether readers may see c, matching CraqStore's liveFilter.
+func live(c *Chunk, now time.Time) bool {
+	return !c.Deleted && c.CleanSeq > 0 && !c.Expired(now)
+}
+
+// inTree reports whether folder is root or below it.
+func inTree(folder, root string) bool {
+	return folder == root || strings.HasPrefix(folder, strings.TrimSuffix(root, "/")+"/")
+}
+
+// ListFilesInFolder returns one page of the entries under q.Folder, sorted
+// by name. Non-recursive listings report each subfolder once, as "name/".
+func (store *MemStore) ListFilesInFolder(ctx context.Context, q ListQuery) (ListPage, error) {
+	if err := ctx.Err(); err != nil {
+		return ListPage{}, err
+	}
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	if q.Recursive {
+		return store.listRecursive(q), nil
+	}
+	return store.listChildren(q), nil
+}
+
+func (store *MemStore) listRecursive(q ListQuery) ListPage {
+	now := time.Now()
+	var files []*Chunk
+	for _, c := range store.files {
+		if inTree(c.Folder, q.Folder) && live(c, now) {
+			files = append(files, c)
+		}
+	}
+
+	cursor := func(c *Chunk) string { return c.Folder + "\x00" + c.FileName }
+	sort.Slice(files, func(i, j int) bool { return q.before(cursor(files[i]), cursor(files[j])) })
+
+	p := &pager{q: q}
+	for _, c := range files {
+		if q.After != "" && !q.before(q.After, cursor(c)) {
+			continue
+		}
+		if p.add(Entry{Name: relativeName(q.Folder, *c), Chunk: *c}, cursor(c)) {
+			break
+		}
+	}
+	return p.page()
+}
+
+func (store *MemStore) listChildren(q ListQuery) ListPage {
+	now := time.Now()
+	folder := CleanDir(q.Folder)
+
+	var entries []Entry
+	for _, c := range store.files {
+		if c.Folder == q.Folder && live(c, now) {
+			entries = append(entries, Entry{Name: c.FileName, Chunk: *c})
+		}
+	}
+	for d := range store.dirs {
+		if path.Dir(d) == folder {
+			entries = append(entries, Entry{Name: path.Base(d) + "/", IsDir: true})
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return q.before(entries[i].Name, entries[j].Name) })
+
+	p := &pager{q: q}
+	for _, e := range entries {
+		if q.After != "" && !q.before(q.After, e.Name) {
+			continue
+		}
+		if p.add(e, e.Name) {
+			break
+		}
+	}
+	return p.page()
+}
+
+func (store *MemStore) Mkdir(ctx context.Context, dir string, parents bool) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	dirs := ancestors(dir)
+	if len(dirs) == 0 {
+		return nil // root
+	}
+
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	if !parents && len(dirs) > 1 {
+		if _, err := store.getDir(dirs[len(dirs)-2]); err != nil {
+			return err
+		}
+		dirs = dirs[len(dirs)-1:]
+	}
+	now := time.Now()
+	for _, d := range dirs {
+		store.addDir(d, now)
+	}
+	return nil
+}
+
+func (store *MemStore) addDir(dir string, now time.Time) {
+	if _, ok := store.dirs[dir]; !ok {
+		store.dirs[dir] = now
+	}
+}
+
+func (store *MemStore) Rmdir(ctx context.Context, dir string, recursive bool) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	dir = CleanDir(dir)
+
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	if _, err := store.getDir(dir); err != nil {
+		return err
+	}
+
+	if !recursive {
+		for d := range store.dirs {
+			if path.Dir(d) == dir {
+				return ErrDirNotEmpty
+			}
+		}
+		for _, c := range store.files {
+			if c.Folder == dir && !c.Deleted {
+				return ErrDirNotEmpty
+			}
+		}
+	} else {
+		now := time.Now()
+		for _, c := range store.files {
+			if inTree(c.Folder, dir) && !c.Deleted {
+				c.Deleted = true
+				c.ModifiedAt = now
+			}
+		}
+		for d := range store.dirs {
+			if inTree(path.Dir(d), dir) {
+				delete(store.dirs, d)
+			}
+		}
+	}
+
+	delete(store.dirs, dir)
+	return nil
+}
+
+func (store *MemStore) GetDir(ctx context.Context, dir string) (Dir, error) {
+	if err := ctx.Err(); err != nil {
+		return Dir{}, err
+	}
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+	return store.getDir(CleanDir(dir))
+}
+
+func (store *MemStore) getDir(dir string) (Dir, error) {
+	if dir == "/" {
+		return Dir{Path: "/"}, nil
+	}
+	created, ok := store.dirs[dir]
+	if !ok {
+		return Dir{}, ErrDirNotFound
+	}
+	return Dir{Path: dir, CreatedAt: created}, nil
+}
+
+func (store *MemStore) RecordEvent(ctx context.Context, e Event) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	k := eventKey{e.Folder, e.FileName, e.Seq, e.Kind}
+	if store.eventKeys[k] {
+		return nil
+	}
+	store.eventKeys[k] = true
+
+	store.lastEvent++
+	e.ID = store.lastEvent
+	e.CommittedAt = time.Now()
+	store.events = append(store.events, e)
+	return nil
+}
+
+func (store *MemStore) EventsSince(ctx context.Context, cursor uint64, folder string, recursive bool, limit int) ([]Event, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+	if cursor < store.pruned {
+		return nil, ErrEventsPruned
+	}
+
+	// IDs are assigned in order, so the events after cursor are a suffix.
+	i := sort.Search(len(store.events), func(i int) bool { return store.events[i].ID > cursor })
+
+	var events []Event
+	for _, e := range store.events[i:] {
+		if len(events) == limit {
+			break
+		}
+		if e.Folder == folder || (recursive && inTree(e.Folder, folder)) {
+			events = append(events, e)
+		}
+	}
+	return events, nil
+}
+
+func (store *MemStore) LastEventID(ctx context.Context) (uint64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+	return store.lastEvent, nil
+}
+
+func (store *MemStore) PruneEvents(ctx context.Context, before time.Time) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	n := 0
+	for n < len(store.events) && store.events[n].CommittedAt.Before(before) {
+		e := store.events[n]
+		delete(store.eventKeys, eventKey{e.Folder, e.FileName, e.Seq, e.Kind})
+		store.pruned = e.ID
+		n++
+	}
+	store.events = slices.Delete(store.events, 0, n)
+	return n, nil
+}
+
+func (store *MemStore) CreateSnapshot(ctx context.Context, name, folder string) (Snapshot, error) {
+	if err := ctx.Err(); err != nil {
+		return Snapshot{}, err
+	}
+	folder = CleanDir(folder)
+
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	if _, ok := store.snapshots[name]; ok {
+		return Snapshot{}, ErrSnapshotExists
+	}
+
+	now := time.Now()
+	snap := &memSnapshot{
+		Snapshot: Snapshot{Name: name, Folder: folder, CreatedAt: now},
+		seqs:     make(map[fileKey]uint64),
+	}
+	for k, c := range store.files {
+		if inTree(c.Folder, folder) && live(c, now) {
+			snap.seqs[k] = c.CleanSeq
+		}
+	}
+	snap.FileCount = uint64(len(snap.seqs))
+	store.snapshots[name] = snap
+	return snap.Snapshot, nil
+}
+
+func (store *MemStore) GetSnapshot(ctx context.Context, name string) (Snapshot, error) {
+	if err := ctx.Err(); err != nil {
+		return Snapshot{}, err
+	}
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	snap, ok := store.snapshots[name]
+	if !ok {
+		return Snapshot{}, ErrSnapshotNotFound
+	}
+	return snap.Snapshot, nil
+}
+
+func (store *MemStore) ListSnapshots(ctx context.Context) ([]Snapshot, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	snaps := make([]Snapshot, 0, len(store.snapshots))
+	for _, snap := range store.snapshots {
+		snaps = append(snaps, snap.Snapshot)
+	}
+	sort.Slice(snaps, func(i, j int) bool { return snaps[i].Name < snaps[j].Name })
+	return snaps, nil
+}
+
+func (store *MemStore) DeleteSnapshot(ctx context.Context, name string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	if _, ok := store.snapshots[name]; !ok {
+		return ErrSnapshotNotFound
+	}
+	delete(store.snapshots, name)
+	return nil
+}
+
+func (store *MemStore) SnapshotSeq(ctx context.Context, name, folder, fileName string) (uint64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	snap, ok := store.snapshots[name]
+	if !ok {
+		return 0, ErrSnapshotNotFound
+	}
+	seq, ok := snap.seqs[fileKey{folder, fileName}]
+	if !ok {
+		return 0, ErrNotInSnapshot
+	}
+	return seq, nil
+}
+
+func (store *MemStore) SnapshotSeqs(ctx context.Context, folder, fileName string) ([]uint64, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	seen := make(map[uint64]bool)
+	var seqs []uint64
+	for _, snap := range store.snapshots {
+		if seq, ok := snap.seqs[fileKey{folder, fileName}]; ok && !seen[seq] {
+			seen[seq] = true
+			seqs = append(seqs, seq)
+		}
+	}
+	return seqs, nil
+}
+
+func (store *MemStore) SnapshotFiles(ctx context.Context, name string) ([]SnapshotFile, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	snap, ok := store.snapshots[name]
+	if !ok {
+		return nil, ErrSnapshotNotFound
+	}
+	files := make([]SnapshotFile, 0, len(snap.seqs))
+	for k, seq := range snap.seqs {
+		files = append(files, SnapshotFile{Folder: k.folder, FileName: k.fileName, Seq: seq})
+	}
+	sort.Slice(files, func(i, j int) bool {
+		if files[i].Folder != files[j].Folder {
+			return files[i].Folder < files[j].Folder
+		}
+		return files[i].FileName < files[j].FileName
+	})
+	return files, nil
+}
+
+func (store *MemStore) PutSnapshot(ctx context.Context, snap Snapshot, files []SnapshotFile) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	if _, ok := store.snapshots[snap.Name]; ok {
+		return ErrSnapshotExists
+	}
+	ms := &memSnapshot{Snapshot: snap, seqs: make(map[fileKey]uint64, len(files))}
+	for _, f := range files {
+		ms.seqs[fileKey{f.Folder, f.FileName}] = f.Seq
+	}
+	ms.FileCount = uint64(len(ms.seqs))
+	store.snapshots[snap.Name] = ms
+	return nil
+}
+
+func (store *MemStore) SetQuota(ctx context.Context, q Quota) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	q.Folder = CleanDir(q.Folder)
+
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	if q.MaxBytes == 0 && q.MaxFiles == 0 {
+		delete(store.quotas, q.Folder)
+	} else {
+		store.quotas[q.Folder] = q
+	}
+	return nil
+}
+
+func (store *MemStore) ListQuotas(ctx context.Context) ([]Quota, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	quotas := make([]Quota, 0, len(store.quotas))
+	for _, q := range store.quotas {
+		quotas = append(quotas, q)
+	}
+	sort.Slice(quotas, func(i, j int) bool { return quotas[i].Folder < quotas[j].Folder })
+	return quotas, nil
+}
+
+func (store *MemStore) QuotasFor(ctx context.Context, folder string) ([]Quota, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	var quotas []Quota
+	for _, d := range append([]string{"/"}, ancestors(folder)...) {
+		if q, ok := store.quotas[d]; ok {
+			quotas = append(quotas, q)
+		}
+	}
+	return quotas, nil
+}
+
+func (store *MemStore) Usage(ctx context.Context, folder string) (Usage, error) {
+	if err := ctx.Err(); err != nil {
+		return Usage{}, err
+	}
+	folder = CleanDir(folder)
+
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	var u Usage
+	for _, c := range store.files {
+		if inTree(c.Folder, folder) && !c.Deleted {
+			u.Bytes += c.Size
+			u.Files++
+		}
+	}
+	return u, nil
+}
+
+func (store *MemStore) DeleteFile(ctx context.Context, folder, fileName string, seq uint64) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	if c, ok := store.files[fileKey{folder, fileName}]; ok && c.Seq < seq {
+		c.Deleted = true
+		c.Seq = seq
+		c.ModifiedAt = time.Now()
+	}
+	return nil
+}
+
+func (store *MemStore) ExpiredFiles(ctx context.Context, after string, limit int) ([]Chunk, string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, "", err
+	}
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	now := time.Now()
+	var expired []Chunk
+	for _, c := range store.files {
+		if !c.Deleted && c.Expired(now) && c.Folder+"\x00"+c.FileName > after {
+			expired = append(expired, *c)
+		}
+	}
+	sort.Slice(expired, func(i, j int) bool {
+		return expired[i].Folder+"\x00"+expired[i].FileName < expired[j].Folder+"\x00"+expired[j].FileName
+	})
+
+	if len(expired) <= limit {
+		return expired, "", nil
+	}
+	expired = expired[:limit]
+	last := expired[limit-1]
+	return expired, last.Folder + "\x00" + last.FileName, nil
+}
+
+func (store *MemStore) SetFolderTTL(ctx context.Context, folder string, ttl time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	folder = CleanDir(folder)
+
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	if ttl <= 0 {
+		delete(store.ttls, folder)
+	} else {
+		store.ttls[folder] = ttl
+	}
+	return nil
+}
+
+func (store *MemStore) FolderTTL(ctx context.Context, folder string) (time.Duration, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	dirs := append([]string{"/"}, ancestors(folder)...)
+	for i := len(dirs) - 1; i >= 0; i-- {
+		if ttl, ok := store.ttls[dirs[i]]; ok {
+			return ttl, nil
+		}
+	}
+	return 0, nil
+}
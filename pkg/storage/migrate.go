@@ -0,0 +1,262 @@
+package storage
+
+import (
+	"context"
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// Migration is one versioned schema change, embedded from
+// migrations/NNNN_name.sql.
+type Migration struct {
+	Version  int
+	Name     string
+	SQL      string
+	Checksum string
+}
+
+// AppliedMigration is a migration recorded in schema_migrations.
+type AppliedMigration struct {
+	Version   int
+	Name      string
+	Checksum  string
+	AppliedAt time.Time
+}
+
+// ErrMigrationChanged is returned when an applied migration's embedded SQL
+// no longer matches what was applied.
+var ErrMigrationChanged = errors.New("applied migration was modified")
+
+// ErrMigrationLockLost is returned when the migration lock expired while
+// its holder was still migrating, so another node may have run alongside.
+var ErrMigrationLockLost = errors.New("migration lock was lost")
+
+// migrationLockTTL is how long a migration lock is honoured; a holder that
+// died mid-migration stops blocking other nodes after this.
+const migrationLockTTL = 10 * time.Minute
+
+// Migrations returns the embedded migrations in version order.
+func Migrations() ([]Migration, error) {
+	entries, err := migrationFiles.ReadDir("migrations")
+	if err != nil {
+		return nil, err
+	}
+	var ms []Migration
+	for _, e := range entries {
+		base := strings.TrimSuffix(e.Name(), ".sql")
+		num, name, ok := strings.Cut(base, "_")
+		version, err := strconv.Atoi(num)
+		if !ok || err != nil || version <= 0 {
+			return nil, fmt.Errorf("bad migration file name %q", e.Name())
+		}
+		b, err := migrationFiles.ReadFile(path.Join("migrations", e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		sum := sha256.Sum256(b)
+		ms = append(ms, Migration{Version: version, Name: name, SQL: string(b), Checksum: hex.EncodeToString(sum[:])})
+	}
+	sort.Slice(ms, func(i, j int) bool { return ms[i].Version < ms[j].Version })
+	for i := 1; i < len(ms); i++ {
+		if ms[i].Version == ms[i-1].Version {
+			return nil, fmt.Errorf("duplicate migration version %d", ms[i].Version)
+		}
+	}
+	return ms, nil
+}
+
+// statements splits a migration into the statements it holds, each ending
+// with a line that ends in a semicolon. They run one at a time because
+// CockroachDB can't mix schema changes and writes in one transaction.
+func statements(sql string) []string {
+	var stmts []string
+	var cur strings.Builder
+	for _, line := range strings.SplitAfter(sql, "\n") {
+		cur.WriteString(line)
+		if strings.HasSuffix(strings.TrimSpace(line), ";") {
+			stmts = append(stmts, cur.String())
+			cur.Reset()
+		}
+	}
+	if rest := strings.TrimSpace(cur.String()); rest != "" && !onlyComments(rest) {
+		stmts = append(stmts, rest)
+	}
+	return stmts
+}
+
+func onlyComments(s string) bool {
+	for _, line := range strings.Split(s, "\n") {
+		if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "--") {
+			return false
+		}
+	}
+	return true
+}
+
+// Migrate applies the embedded migrations the database hasn't seen, in
+// order, and returns them. It holds the migration lock throughout, so
+// nodes starting together apply each migration once; the others wait and
+// then find nothing left to do. Every migration is idempotent, so a
+// database set up by hand before migrations existed adopts them cleanly.
+func (store *CraqStore) Migrate(ctx context.Context) (ran []Migration, err error) {
+	ms, err := Migrations()
+	if err != nil {
+		return nil, err
+	}
+	if err := store.ensureMigrationTable(ctx); err != nil {
+		return nil, err
+	}
+	lock := store.migrationLock()
+	if err := lock.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer func() {
+		if uerr := lock.unlock(context.WithoutCancel(ctx)); err == nil {
+			err = uerr
+		}
+	}()
+
+	applied, err := store.AppliedMigrations(ctx)
+	if err != nil {
+		return nil, err
+	}
+	done := make(map[int]AppliedMigration, len(applied))
+	for _, a := range applied {
+		done[a.Version] = a
+	}
+
+	for _, m := range ms {
+		if a, ok := done[m.Version]; ok {
+			if a.Checksum != m.Checksum {
+				return ran, fmt.Errorf("migration %04d_%s: %w", m.Version, m.Name, ErrMigrationChanged)
+			}
+			continue
+		}
+		for _, stmt := range statements(m.SQL) {
+			if _, err := store.pool.Exec(ctx, stmt); err != nil {
+				return ran, fmt.Errorf("migration %04d_%s: %w", m.Version, m.Name, err)
+			}
+		}
+		_, err := store.pool.Exec(ctx, `
+			INSERT INTO schema_migrations (version, name, checksum) VALUES ($1, $2, $3)
+		`, m.Version, m.Name, m.Checksum)
+		if err != nil {
+			return ran, fmt.Errorf("record migration %04d_%s: %w", m.Version, m.Name, err)
+		}
+		ran = append(ran, m)
+	}
+	return ran, nil
+}
+
+// AppliedMigrations returns the migrations recorded in the database, in
+// version order.
+func (store *CraqStore) AppliedMigrations(ctx context.Context) ([]AppliedMigration, error) {
+	if err := store.ensureMigrationTable(ctx); err != nil {
+		return nil, err
+	}
+	rows, err := store.pool.Query(ctx, `
+		SELECT version, name, checksum, applied_at FROM schema_migrations
+		WHERE version > 0 ORDER BY version
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var applied []AppliedMigration
+	for rows.Next() {
+		var a AppliedMigration
+		if err := rows.Scan(&a.Version, &a.Name, &a.Checksum, &a.AppliedAt); err != nil {
+			return nil, err
+		}
+		applied = append(applied, a)
+	}
+	return applied, rows.Err()
+}
+
+func (store *CraqStore) ensureMigrationTable(ctx context.Context) error {
+	_, err := store.pool.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS public.schema_migrations (
+		  version INT8 PRIMARY KEY,
+		  name STRING NOT NULL,
+		  checksum STRING NOT NULL DEFAULT '',
+		  applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("create schema_migrations: %w", err)
+	}
+	return nil
+}
+
+// migrationLock is the migration lock: a schema_migrations row with
+// version 0 naming its holder. exec runs one statement on the database.
+type migrationLock struct {
+	holder string
+	ttl    time.Duration
+	retry  time.Duration
+	exec   func(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+}
+
+// migrationLock returns the lock as held by this process.
+func (store *CraqStore) migrationLock() *migrationLock {
+	host, _ := os.Hostname()
+	return &migrationLock{
+		holder: fmt.Sprintf("%s:%d", host, os.Getpid()),
+		ttl:    migrationLockTTL,
+		retry:  time.Second,
+		exec:   store.pool.Exec,
+	}
+}
+
+// lock takes the migration lock, waiting until it is free or has expired.
+func (l *migrationLock) lock(ctx context.Context) error {
+	for {
+		tag, err := l.exec(ctx, `
+			INSERT INTO schema_migrations (version, name, checksum) VALUES (0, $1, 'lock')
+			ON CONFLICT (version) DO UPDATE
+			SET name = EXCLUDED.name, applied_at = now()
+			WHERE schema_migrations.applied_at < now() - $2 * INTERVAL '1 second'
+		`, l.holder, int64(l.ttl/time.Second))
+		if err != nil {
+			return fmt.Errorf("take migration lock: %w", err)
+		}
+		if tag.RowsAffected() > 0 {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("wait for migration lock: %w", ctx.Err())
+		case <-time.After(l.retry):
+		}
+	}
+}
+
+// unlock releases the lock if this holder still has it. It fails with
+// ErrMigrationLockLost if the lock expired and another node took it.
+func (l *migrationLock) unlock(ctx context.Context) error {
+	tag, err := l.exec(ctx, `
+		DELETE FROM schema_migrations WHERE version = 0 AND name = $1
+	`, l.holder)
+	if err != nil {
+		return fmt.Errorf("release migration lock: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("release migration lock of %s: %w", l.holder, ErrMigrationLockLost)
+	}
+	return nil
+}
@@ -0,0 +1,156 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestStatementsSplitOnTrailingSemicolons(t *testing.T) {
+	sql := `-- 0009_example.sql
+CREATE TABLE t (
+  a INT8 PRIMARY KEY, -- not the end;
+  b STRING
+);
+INSERT INTO t VALUES (1, 'x;y');
+  CREATE INDEX ON t (b) ;
+-- trailing notes
+`
+	got := statements(sql)
+	want := []string{
+		"-- 0009_example.sql\nCREATE TABLE t (\n  a INT8 PRIMARY KEY, -- not the end;\n",
+		"  b STRING\n);\n",
+		"INSERT INTO t VALUES (1, 'x;y');\n",
+		"  CREATE INDEX ON t (b) ;\n",
+	}
+	if !slices.Equal(got, want) {
+		t.Fatalf("statements:\n%q\nwant\n%q", got, want)
+	}
+	if got := statements("SELECT 1;\nSELECT 2"); !slices.Equal(got, []string{"SELECT 1;\n", "SELECT 2"}) {
+		t.Fatalf("last statement without a semicolon: %q", got)
+	}
+	if got := statements("\n-- nothing\n\n"); len(got) != 0 {
+		t.Fatalf("comments only: %q", got)
+	}
+}
+
+func TestMigrationsAreOrderedAndSplit(t *testing.T) {
+	ms, err := Migrations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, m := range ms {
+		if m.Version != i+1 || m.Name == "" || len(m.Checksum) != 64 {
+			t.Fatalf("migration %d: version %d, name %q, checksum %q", i, m.Version, m.Name, m.Checksum)
+		}
+		stmts := statements(m.SQL)
+		if len(stmts) == 0 {
+			t.Fatalf("migration %04d_%s has no statements", m.Version, m.Name)
+		}
+		for _, stmt := range stmts {
+			if !strings.HasSuffix(strings.TrimSpace(stmt), ";") {
+				t.Fatalf("migration %04d_%s: statement %q doesn't end in a semicolon", m.Version, m.Name, stmt)
+			}
+		}
+	}
+}
+
+// lockRow stands in for the version 0 row of schema_migrations.
+type lockRow struct {
+	mu     sync.Mutex
+	holder string
+	taken  time.Time
+}
+
+// newLock returns a lock for holder on row that retries quickly.
+func (row *lockRow) newLock(holder string) *migrationLock {
+	return &migrationLock{
+		holder: holder,
+		ttl:    migrationLockTTL,
+		retry:  5 * time.Millisecond,
+		exec: func(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+			row.mu.Lock()
+			defer row.mu.Unlock()
+			switch sql = strings.TrimSpace(sql); {
+			case strings.HasPrefix(sql, "INSERT"):
+				ttl := time.Duration(args[1].(int64)) * time.Second
+				if row.holder != "" && time.Since(row.taken) < ttl {
+					return pgconn.NewCommandTag("INSERT 0 0"), nil
+				}
+				row.holder, row.taken = args[0].(string), time.Now()
+				return pgconn.NewCommandTag("INSERT 0 1"), nil
+			case strings.HasPrefix(sql, "DELETE"):
+				if row.holder != args[0].(string) {
+					return pgconn.NewCommandTag("DELETE 0"), nil
+				}
+				row.holder = ""
+				return pgconn.NewCommandTag("DELETE 1"), nil
+			}
+			return pgconn.CommandTag{}, errors.New("unexpected statement")
+		},
+	}
+}
+
+func (row *lockRow) heldBy() string {
+	row.mu.Lock()
+	defer row.mu.Unlock()
+	return row.holder
+}
+
+func TestMigrationLockWaitsForHolderOrExpiry(t *testing.T) {
+	ctx := context.Background()
+	row := &lockRow{}
+	a, b, c := row.newLock("a"), row.newLock("b"), row.newLock("c")
+	if err := a.lock(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	short, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	if err := b.lock(short); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("lock held by another: %v, want to wait until the deadline", err)
+	}
+
+	taken := make(chan error, 1)
+	go func() { taken <- b.lock(ctx) }()
+	time.Sleep(20 * time.Millisecond)
+	if holder := row.heldBy(); holder != "a" {
+		t.Fatalf("lock taken by %q while a held it", holder)
+	}
+	if err := a.unlock(ctx); err != nil {
+		t.Fatalf("unlock: %v", err)
+	}
+	select {
+	case err := <-taken:
+		if err != nil || row.heldBy() != "b" {
+			t.Fatalf("waiter after unlock: %v, held by %q", err, row.heldBy())
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("waiter never took the released lock")
+	}
+
+	// A holder that died stops blocking others once its lock expires.
+	row.mu.Lock()
+	row.taken = time.Now().Add(-migrationLockTTL - time.Minute)
+	row.mu.Unlock()
+	if err := c.lock(ctx); err != nil || row.heldBy() != "c" {
+		t.Fatalf("taking an expired lock: %v, held by %q", err, row.heldBy())
+	}
+
+	// The old holder finding its lock taken must not release the new one.
+	if err := b.unlock(ctx); !errors.Is(err, ErrMigrationLockLost) {
+		t.Fatalf("unlock of a lock taken over: %v, want ErrMigrationLockLost", err)
+	}
+	if holder := row.heldBy(); holder != "c" {
+		t.Fatalf("lock held by %q after a stale unlock, want c", holder)
+	}
+	if err := c.unlock(ctx); err != nil || row.heldBy() != "" {
+		t.Fatalf("unlock by the holder: %v, held by %q", err, row.heldBy())
+	}
+}
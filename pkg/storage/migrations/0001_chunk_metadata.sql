@@ -0,0 +1,24 @@
+-- File metadata: one row per file holding its latest version.
+CREATE TABLE IF NOT EXISTS public.chunk_metadata (
+  folder STRING NOT NULL DEFAULT '/',
+  file_name STRING NOT NULL,
+  seq INT8 NOT NULL,
+  state STRING NOT NULL,
+  path STRING NOT NULL,
+  size INT8 NOT NULL DEFAULT 0,
+  checksum STRING NOT NULL DEFAULT '',
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  modified_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  deleted BOOL NOT NULL DEFAULT false,
+  clean_seq INT8 NOT NULL DEFAULT 0,
+  CONSTRAINT pk_folder_file PRIMARY KEY (folder, file_name)
+);
+
+-- Upgrade tables created before file stats were tracked
+ALTER TABLE public.chunk_metadata ADD COLUMN IF NOT EXISTS size INT8 NOT NULL DEFAULT 0;
+ALTER TABLE public.chunk_metadata ADD COLUMN IF NOT EXISTS checksum STRING NOT NULL DEFAULT '';
+ALTER TABLE public.chunk_metadata ADD COLUMN IF NOT EXISTS created_at TIMESTAMPTZ NOT NULL DEFAULT now();
+ALTER TABLE public.chunk_metadata ADD COLUMN IF NOT EXISTS modified_at TIMESTAMPTZ NOT NULL DEFAULT now();
+ALTER TABLE public.chunk_metadata ADD COLUMN IF NOT EXISTS deleted BOOL NOT NULL DEFAULT false;
+ALTER TABLE public.chunk_metadata ADD COLUMN IF NOT EXISTS clean_seq INT8 NOT NULL DEFAULT 0;
+UPDATE public.chunk_metadata SET clean_seq = seq WHERE state = 'clean' AND clean_seq = 0;
@@ -0,0 +1,20 @@
+-- First-class directories: one row per directory, keyed by its parent so a
+-- folder's children are a prefix scan. The root "/" is implicit.
+CREATE TABLE IF NOT EXISTS public.directories (
+  parent STRING NOT NULL,
+  name STRING NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  CONSTRAINT pk_parent_name PRIMARY KEY (parent, name)
+);
+
+-- One-time backfill of directories implied by files written before
+-- directories existed, including every ancestor.
+INSERT INTO public.directories (parent, name)
+WITH RECURSIVE d (path) AS (
+  SELECT DISTINCT folder FROM public.chunk_metadata WHERE folder <> '/'
+  UNION
+  SELECT regexp_replace(path, '/[^/]+$', '') FROM d WHERE path LIKE '/%/%'
+)
+SELECT COALESCE(NULLIF(regexp_replace(path, '/[^/]+$', ''), ''), '/'), regexp_extract(path, '[^/]+$')
+FROM d
+ON CONFLICT (parent, name) DO NOTHING;
@@ -0,0 +1,13 @@
+-- Change log behind the Watch RPC. id is the resume cursor; the unique key
+-- lets every replica record the commits it sees without duplicates.
+CREATE TABLE IF NOT EXISTS public.change_events (
+  id INT8 NOT NULL DEFAULT unique_rowid(),
+  folder STRING NOT NULL,
+  file_name STRING NOT NULL,
+  seq INT8 NOT NULL,
+  kind STRING NOT NULL,
+  is_dir BOOL NOT NULL DEFAULT false,
+  committed_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  CONSTRAINT pk_change_events PRIMARY KEY (id),
+  CONSTRAINT uq_change_events UNIQUE (folder, file_name, seq, kind)
+);
@@ -0,0 +1,18 @@
+-- Point-in-time snapshots. snapshot_files pins the committed seq of every
+-- file a snapshot covers; those versions must outlive newer writes.
+CREATE TABLE IF NOT EXISTS public.snapshots (
+  name STRING NOT NULL,
+  folder STRING NOT NULL,
+  file_count INT8 NOT NULL DEFAULT 0,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  CONSTRAINT pk_snapshots PRIMARY KEY (name)
+);
+
+CREATE TABLE IF NOT EXISTS public.snapshot_files (
+  snapshot STRING NOT NULL,
+  folder STRING NOT NULL,
+  file_name STRING NOT NULL,
+  seq INT8 NOT NULL,
+  CONSTRAINT pk_snapshot_files PRIMARY KEY (snapshot, folder, file_name),
+  INDEX idx_snapshot_files_file (folder, file_name, seq)
+);
@@ -0,0 +1,8 @@
+-- Per-folder quotas, enforced by the head against the live files in the
+-- folder tree. 0 means unlimited.
+CREATE TABLE IF NOT EXISTS public.folder_quotas (
+  folder STRING NOT NULL,
+  max_bytes INT8 NOT NULL DEFAULT 0,
+  max_files INT8 NOT NULL DEFAULT 0,
+  CONSTRAINT pk_folder_quotas PRIMARY KEY (folder)
+);
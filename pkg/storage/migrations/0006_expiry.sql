@@ -0,0 +1,11 @@
+-- Per-file expiry, and the index the head's reaper scans.
+ALTER TABLE public.chunk_metadata ADD COLUMN IF NOT EXISTS expires_at TIMESTAMPTZ NULL;
+CREATE INDEX IF NOT EXISTS idx_chunk_metadata_expires_at ON public.chunk_metadata (expires_at) WHERE expires_at IS NOT NULL;
+
+-- Default TTL for files written under a folder tree; the nearest folder
+-- with a row wins.
+CREATE TABLE IF NOT EXISTS public.folder_ttls (
+  folder STRING NOT NULL,
+  ttl_seconds INT8 NOT NULL,
+  CONSTRAINT pk_folder_ttls PRIMARY KEY (folder)
+);
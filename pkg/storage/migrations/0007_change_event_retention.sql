@@ -0,0 +1,10 @@
+-- Pruning drops change events by age; the marker records the cursor of the
+-- newest one dropped, so a watcher resuming before it learns it missed some.
+CREATE INDEX IF NOT EXISTS idx_change_events_committed_at ON public.change_events (committed_at);
+
+CREATE TABLE IF NOT EXISTS public.change_events_pruned (
+  singleton BOOL NOT NULL DEFAULT true,
+  through INT8 NOT NULL,
+  CONSTRAINT pk_change_events_pruned PRIMARY KEY (singleton),
+  CONSTRAINT ck_change_events_pruned_singleton CHECK (singleton)
+);
@@ -0,0 +1,15 @@
+package storage
+
+// Quota limits what a folder tree may hold. A zero limit is unlimited.
+type Quota struct {
+	Folder   string
+	MaxBytes uint64
+	MaxFiles uint64
+}
+
+// Usage is what a folder tree holds: the size and count of its live files,
+// counting the latest version of each, committed or not.
+type Usage struct {
+	Bytes uint64
+	Files uint64
+}
@@ -0,0 +1,310 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const (
+	testAccessKey = "AKIDEXAMPLE"
+	testSecretKey = "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"
+	testRegion    = "eu-central-1"
+	testBucket    = "craq"
+)
+
+// fakeS3 is a stand-in for an S3 endpoint: one bucket, path-style URLs,
+// ListObjectsV2 in pages of pageSize, and every request's Signature V4
+// checked against the test credentials.
+type fakeS3 struct {
+	pageSize int
+
+	mu      sync.Mutex
+	objects map[string]fakeObject
+}
+
+type fakeObject struct {
+	data    []byte
+	modTime time.Time
+}
+
+func newFakeS3(t *testing.T) (*fakeS3, *httptest.Server) {
+	f := &fakeS3{pageSize: 2, objects: make(map[string]fakeObject)}
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+	return f, srv
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		f.fail(w, http.StatusBadRequest, "IncompleteBody", err.Error())
+		return
+	}
+	if err := verifySigV4(r, body); err != nil {
+		f.fail(w, http.StatusForbidden, "SignatureDoesNotMatch", err.Error())
+		return
+	}
+
+	path := strings.TrimPrefix(r.URL.Path, "/")
+	bucket, key, _ := strings.Cut(path, "/")
+	if bucket != testBucket {
+		f.fail(w, http.StatusNotFound, "NoSuchBucket", bucket)
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch {
+	case key == "" && r.Method == http.MethodGet && r.URL.Query().Get("list-type") == "2":
+		f.list(w, r.URL.Query())
+	case r.Method == http.MethodPut && r.Header.Get("X-Amz-Copy-Source") != "":
+		src, _ := url.PathUnescape(r.Header.Get("X-Amz-Copy-Source"))
+		obj, ok := f.objects[strings.TrimPrefix(src, "/"+testBucket+"/")]
+		if !ok || src != "/"+testBucket+"/"+key {
+			f.fail(w, http.StatusNotFound, "NoSuchKey", src)
+			return
+		}
+		if r.Header.Get("X-Amz-Metadata-Directive") != "REPLACE" {
+			f.fail(w, http.StatusBadRequest, "InvalidRequest", "copy onto itself without REPLACE")
+			return
+		}
+		obj.modTime = time.Now()
+		f.objects[key] = obj
+	case r.Method == http.MethodPut:
+		f.objects[key] = fakeObject{data: body, modTime: time.Now()}
+	case r.Method == http.MethodGet, r.Method == http.MethodHead:
+		obj, ok := f.objects[key]
+		if !ok {
+			f.fail(w, http.StatusNotFound, "NoSuchKey", key)
+			return
+		}
+		w.Header().Set("Content-Length", fmt.Sprint(len(obj.data)))
+		w.Header().Set("Last-Modified", obj.modTime.UTC().Format(http.TimeFormat))
+		if r.Method == http.MethodGet {
+			w.Write(obj.data)
+		}
+	case r.Method == http.MethodDelete:
+		delete(f.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		f.fail(w, http.StatusMethodNotAllowed, "MethodNotAllowed", r.Method)
+	}
+}
+
+func (f *fakeS3) list(w http.ResponseWriter, q url.Values) {
+	var keys []string
+	for k := range f.objects {
+		if strings.HasPrefix(k, q.Get("prefix")) && k > q.Get("continuation-token") {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	type entry struct {
+		Key          string
+		Size         int
+		LastModified string
+	}
+	var result struct {
+		XMLName               xml.Name `xml:"ListBucketResult"`
+		Contents              []entry
+		IsTruncated           bool
+		NextContinuationToken string `xml:",omitempty"`
+	}
+	if len(keys) > f.pageSize {
+		keys = keys[:f.pageSize]
+		result.IsTruncated = true
+		result.NextContinuationToken = keys[len(keys)-1]
+	}
+	for _, k := range keys {
+		obj := f.objects[k]
+		result.Contents = append(result.Contents, entry{k, len(obj.data), obj.modTime.UTC().Format(time.RFC3339Nano)})
+	}
+	w.Header().Set("Content-Type", "application/xml")
+	xml.NewEncoder(w).Encode(result)
+}
+
+func (f *fakeS3) fail(w http.ResponseWriter, code int, s3Code, msg string) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(code)
+	fmt.Fprintf(w, "<Error><Code>%s</Code><Message>%s</Message></Error>", s3Code, msg)
+}
+
+// verifySigV4 checks r's Signature V4 the way S3 does, from the request as
+// it arrived.
+func verifySigV4(r *http.Request, body []byte) error {
+	auth, ok := strings.CutPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 ")
+	if !ok {
+		return errors.New("missing AWS4-HMAC-SHA256 authorization")
+	}
+	fields := make(map[string]string)
+	for _, part := range strings.Split(auth, ",") {
+		k, v, _ := strings.Cut(strings.TrimSpace(part), "=")
+		fields[k] = v
+	}
+	cred := strings.Split(fields["Credential"], "/")
+	if len(cred) != 5 || cred[0] != testAccessKey || cred[2] != testRegion || cred[3] != "s3" || cred[4] != "aws4_request" {
+		return fmt.Errorf("bad credential scope %q", fields["Credential"])
+	}
+	amzDate := r.Header.Get("X-Amz-Date")
+	if !strings.HasPrefix(amzDate, cred[1]) {
+		return fmt.Errorf("date %s outside scope %s", amzDate, cred[1])
+	}
+	payloadHash := r.Header.Get("X-Amz-Content-Sha256")
+	if sum := sha256.Sum256(body); payloadHash != hex.EncodeToString(sum[:]) {
+		return errors.New("payload hash does not match body")
+	}
+
+	signed := strings.Split(fields["SignedHeaders"], ";")
+	var headers strings.Builder
+	for _, h := range signed {
+		v := r.Header.Get(h)
+		if h == "host" {
+			v = r.Host
+		}
+		headers.WriteString(h + ":" + strings.TrimSpace(v) + "\n")
+	}
+	for _, must := range []string{"host", "x-amz-date", "x-amz-content-sha256"} {
+		if !strings.Contains(";"+fields["SignedHeaders"]+";", ";"+must+";") {
+			return fmt.Errorf("%s not signed", must)
+		}
+	}
+
+	query := r.URL.Query()
+	var params []string
+	for k, vs := range query {
+		for _, v := range vs {
+			params = append(params, awsEscape(k)+"="+awsEscape(v))
+		}
+	}
+	sort.Strings(params)
+
+	rawPath, _, _ := strings.Cut(r.RequestURI, "?")
+	canonical := strings.Join([]string{
+		r.Method, rawPath, strings.Join(params, "&"), headers.String(), fields["SignedHeaders"], payloadHash,
+	}, "\n")
+	sum := sha256.Sum256([]byte(canonical))
+	scope := strings.Join(cred[1:], "/")
+	toSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(sum[:])
+
+	key := []byte("AWS4" + testSecretKey)
+	for _, part := range append(cred[1:], toSign) {
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte(part))
+		key = mac.Sum(nil)
+	}
+	if hex.EncodeToString(key) != fields["Signature"] {
+		return errors.New("signature mismatch")
+	}
+	return nil
+}
+
+// awsEscape encodes s as RFC 3986 requires for signed query strings.
+func awsEscape(s string) string {
+	s = url.QueryEscape(s)
+	return strings.NewReplacer("+", "%20", "*", "%2A", "%7E", "~").Replace(s)
+}
+
+func newTestS3Store(t *testing.T, srv *httptest.Server, secret, prefix string) *S3BlobStore {
+	t.Helper()
+	s, err := NewS3BlobStore(S3Config{
+		Endpoint:  srv.URL,
+		Bucket:    testBucket,
+		Region:    testRegion,
+		AccessKey: testAccessKey,
+		SecretKey: secret,
+		Prefix:    prefix,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestS3BlobStore(t *testing.T) {
+	ctx := context.Background()
+	fake, srv := newFakeS3(t)
+	s := newTestS3Store(t, srv, testSecretKey, "node1")
+	other := newTestS3Store(t, srv, testSecretKey, "node2")
+
+	// Keys needing escaping must sign and round-trip too.
+	keys := []string{"chunks/ab/abc", "chunks/ab/abd", "manifests/my docs/ü+file/1.manifest", "manifests/x/y/2.manifest", "chunks/cd/cde"}
+	for i, key := range keys {
+		if err := WriteBlob(ctx, s, key, []byte(fmt.Sprintf("blob %d", i))); err != nil {
+			t.Fatalf("put %s: %v", key, err)
+		}
+	}
+	if err := WriteBlob(ctx, other, "chunks/ab/abc", []byte("not mine")); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := fake.objects["node1/manifests/my docs/ü+file/1.manifest"]; !ok {
+		t.Fatalf("object not stored under the node's prefix: %v", fake.objects)
+	}
+
+	data, err := ReadBlob(ctx, s, keys[2])
+	if err != nil || string(data) != "blob 2" {
+		t.Fatalf("open %s: %q, %v", keys[2], data, err)
+	}
+	info, err := s.Stat(ctx, keys[0])
+	if err != nil || info.Key != keys[0] || info.Size != int64(len("blob 0")) || time.Since(info.ModTime) > time.Minute {
+		t.Fatalf("stat %s: %+v, %v", keys[0], info, err)
+	}
+
+	// Three chunks take two pages; the other node's and the manifests
+	// aren't listed.
+	var listed []string
+	err = s.List(ctx, "chunks/", func(info BlobInfo) error {
+		listed = append(listed, info.Key)
+		return nil
+	})
+	if want := []string{"chunks/ab/abc", "chunks/ab/abd", "chunks/cd/cde"}; err != nil || strings.Join(listed, ",") != strings.Join(want, ",") {
+		t.Fatalf("list chunks/: %v, %v; want %v", listed, err, want)
+	}
+
+	if err := s.Touch(ctx, keys[1]); err != nil {
+		t.Fatalf("touch: %v", err)
+	}
+
+	if err := s.Delete(ctx, keys[0]); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if err := s.Delete(ctx, keys[0]); err != nil {
+		t.Fatalf("delete of a missing key: %v", err)
+	}
+	if _, err := s.Open(ctx, keys[0]); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("open deleted: %v, want ErrNotFound", err)
+	}
+	if _, err := s.Stat(ctx, keys[0]); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("stat deleted: %v, want ErrNotFound", err)
+	}
+	if err := s.Touch(ctx, keys[0]); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("touch deleted: %v, want ErrNotFound", err)
+	}
+	if data, err := ReadBlob(ctx, other, "chunks/ab/abc"); err != nil || string(data) != "not mine" {
+		t.Fatalf("other node's blob: %q, %v", data, err)
+	}
+}
+
+func TestS3BlobStoreBadSignature(t *testing.T) {
+	_, srv := newFakeS3(t)
+	s := newTestS3Store(t, srv, "wrong secret", "node1")
+
+	err := WriteBlob(context.Background(), s, "chunks/ab/abc", []byte("x"))
+	if err == nil || errors.Is(err, ErrNotFound) || !strings.Contains(err.Error(), "SignatureDoesNotMatch") {
+		t.Fatalf("put with a wrong secret: %v, want SignatureDoesNotMatch", err)
+	}
+}
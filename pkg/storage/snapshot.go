@@ -0,0 +1,28 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+)
+
+var (
+	ErrSnapshotExists   = fmt.Errorf("snapshot already exists: %w", ErrConflict)
+	ErrSnapshotNotFound = fmt.Errorf("snapshot %w", ErrNotFound)
+	ErrNotInSnapshot    = fmt.Errorf("file not in snapshot: %w", ErrNotFound)
+)
+
+// Snapshot is a frozen view of a folder tree: the committed seq every file
+// under Folder had when it was taken.
+type Snapshot struct {
+	Name      string
+	Folder    string
+	FileCount uint64
+	CreatedAt time.Time
+}
+
+// SnapshotFile is one file version a snapshot pins.
+type SnapshotFile struct {
+	Folder   string
+	FileName string
+	Seq      uint64
+}
@@ -1,5 +1,33 @@
 package storage
 
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Errors every StorageClient reports. The more specific errors, such as
+// ErrDirNotFound, wrap one of these so callers can test for the kind.
+var (
+	ErrNotFound = errors.New("not found")
+	// ErrStale is returned for a request naming a version older than the
+	// one stored.
+	ErrStale = errors.New("stale version")
+	// ErrConflict is returned for a request that clashes with what is
+	// stored.
+	ErrConflict = errors.New("conflict")
+)
+
+// markCleanError explains why version seq of a file could not be marked
+// clean, given the seq stored for it (0 if none).
+func markCleanError(folder, fileName string, seq, stored uint64) error {
+	if stored > seq {
+		return fmt.Errorf("mark %s/%s@%d clean: seq %d is stored: %w", folder, fileName, seq, stored, ErrStale)
+	}
+	return fmt.Errorf("mark %s/%s@%d clean: %w", folder, fileName, seq, ErrNotFound)
+}
+
 // VersionState represents whether a chunk is dirty or clean.
 type VersionState int
 
@@ -8,18 +36,111 @@ const (
 	Clean
 )
 
+func (s VersionState) String() string {
+	if s == Clean {
+		return "clean"
+	}
+	return "dirty"
+}
+
 // Chunk stores metadata and data for a versioned chunk.
 type Chunk struct {
 	Folder   string       // Folder
 	FileName string       // Original file name
 	Seq      uint64       // Version/sequence number
 	State    VersionState // "clean" or "dirty"
-	Path     string       // Path to the chunk file on disk
+	Path     string       // BlobStore key of the version's manifest
+
+	Size       uint64    // File size in bytes
+	Checksum   string    // SHA-256 of the file content
+	CreatedAt  time.Time // When the first version was written
+	ModifiedAt time.Time // When this version was written
+
+	Deleted bool // Tombstoned by a recursive rmdir
+
+	CleanSeq uint64 // Latest committed version; 0 if none yet
+
+	ExpiresAt time.Time // When the version expires; zero if never
 }
 
+// Expired reports whether the version has expired at now.
+func (c Chunk) Expired(now time.Time) bool {
+	return !c.ExpiresAt.IsZero() && !now.Before(c.ExpiresAt)
+}
+
+// StorageClient is a node's metadata store. Every method gives up when
+// ctx is done. Missing files, directories and snapshots are reported with
+// errors wrapping ErrNotFound.
 type StorageClient interface {
-	Put(seq uint64, fileName, folder, path string) error
-	MarkClean(folder, fileName string, seq uint64) error
-	GetLatest(folder, fileName string) (Chunk, bool)
-	ListFilesInFolder(folder string) ([]string, error)
+	// Put stores c as a dirty version if c.Seq is newer than what's stored;
+	// an older or equal version is already applied and ignored.
+	Put(ctx context.Context, c Chunk) error
+	// MarkClean commits version seq. It fails with ErrStale if a newer
+	// version is stored and with ErrNotFound if seq isn't.
+	MarkClean(ctx context.Context, folder, fileName string, seq uint64) error
+	// PutBatch and MarkCleanBatch apply Put and MarkClean to several
+	// files in one transaction, so readers see all of them or none.
+	PutBatch(ctx context.Context, chunks []Chunk) error
+	MarkCleanBatch(ctx context.Context, chunks []Chunk) error
+	// GetLatest returns the newest stored version of a file, or ErrNotFound.
+	GetLatest(ctx context.Context, folder, fileName string) (Chunk, error)
+	ListFilesInFolder(ctx context.Context, q ListQuery) (ListPage, error)
+
+	// Mkdir creates dir; with parents it also creates missing ancestors,
+	// otherwise a missing parent is ErrDirNotFound. Existing dirs are fine.
+	Mkdir(ctx context.Context, dir string, parents bool) error
+	// Rmdir removes dir. Without recursive it fails with ErrDirNotEmpty if
+	// dir has subfolders or files; with it, those are removed and the files
+	// tombstoned.
+	Rmdir(ctx context.Context, dir string, recursive bool) error
+	GetDir(ctx context.Context, dir string) (Dir, error)
+
+	// RecordEvent appends e to the change log unless it is already there.
+	RecordEvent(ctx context.Context, e Event) error
+	// EventsSince returns up to limit events after cursor for files in
+	// folder, or anywhere below it when recursive. It fails with
+	// ErrEventsPruned if events after cursor have been pruned.
+	EventsSince(ctx context.Context, cursor uint64, folder string, recursive bool, limit int) ([]Event, error)
+	// LastEventID returns the newest cursor, or 0 if no event exists.
+	LastEventID(ctx context.Context) (uint64, error)
+	// PruneEvents drops the events committed before before and returns how
+	// many it dropped.
+	PruneEvents(ctx context.Context, before time.Time) (int, error)
+
+	// CreateSnapshot records, in one transaction, the committed seq of every
+	// live file in folder and below it under name.
+	CreateSnapshot(ctx context.Context, name, folder string) (Snapshot, error)
+	GetSnapshot(ctx context.Context, name string) (Snapshot, error)
+	ListSnapshots(ctx context.Context) ([]Snapshot, error)
+	DeleteSnapshot(ctx context.Context, name string) error
+	// SnapshotSeq returns the seq of a file recorded in snapshot name.
+	SnapshotSeq(ctx context.Context, name, folder, fileName string) (uint64, error)
+	// SnapshotSeqs returns every seq of a file pinned by some snapshot.
+	SnapshotSeqs(ctx context.Context, folder, fileName string) ([]uint64, error)
+	// SnapshotFiles returns every file version snapshot name pins.
+	SnapshotFiles(ctx context.Context, name string) ([]SnapshotFile, error)
+	// PutSnapshot records a snapshot taken elsewhere, pinning exactly
+	// files. It fails with ErrSnapshotExists if the name is taken.
+	PutSnapshot(ctx context.Context, snap Snapshot, files []SnapshotFile) error
+
+	// SetQuota sets the quota of q.Folder; with both limits 0 it removes it.
+	SetQuota(ctx context.Context, q Quota) error
+	ListQuotas(ctx context.Context) ([]Quota, error)
+	// QuotasFor returns the quotas on folder and on each of its ancestors.
+	QuotasFor(ctx context.Context, folder string) ([]Quota, error)
+	// Usage totals the live files in folder and below it.
+	Usage(ctx context.Context, folder string) (Usage, error)
+
+	// DeleteFile tombstones a file as version seq unless a newer version
+	// is already stored.
+	DeleteFile(ctx context.Context, folder, fileName string, seq uint64) error
+	// ExpiredFiles returns up to limit live files whose latest version has
+	// expired, ordered by (folder, file_name) after the cursor returned as
+	// the second value, which is "" once there are no more.
+	ExpiredFiles(ctx context.Context, after string, limit int) ([]Chunk, string, error)
+	// SetFolderTTL sets the default TTL of files written under folder; 0
+	// removes it. FolderTTL returns the default of the nearest folder that
+	// has one, or 0.
+	SetFolderTTL(ctx context.Context, folder string, ttl time.Duration) error
+	FolderTTL(ctx context.Context, folder string) (time.Duration, error)
 }
@@ -0,0 +1,204 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStoresRefuseDoneContexts(t *testing.T) {
+	bolt, err := NewBoltStore(filepath.Join(t.TempDir(), "meta.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer bolt.Close()
+
+	for name, store := range map[string]StorageClient{"mem": NewMemStore(), "bolt": bolt} {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		if err := store.Put(ctx, Chunk{Folder: "/docs", FileName: "a", Seq: 1}); !errors.Is(err, context.Canceled) {
+			t.Fatalf("%s: put with a cancelled context: %v", name, err)
+		}
+		if _, err := store.GetLatest(ctx, "/docs", "a"); !errors.Is(err, context.Canceled) {
+			t.Fatalf("%s: get with a cancelled context: %v", name, err)
+		}
+		if err := store.SetQuota(ctx, Quota{Folder: "/docs", MaxBytes: 1}); !errors.Is(err, context.Canceled) {
+			t.Fatalf("%s: set quota with a cancelled context: %v", name, err)
+		}
+		if _, err := store.GetLatest(context.Background(), "/docs", "a"); err == nil {
+			t.Fatalf("%s: put with a cancelled context was applied", name)
+		}
+	}
+}
+
+func TestBoltStoreKeepsStateWhenReopened(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "meta.db")
+	store, err := NewBoltStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Mkdir(ctx, "/docs/sub", true); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Put(ctx, Chunk{Folder: "/docs", FileName: "a", Seq: 1, Size: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.MarkClean(ctx, "/docs", "a", 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Put(ctx, Chunk{Folder: "/docs", FileName: "a", Seq: 2, Size: 2}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.RecordEvent(ctx, Event{Kind: EventCreate, Folder: "/docs", FileName: "a", Seq: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.SetQuota(ctx, Quota{Folder: "/docs", MaxFiles: 5}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.SetFolderTTL(ctx, "/docs", time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.CreateSnapshot(ctx, "snap", "/docs"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	store, err = NewBoltStore(path)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer store.Close()
+	if c, err := store.GetLatest(ctx, "/docs", "a"); err != nil || c.Seq != 2 || c.CleanSeq != 1 || c.Size != 2 {
+		t.Fatalf("file after reopening: %+v, %v; want seq 2 dirty over clean 1", c, err)
+	}
+	if _, err := store.GetDir(ctx, "/docs/sub"); err != nil {
+		t.Fatalf("folder after reopening: %v", err)
+	}
+	if id, err := store.LastEventID(ctx); err != nil || id != 1 {
+		t.Fatalf("last event after reopening: %d, %v", id, err)
+	}
+	if quotas, err := store.QuotasFor(ctx, "/docs"); err != nil || len(quotas) != 1 || quotas[0].MaxFiles != 5 {
+		t.Fatalf("quotas after reopening: %+v, %v", quotas, err)
+	}
+	if ttl, err := store.FolderTTL(ctx, "/docs/sub"); err != nil || ttl != time.Hour {
+		t.Fatalf("folder TTL after reopening: %v, %v", ttl, err)
+	}
+	if seq, err := store.SnapshotSeq(ctx, "snap", "/docs", "a"); err != nil || seq != 1 {
+		t.Fatalf("snapshot after reopening: %d, %v", seq, err)
+	}
+
+	// New events continue the old cursor rather than restarting it.
+	if err := store.RecordEvent(ctx, Event{Kind: EventUpdate, Folder: "/docs", FileName: "a", Seq: 2}); err != nil {
+		t.Fatal(err)
+	}
+	if events, err := store.EventsSince(ctx, 0, "/docs", false, 10); err != nil || len(events) != 2 || events[1].ID != 2 {
+		t.Fatalf("events after reopening: %+v, %v", events, err)
+	}
+}
+
+func TestStoresPageExpiredFiles(t *testing.T) {
+	ctx := context.Background()
+	bolt, err := NewBoltStore(filepath.Join(t.TempDir(), "meta.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer bolt.Close()
+
+	past, future := time.Now().Add(-time.Minute), time.Now().Add(time.Hour)
+	for name, store := range map[string]StorageClient{"mem": NewMemStore(), "bolt": bolt} {
+		for _, c := range []Chunk{
+			{Folder: "/a", FileName: "x", ExpiresAt: past},
+			{Folder: "/a", FileName: "y", ExpiresAt: future},
+			{Folder: "/a", FileName: "z", ExpiresAt: past},
+			{Folder: "/a/b", FileName: "x", ExpiresAt: past},
+			{Folder: "/b", FileName: "forever"},
+			{Folder: "/b", FileName: "gone", ExpiresAt: past},
+			{Folder: "/c", FileName: "x", ExpiresAt: past},
+		} {
+			c.Seq = 1
+			if err := store.Put(ctx, c); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := store.DeleteFile(ctx, "/b", "gone", 2); err != nil {
+			t.Fatal(err)
+		}
+
+		var got []string
+		after := ""
+		for pages := 1; ; pages++ {
+			page, next, err := store.ExpiredFiles(ctx, after, 2)
+			if err != nil {
+				t.Fatalf("%s: page %d: %v", name, pages, err)
+			}
+			if len(page) > 2 || pages > 3 {
+				t.Fatalf("%s: page %d of %d files", name, pages, len(page))
+			}
+			for _, c := range page {
+				got = append(got, c.Folder+"/"+c.FileName)
+			}
+			if next == "" {
+				break
+			}
+			after = next
+		}
+		if want := "/a/x,/a/z,/a/b/x,/c/x"; strings.Join(got, ",") != want {
+			t.Fatalf("%s: expired files %v, want %s", name, got, want)
+		}
+	}
+}
+
+func TestStoresPruneOldEvents(t *testing.T) {
+	ctx := context.Background()
+	bolt, err := NewBoltStore(filepath.Join(t.TempDir(), "meta.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer bolt.Close()
+
+	for name, store := range map[string]StorageClient{"mem": NewMemStore(), "bolt": bolt} {
+		record := func(fileName string) {
+			if err := store.RecordEvent(ctx, Event{Kind: EventCreate, Folder: "/docs", FileName: fileName, Seq: 1}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		record("a")
+		record("b")
+		time.Sleep(time.Millisecond)
+		cutoff := time.Now()
+		time.Sleep(time.Millisecond)
+		record("c")
+
+		if n, err := store.PruneEvents(ctx, cutoff); err != nil || n != 2 {
+			t.Fatalf("%s: pruned %d, %v; want the 2 older events", name, n, err)
+		}
+		for _, cursor := range []uint64{0, 1} {
+			if _, err := store.EventsSince(ctx, cursor, "/docs", false, 10); !errors.Is(err, ErrEventsPruned) {
+				t.Fatalf("%s: events since pruned cursor %d: %v, want ErrEventsPruned", name, cursor, err)
+			}
+		}
+		if events, err := store.EventsSince(ctx, 2, "/docs", false, 10); err != nil || len(events) != 1 || events[0].FileName != "c" {
+			t.Fatalf("%s: events since the newest pruned: %+v, %v; want c", name, events, err)
+		}
+		if n, err := store.PruneEvents(ctx, cutoff); err != nil || n != 0 {
+			t.Fatalf("%s: second prune dropped %d, %v", name, n, err)
+		}
+
+		// With every event pruned, the newest cursor still stands.
+		if n, err := store.PruneEvents(ctx, time.Now()); err != nil || n != 1 {
+			t.Fatalf("%s: pruning the rest dropped %d, %v", name, n, err)
+		}
+		if id, err := store.LastEventID(ctx); err != nil || id != 3 {
+			t.Fatalf("%s: last event after pruning all: %d, %v; want 3", name, id, err)
+		}
+		if events, err := store.EventsSince(ctx, 3, "/docs", false, 10); err != nil || len(events) != 0 {
+			t.Fatalf("%s: events since the last cursor: %+v, %v", name, events, err)
+		}
+	}
+}
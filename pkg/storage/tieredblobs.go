@@ -0,0 +1,180 @@
+package storage
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// TieredBlobStore puts a compressed archive tier behind a hot BlobStore.
+// New blobs go to the hot tier; Archive moves one to the archive tier,
+// gzipped, and Rehydrate moves it back. Reads, stats and listings see both
+// tiers, preferring the hot copy, so a blob stays readable wherever it is
+// and while it moves.
+type TieredBlobStore struct {
+	hot, archive BlobStore
+}
+
+func NewTieredBlobStore(hot, archive BlobStore) *TieredBlobStore {
+	return &TieredBlobStore{hot: hot, archive: archive}
+}
+
+func (t *TieredBlobStore) Create(ctx context.Context, key string) (BlobWriter, error) {
+	return t.hot.Create(ctx, key)
+}
+
+// Open reads an archived blob in place, decompressing it, without
+// rehydrating it.
+func (t *TieredBlobStore) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	r, err := t.hot.Open(ctx, key)
+	if !errors.Is(err, ErrNotFound) {
+		return r, err
+	}
+	ar, err := t.archive.Open(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	zr, err := gzip.NewReader(ar)
+	if err != nil {
+		ar.Close()
+		return nil, fmt.Errorf("archived blob %s: %w", key, err)
+	}
+	return &archiveReader{Reader: zr, zr: zr, ar: ar}, nil
+}
+
+type archiveReader struct {
+	io.Reader
+	zr *gzip.Reader
+	ar io.Closer
+}
+
+func (r *archiveReader) Close() error {
+	r.zr.Close()
+	return r.ar.Close()
+}
+
+// Stat reports an archived blob with its compressed size.
+func (t *TieredBlobStore) Stat(ctx context.Context, key string) (BlobInfo, error) {
+	info, err := t.hot.Stat(ctx, key)
+	if !errors.Is(err, ErrNotFound) {
+		return info, err
+	}
+	return t.archive.Stat(ctx, key)
+}
+
+func (t *TieredBlobStore) Delete(ctx context.Context, key string) error {
+	if err := t.hot.Delete(ctx, key); err != nil {
+		return err
+	}
+	return t.archive.Delete(ctx, key)
+}
+
+func (t *TieredBlobStore) Touch(ctx context.Context, key string) error {
+	err := t.hot.Touch(ctx, key)
+	if !errors.Is(err, ErrNotFound) {
+		return err
+	}
+	return t.archive.Touch(ctx, key)
+}
+
+// List merges the tiers, reporting a blob in both once, as its hot copy.
+func (t *TieredBlobStore) List(ctx context.Context, prefix string, fn func(BlobInfo) error) error {
+	blobs := make(map[string]BlobInfo)
+	err := t.archive.List(ctx, prefix, func(info BlobInfo) error {
+		blobs[info.Key] = info
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	err = t.hot.List(ctx, prefix, func(info BlobInfo) error {
+		blobs[info.Key] = info
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	infos := make([]BlobInfo, 0, len(blobs))
+	for _, info := range blobs {
+		infos = append(infos, info)
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Key < infos[j].Key })
+	for _, info := range infos {
+		if err := fn(info); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// IsHot reports whether the blob at key is in the hot tier.
+func (t *TieredBlobStore) IsHot(ctx context.Context, key string) (bool, error) {
+	_, err := t.hot.Stat(ctx, key)
+	if errors.Is(err, ErrNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Archive compresses the hot blob at key into the archive tier and
+// removes the hot copy, returning the bytes it freed. A blob already
+// archived is left alone.
+func (t *TieredBlobStore) Archive(ctx context.Context, key string) (int64, error) {
+	data, err := ReadBlob(ctx, t.hot, key)
+	if errors.Is(err, ErrNotFound) {
+		if _, err := t.archive.Stat(ctx, key); err != nil {
+			return 0, err
+		}
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	w, err := t.archive.Create(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+	zw := gzip.NewWriter(w)
+	if _, err := zw.Write(data); err != nil {
+		w.Abort()
+		return 0, err
+	}
+	if err := zw.Close(); err != nil {
+		w.Abort()
+		return 0, err
+	}
+	if err := w.Close(); err != nil {
+		return 0, fmt.Errorf("archive %s: %w", key, err)
+	}
+	if err := t.hot.Delete(ctx, key); err != nil {
+		return 0, err
+	}
+	return int64(len(data)), nil
+}
+
+// Rehydrate moves the blob at key back to the hot tier. A blob already
+// hot is left alone.
+func (t *TieredBlobStore) Rehydrate(ctx context.Context, key string) error {
+	hot, err := t.IsHot(ctx, key)
+	if err != nil || hot {
+		return err
+	}
+	r, err := t.Open(ctx, key)
+	if err != nil {
+		return err
+	}
+	data, err := io.ReadAll(r)
+	r.Close()
+	if err != nil {
+		return fmt.Errorf("read archived %s: %w", key, err)
+	}
+	if err := WriteBlob(ctx, t.hot, key, data); err != nil {
+		return err
+	}
+	return t.archive.Delete(ctx, key)
+}